@@ -0,0 +1,21 @@
+package main
+
+import (
+	"context"
+	"flag"
+
+	"github.com/exzacter/gorestapi/internal/migrations"
+)
+
+// applies schema.sql, safe to run on every deploy
+func dbMigrate(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("db migrate", flag.ExitOnError)
+	fs.Parse(args)
+
+	db, _ := a.database()
+	if err := migrations.Migrate(ctx, db); err != nil {
+		return err
+	}
+
+	return a.out.print(map[string]string{"status": "migrated"}, []string{"STATUS"}, [][]string{{"migrated"}})
+}
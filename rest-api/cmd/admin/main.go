@@ -0,0 +1,128 @@
+// Command admin runs common operator tasks against the same Postgres and Redis as the api, configured by
+// the same .env, so nobody has to hand-write SQL for them
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/exzacter/gorestapi/internal/dbconfig"
+	"github.com/exzacter/gorestapi/internal/serverconfig"
+	"github.com/exzacter/gorestapi/internal/store"
+	"github.com/redis/go-redis/v9"
+)
+
+const usage = `usage: admin [-o table|json] <command> [flags]
+
+commands:
+  users create --username NAME --email EMAIL [--role user|admin]   the password is read from stdin
+  users list [--limit N] [--offset N]
+  users disable --user USER
+  users enable --user USER
+  roles grant --user USER --role user|admin
+  tokens revoke --user USER [--api-keys]
+  sessions purge [--user USER]
+  db migrate
+
+USER is an id, username or email. run "admin <command> -h" for a command's flags`
+
+type command func(ctx context.Context, a *app, args []string) error
+
+var commands = map[string]command{
+	"users create":   usersCreate,
+	"users list":     usersList,
+	"users disable":  usersDisable,
+	"users enable":   usersEnable,
+	"roles grant":    rolesGrant,
+	"tokens revoke":  tokensRevoke,
+	"sessions purge": sessionsPurge,
+	"db migrate":     dbMigrate,
+}
+
+func main() {
+	global := flag.NewFlagSet("admin", flag.ExitOnError)
+	output := global.String("o", "table", "output format, table or json")
+	global.Usage = func() { fmt.Fprintln(os.Stderr, usage) }
+	global.Parse(os.Args[1:])
+
+	args := global.Args()
+	if len(args) < 2 {
+		global.Usage()
+		os.Exit(2)
+	}
+	run, ok := commands[args[0]+" "+args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "admin: unknown command %q\n\n", args[0]+" "+args[1])
+		global.Usage()
+		os.Exit(2)
+	}
+	if *output != "table" && *output != "json" {
+		fmt.Fprintf(os.Stderr, "admin: unknown output %q, expected table or json\n", *output)
+		os.Exit(2)
+	}
+
+	// the same config as the server, so the cli always talks to the same database and redis
+	config, err := serverconfig.LoadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "admin: %v\n", err)
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	a := &app{config: config, out: &printer{w: os.Stdout, json: *output == "json"}}
+	err = run(ctx, a, args[2:])
+	a.close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "admin: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// app holds what the commands share. connections are opened on first use, so a command that only needs
+// postgres works while redis is down
+type app struct {
+	config *serverconfig.Config
+	out    *printer
+
+	db      *sql.DB
+	queries *store.Queries
+	rdb     *redis.Client
+}
+
+func (a *app) database() (*sql.DB, *store.Queries) {
+	if a.db == nil {
+		a.db = dbconfig.ConnectDB(a.config.DatabaseURL)
+		a.queries = store.New(a.db)
+	}
+
+	return a.db, a.queries
+}
+
+// unlike the server the cli can't do its job without redis, so it fails instead of degrading
+func (a *app) redis(ctx context.Context) (*redis.Client, error) {
+	if a.rdb == nil {
+		a.rdb = dbconfig.ConnectRedis(a.config)
+	}
+
+	if err := a.rdb.Ping(ctx).Err(); err != nil {
+		return nil, fmt.Errorf("redis is unavailable: %w", err)
+	}
+
+	return a.rdb, nil
+}
+
+func (a *app) close() {
+	if a.db != nil {
+		a.db.Close()
+	}
+	if a.rdb != nil {
+		a.rdb.Close()
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+)
+
+// deletes login state kept in redis: sessions, pending 2fa challenges and unused magic links. jwts are
+// stateless, tokens revoke is what ends them
+func sessionsPurge(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("sessions purge", flag.ExitOnError)
+	ref := fs.String("user", "", "only this user's sessions, id, username or email")
+	fs.Parse(args)
+
+	patterns := []string{"Session:*", "2fa:challenge:*", "magic-link:*"}
+	if *ref != "" {
+		// challenges and magic links are keyed by token, not by user
+		user, err := a.findUser(ctx, *ref)
+		if err != nil {
+			return err
+		}
+		patterns = []string{fmt.Sprintf("Session:%d:*", user.ID)}
+	}
+
+	rdb, err := a.redis(ctx)
+	if err != nil {
+		return err
+	}
+
+	var deleted int64
+	for _, pattern := range patterns {
+		iter := rdb.Scan(ctx, 0, pattern, 500).Iterator()
+		var batch []string
+		for iter.Next(ctx) {
+			batch = append(batch, iter.Val())
+			if len(batch) == 500 {
+				n, err := rdb.Del(ctx, batch...).Result()
+				if err != nil {
+					return err
+				}
+				deleted += n
+				batch = batch[:0]
+			}
+		}
+		if err := iter.Err(); err != nil {
+			return err
+		}
+		if len(batch) > 0 {
+			n, err := rdb.Del(ctx, batch...).Result()
+			if err != nil {
+				return err
+			}
+			deleted += n
+		}
+	}
+
+	return a.out.print(map[string]int64{"deleted": deleted}, []string{"DELETED"}, [][]string{{strconv.FormatInt(deleted, 10)}})
+}
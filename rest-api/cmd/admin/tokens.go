@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"strconv"
+	"time"
+
+	"github.com/exzacter/gorestapi/internal/auth"
+)
+
+// every token of the user issued until now is refused, the same revocation erasing an account uses
+func (a *app) revokeTokens(ctx context.Context, userID int32) (time.Time, error) {
+	rdb, err := a.redis(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	revokedAt := time.Now()
+	err = rdb.Set(ctx, auth.TokensRevokedKey(int64(userID)), revokedAt.Unix(), auth.TokenLifetime).Err()
+	return revokedAt, err
+}
+
+type revokeResult struct {
+	UserID         int32     `json:"user_id"`
+	RevokedAt      time.Time `json:"revoked_at"`
+	APIKeysRevoked int64     `json:"api_keys_revoked"`
+}
+
+func tokensRevoke(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("tokens revoke", flag.ExitOnError)
+	ref := fs.String("user", "", "id, username or email")
+	apiKeys := fs.Bool("api-keys", false, "revoke the user's api keys too")
+	fs.Parse(args)
+
+	if *ref == "" {
+		return errors.New("--user is required")
+	}
+
+	user, err := a.findUser(ctx, *ref)
+	if err != nil {
+		return err
+	}
+
+	revokedAt, err := a.revokeTokens(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	result := revokeResult{UserID: user.ID, RevokedAt: revokedAt.UTC()}
+	if *apiKeys {
+		_, queries := a.database()
+		if result.APIKeysRevoked, err = queries.RevokeUserApiKeys(ctx, user.ID); err != nil {
+			return err
+		}
+	}
+
+	return a.out.print(result, []string{"USER", "REVOKED", "API KEYS REVOKED"}, [][]string{{
+		strconv.Itoa(int(result.UserID)),
+		formatTime(&result.RevokedAt),
+		strconv.FormatInt(result.APIKeysRevoked, 10),
+	}})
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/exzacter/gorestapi/internal/auth"
+	"github.com/exzacter/gorestapi/internal/dtos/request"
+	"github.com/exzacter/gorestapi/internal/password"
+	"github.com/exzacter/gorestapi/internal/store"
+	"github.com/exzacter/gorestapi/internal/validate"
+	"github.com/exzacter/gorestapi/internal/webhooks"
+)
+
+// what the user commands print, without the password hash
+type userView struct {
+	ID         int32      `json:"id"`
+	Username   string     `json:"username"`
+	Email      string     `json:"email"`
+	Role       string     `json:"role"`
+	DisabledAt *time.Time `json:"disabled_at"`
+	Created    *time.Time `json:"created"`
+}
+
+var userHeaders = []string{"ID", "USERNAME", "EMAIL", "ROLE", "DISABLED", "CREATED"}
+
+func (u userView) row() []string {
+	return []string{strconv.Itoa(int(u.ID)), u.Username, u.Email, u.Role, formatTime(u.DisabledAt), formatTime(u.Created)}
+}
+
+func viewUser(user store.User) userView {
+	return userView{
+		ID:         user.ID,
+		Username:   user.Username,
+		Email:      user.Email,
+		Role:       user.Role,
+		DisabledAt: nullTime(user.DisabledAt),
+		Created:    nullTime(user.Created),
+	}
+}
+
+func (a *app) printUser(user store.User) error {
+	view := viewUser(user)
+	return a.out.print(view, userHeaders, [][]string{view.row()})
+}
+
+// findUser looks a user up by id, then by username or email. a username made of digits is read as an id
+func (a *app) findUser(ctx context.Context, ref string) (store.User, error) {
+	_, queries := a.database()
+
+	id, err := strconv.ParseInt(ref, 10, 32)
+	if err != nil {
+		row, err := queries.GetUserByUsernameOrEmail(ctx, ref)
+		if errors.Is(err, sql.ErrNoRows) {
+			return store.User{}, fmt.Errorf("no user %q", ref)
+		} else if err != nil {
+			return store.User{}, err
+		}
+		id = int64(row.ID)
+	}
+
+	user, err := queries.GetUser(ctx, int32(id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return store.User{}, fmt.Errorf("no user %q", ref)
+	}
+
+	return user, err
+}
+
+func validRole(role string) error {
+	if role != auth.RoleUser && role != auth.RoleAdmin {
+		return fmt.Errorf("unknown role %q, expected %s or %s", role, auth.RoleUser, auth.RoleAdmin)
+	}
+
+	return nil
+}
+
+// creates a user the way registering does, validation, password policy and user.created webhook included. the
+// password comes from stdin so it doesn't end up in the shell history or the process list
+func usersCreate(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("users create", flag.ExitOnError)
+	username := fs.String("username", "", "username")
+	email := fs.String("email", "", "email")
+	role := fs.String("role", auth.RoleUser, "role, user or admin")
+	fs.Parse(args)
+
+	if *username == "" || *email == "" {
+		return errors.New("--username and --email are required")
+	}
+	if err := validRole(*role); err != nil {
+		return err
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return errors.New("no password on stdin")
+	}
+	plain := strings.TrimRight(line, "\r\n")
+
+	// the same rules as POST /users/register
+	if err := validate.Validate(&dtos.CreateUserRequest{Username: *username, Email: *email, Password: plain}); err != nil {
+		return err
+	}
+
+	passwords, err := password.FromConfig(a.config)
+	if err != nil {
+		return err
+	}
+	if err := passwords.Check(ctx, plain, *username, *email); err != nil {
+		return err
+	}
+	hashed, err := passwords.Hash(plain)
+	if err != nil {
+		return err
+	}
+
+	db, queries := a.database()
+	for _, taken := range []string{*username, *email} {
+		if _, err := queries.GetUserByUsernameOrEmail(ctx, taken); err == nil {
+			return fmt.Errorf("%q is already taken", taken)
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	qtx := queries.WithTx(tx)
+
+	now := sql.NullTime{Time: time.Now(), Valid: true}
+	created, err := qtx.CreateUser(ctx, store.CreateUserParams{
+		Username: *username,
+		Email:    *email,
+		Password: hashed,
+		Created:  now,
+		Updated:  now,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%q is already taken", *email)
+	} else if err != nil {
+		return err
+	}
+
+	if *role != auth.RoleUser {
+		if _, err := qtx.SetUserRole(ctx, store.SetUserRoleParams{Role: *role, ID: created.ID}); err != nil {
+			return err
+		}
+	}
+
+	if err := webhooks.Enqueue(ctx, qtx, webhooks.EventUserCreated, webhooks.UserCreated{
+		ID:       created.ID,
+		Username: created.Username,
+	}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	user, err := queries.GetUser(ctx, created.ID)
+	if err != nil {
+		return err
+	}
+
+	return a.printUser(user)
+}
+
+func usersList(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("users list", flag.ExitOnError)
+	limit := fs.Int("limit", 50, "users to show")
+	offset := fs.Int("offset", 0, "users to skip")
+	fs.Parse(args)
+
+	_, queries := a.database()
+	users, err := queries.AdminListUsers(ctx, store.AdminListUsersParams{
+		Limit:  int32(*limit),
+		Offset: int32(*offset),
+	})
+	if err != nil {
+		return err
+	}
+
+	views := []userView{}
+	var rows [][]string
+	for _, user := range users {
+		view := userView{
+			ID:         user.ID,
+			Username:   user.Username,
+			Email:      user.Email,
+			Role:       user.Role,
+			DisabledAt: nullTime(user.DisabledAt),
+			Created:    nullTime(user.Created),
+		}
+		views = append(views, view)
+		rows = append(rows, view.row())
+	}
+
+	return a.out.print(views, userHeaders, rows)
+}
+
+// disabling also revokes every token the user holds, api keys stop working through disabled_at
+func usersDisable(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("users disable", flag.ExitOnError)
+	ref := fs.String("user", "", "id, username or email")
+	fs.Parse(args)
+
+	if *ref == "" {
+		return errors.New("--user is required")
+	}
+
+	user, err := a.findUser(ctx, *ref)
+	if err != nil {
+		return err
+	}
+
+	_, queries := a.database()
+	if _, err := queries.DisableUser(ctx, user.ID); err != nil {
+		return err
+	}
+
+	if _, err := a.revokeTokens(ctx, user.ID); err != nil {
+		return fmt.Errorf("user disabled, but revoking their tokens failed, run tokens revoke: %w", err)
+	}
+
+	if user, err = queries.GetUser(ctx, user.ID); err != nil {
+		return err
+	}
+
+	return a.printUser(user)
+}
+
+func usersEnable(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("users enable", flag.ExitOnError)
+	ref := fs.String("user", "", "id, username or email")
+	fs.Parse(args)
+
+	if *ref == "" {
+		return errors.New("--user is required")
+	}
+
+	user, err := a.findUser(ctx, *ref)
+	if err != nil {
+		return err
+	}
+
+	_, queries := a.database()
+	if _, err := queries.EnableUser(ctx, user.ID); err != nil {
+		return err
+	}
+
+	if user, err = queries.GetUser(ctx, user.ID); err != nil {
+		return err
+	}
+
+	return a.printUser(user)
+}
+
+// sets the user's role. admin is the only role above user, grant user to take it away
+func rolesGrant(ctx context.Context, a *app, args []string) error {
+	fs := flag.NewFlagSet("roles grant", flag.ExitOnError)
+	ref := fs.String("user", "", "id, username or email")
+	role := fs.String("role", "", "role, user or admin")
+	fs.Parse(args)
+
+	if *ref == "" || *role == "" {
+		return errors.New("--user and --role are required")
+	}
+	if err := validRole(*role); err != nil {
+		return err
+	}
+
+	user, err := a.findUser(ctx, *ref)
+	if err != nil {
+		return err
+	}
+
+	_, queries := a.database()
+	if _, err := queries.SetUserRole(ctx, store.SetUserRoleParams{Role: *role, ID: user.ID}); err != nil {
+		return err
+	}
+
+	if user, err = queries.GetUser(ctx, user.ID); err != nil {
+		return err
+	}
+
+	return a.printUser(user)
+}
@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// keys look like gra_<prefix>_<secret>. the prefix finds the row and can be shown in listings,
+// the secret is only ever stored as a sha256 hash
+const apiKeyTag = "gra"
+
+var ErrMalformedAPIKey = errors.New("malformed api key")
+
+// no padding and lower case so keys are safe to paste anywhere
+var keyEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateAPIKey returns the full key to hand to the user once, plus the prefix and secret hash to store
+func GenerateAPIKey() (key, prefix, secretHash string, err error) {
+	prefixBytes := make([]byte, 5)
+	secretBytes := make([]byte, 20)
+	if _, err := rand.Read(prefixBytes); err != nil {
+		return "", "", "", err
+	}
+	if _, err := rand.Read(secretBytes); err != nil {
+		return "", "", "", err
+	}
+
+	prefix = strings.ToLower(keyEncoding.EncodeToString(prefixBytes))
+	secret := strings.ToLower(keyEncoding.EncodeToString(secretBytes))
+
+	return apiKeyTag + "_" + prefix + "_" + secret, prefix, HashAPIKeySecret(secret), nil
+}
+
+// ParseAPIKey splits a key into its prefix and secret
+func ParseAPIKey(key string) (prefix, secret string, err error) {
+	parts := strings.Split(key, "_")
+	if len(parts) != 3 || parts[0] != apiKeyTag || parts[1] == "" || parts[2] == "" {
+		return "", "", ErrMalformedAPIKey
+	}
+
+	return parts[1], parts[2], nil
+}
+
+// the secret has 160 random bits, so a plain sha256 is enough, no need for a slow password hash
+func HashAPIKeySecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func APIKeySecretMatches(secret, secretHash string) bool {
+	return subtle.ConstantTimeCompare([]byte(HashAPIKeySecret(secret)), []byte(secretHash)) == 1
+}
@@ -0,0 +1,25 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// GenerateInvitationToken returns the token to send to the invitee once, plus its hash to store
+func GenerateInvitationToken() (token, tokenHash string, err error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+
+	token = strings.ToLower(keyEncoding.EncodeToString(b))
+	return token, HashInvitationToken(token), nil
+}
+
+// like api keys the token is random enough for a plain sha256
+func HashInvitationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -1,11 +1,15 @@
 package auth
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// how long a login lasts
+const TokenLifetime = 24 * time.Hour
+
 type Claims struct {
 	UserID   int64  `json:"user_id"`
 	Username string `json:"username"`
@@ -17,7 +21,8 @@ func GenerateJWT(userID int64, username string, secretKey []byte) (string, error
 		UserID:   userID,
 		Username: username,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(TokenLifetime)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Issuer:    "Project Harbinger",
 		},
 	}
@@ -40,3 +45,19 @@ func ParseJWT(tokenString string, secretKey []byte) (*Claims, error) {
 	}
 	return nil, err
 }
+
+// redis key holding the unix time before which every token of the user is rejected. it only has to
+// outlive TokenLifetime, older tokens have expired anyway
+func TokensRevokedKey(userID int64) string {
+	return fmt.Sprintf("tokens-revoked:%d", userID)
+}
+
+// IssuedBefore reports whether the token was issued at or before t. tokens from before issued at was
+// recorded count as issued at the start of time
+func (c *Claims) IssuedBefore(t time.Time) bool {
+	if c.IssuedAt == nil {
+		return true
+	}
+
+	return !c.IssuedAt.Time.After(t)
+}
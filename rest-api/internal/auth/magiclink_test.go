@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMagicLinkToken(t *testing.T) {
+	key := []byte("test-secret")
+	token, nonceHash, err := GenerateMagicLinkToken("Ada@Example.com", time.Now().Add(time.Minute), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	email, parsedHash, err := ParseMagicLinkToken(token, key)
+	if err != nil {
+		t.Fatalf("ParseMagicLinkToken: %v", err)
+	}
+	if email != "ada@example.com" {
+		t.Fatalf("email = %q, want it lower cased", email)
+	}
+	if parsedHash != nonceHash {
+		t.Fatalf("nonce hash = %q, want the one handed out with the token %q", parsedHash, nonceHash)
+	}
+
+	// every link gets its own nonce, so using one never uses up another
+	other, otherHash, err := GenerateMagicLinkToken("ada@example.com", time.Now().Add(time.Minute), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other == token || otherHash == nonceHash {
+		t.Fatal("two links share a token or nonce")
+	}
+}
+
+func TestParseMagicLinkTokenRefuses(t *testing.T) {
+	key := []byte("test-secret")
+	token, _, err := GenerateMagicLinkToken("ada@example.com", time.Now().Add(time.Minute), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired, _, err := GenerateMagicLinkToken("ada@example.com", time.Now().Add(-time.Second), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encoded, sig, _ := strings.Cut(token, ".")
+	// same signature over a payload naming someone else
+	swapped := base64.RawURLEncoding.EncodeToString([]byte(`{"e":"eve@example.com","x":9999999999,"n":"abc"}`)) + "." + sig
+
+	tests := []struct {
+		name  string
+		token string
+		key   []byte
+	}{
+		{"expired", expired, key},
+		{"other key", token, []byte("another-secret")},
+		{"payload swapped", swapped, key},
+		{"signature dropped", encoded, key},
+		{"signature empty", encoded + ".", key},
+		{"signature not base64", encoded + ".!!!", key},
+		{"empty", "", key},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := ParseMagicLinkToken(tt.token, tt.key); !errors.Is(err, ErrInvalidMagicLink) {
+				t.Fatalf("ParseMagicLinkToken = %v, want ErrInvalidMagicLink", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package auth
+
+import "slices"
+
+// how a request was authenticated
+const (
+	MethodJWT    = "jwt"
+	MethodAPIKey = "api_key"
+)
+
+// Principal is whoever made the request, the same shape for a logged in user and an api key
+type Principal struct {
+	UserID   int64
+	Username string
+	Method   string
+	// what an api key was granted, jwt logins can do everything the user can
+	Scopes   []string
+	APIKeyID int32
+	// set for jwt logins only, logout needs the token expiry
+	Claims *Claims
+}
+
+func (p *Principal) HasScope(scope string) bool {
+	if p.Method == MethodJWT {
+		return true
+	}
+
+	return slices.Contains(p.Scopes, scope)
+}
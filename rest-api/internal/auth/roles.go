@@ -0,0 +1,26 @@
+package auth
+
+// roles stored in users.role
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// roles in memberships.role, each can do everything the ones below it can
+const (
+	OrgRoleOwner  = "owner"
+	OrgRoleEditor = "editor"
+	OrgRoleViewer = "viewer"
+)
+
+var orgRoleRank = map[string]int{
+	OrgRoleViewer: 1,
+	OrgRoleEditor: 2,
+	OrgRoleOwner:  3,
+}
+
+// OrgRoleAtLeast reports whether role grants everything min does, unknown roles grant nothing
+func OrgRoleAtLeast(role, min string) bool {
+	rank, ok := orgRoleRank[role]
+	return ok && rank >= orgRoleRank[min]
+}
@@ -0,0 +1,33 @@
+package auth
+
+// scopes an api key can be granted, jwt logins implicitly have all of them
+const (
+	ScopeBlogsRead      = "blogs:read"
+	ScopeBlogsWrite     = "blogs:write"
+	ScopeCommentsWrite  = "comments:write"
+	ScopeReactionsWrite = "reactions:write"
+	ScopeFollowsWrite   = "follows:write"
+	ScopeProfileRead    = "profile:read"
+	ScopeProfileWrite   = "profile:write"
+	// webhook management, the user must also be an admin
+	ScopeWebhooksManage = "webhooks:manage"
+	// background job queue stats and failed jobs, the user must also be an admin
+	ScopeJobsManage = "jobs:manage"
+	// organisations the user belongs to, what they can do inside one depends on their role there
+	ScopeOrgsRead  = "orgs:read"
+	ScopeOrgsWrite = "orgs:write"
+)
+
+var Scopes = []string{
+	ScopeBlogsRead,
+	ScopeBlogsWrite,
+	ScopeCommentsWrite,
+	ScopeReactionsWrite,
+	ScopeFollowsWrite,
+	ScopeProfileRead,
+	ScopeProfileWrite,
+	ScopeWebhooksManage,
+	ScopeJobsManage,
+	ScopeOrgsRead,
+	ScopeOrgsWrite,
+}
@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/pquerna/otp/totp"
+)
+
+// shown as the account label in authenticator apps, same issuer the jwt uses
+const totpIssuer = "Project Harbinger"
+
+// how many recovery codes a user gets when 2fa is confirmed
+const RecoveryCodeCount = 10
+
+// GenerateTOTP makes a new RFC 6238 secret (sha1, 6 digits, 30 second steps) and the otpauth:// uri
+// authenticator apps read, usually from a qr code
+func GenerateTOTP(accountName string) (secret, provisioningURI string, err error) {
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      totpIssuer,
+		AccountName: accountName,
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	return key.Secret(), key.URL(), nil
+}
+
+// ValidateTOTP accepts the current code and the ones either side of it, to allow for clock drift
+func ValidateTOTP(code, secret string) bool {
+	return totp.Validate(code, secret)
+}
+
+// GenerateRecoveryCodes returns codes like abcd-efgh-ijkl-mnop to show the user once, and the hashes to store
+func GenerateRecoveryCodes(n int) (codes, hashes []string, err error) {
+	for i := 0; i < n; i++ {
+		raw := make([]byte, 10)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, err
+		}
+
+		encoded := strings.ToLower(keyEncoding.EncodeToString(raw))
+		code := encoded[0:4] + "-" + encoded[4:8] + "-" + encoded[8:12] + "-" + encoded[12:16]
+
+		codes = append(codes, code)
+		hashes = append(hashes, HashRecoveryCode(code))
+	}
+
+	return codes, hashes, nil
+}
+
+// codes are compared without dashes, spaces or case so they can be typed however the user likes
+func HashRecoveryCode(code string) string {
+	normalised := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalised))
+	return hex.EncodeToString(sum[:])
+}
+
+// six digits is a totp code, anything else is tried as a recovery code
+func IsTOTPCode(code string) bool {
+	if len(code) != 6 {
+		return false
+	}
+
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return true
+}
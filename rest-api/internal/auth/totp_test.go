@@ -0,0 +1,137 @@
+package auth
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+)
+
+func TestValidateTOTPWindow(t *testing.T) {
+	secret, uri, err := GenerateTOTP("ada@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(uri, "otpauth://totp/") || !strings.Contains(uri, "secret="+secret) {
+		t.Fatalf("provisioning uri = %q", uri)
+	}
+
+	now := time.Now()
+	tests := []struct {
+		name   string
+		offset time.Duration
+		want   bool
+	}{
+		{"current step", 0, true},
+		// one step either side is allowed for clock drift
+		{"previous step", -30 * time.Second, true},
+		{"next step", 30 * time.Second, true},
+		{"two steps behind", -60 * time.Second, false},
+		{"two steps ahead", 60 * time.Second, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := totp.GenerateCode(secret, now.Add(tt.offset))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := ValidateTOTP(code, secret); got != tt.want {
+				t.Fatalf("ValidateTOTP(code from %v) = %v, want %v", tt.offset, got, tt.want)
+			}
+		})
+	}
+
+	other, _, err := GenerateTOTP("ada@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := totp.GenerateCode(other, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ValidateTOTP(code, secret) {
+		t.Fatal("a code for another secret was accepted")
+	}
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, hashes, err := GenerateRecoveryCodes(RecoveryCodeCount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != RecoveryCodeCount || len(hashes) != RecoveryCodeCount {
+		t.Fatalf("got %d codes and %d hashes, want %d", len(codes), len(hashes), RecoveryCodeCount)
+	}
+
+	format := regexp.MustCompile(`^[a-z2-7]{4}-[a-z2-7]{4}-[a-z2-7]{4}-[a-z2-7]{4}$`)
+	seen := map[string]bool{}
+	for i, code := range codes {
+		if !format.MatchString(code) {
+			t.Fatalf("code %q isn't four groups of four", code)
+		}
+		if seen[code] {
+			t.Fatalf("code %q handed out twice", code)
+		}
+		seen[code] = true
+
+		if hashes[i] != HashRecoveryCode(code) {
+			t.Fatalf("hash %d doesn't belong to code %q", i, code)
+		}
+		// recovery codes are never mistaken for totp codes
+		if IsTOTPCode(code) {
+			t.Fatalf("IsTOTPCode(%q) = true", code)
+		}
+	}
+}
+
+func TestHashRecoveryCode(t *testing.T) {
+	want := HashRecoveryCode("abcd-efgh-ijkl-mnop")
+
+	tests := []struct {
+		name  string
+		typed string
+		same  bool
+	}{
+		{"as shown", "abcd-efgh-ijkl-mnop", true},
+		{"upper case", "ABCD-EFGH-IJKL-MNOP", true},
+		{"without dashes", "abcdefghijklmnop", true},
+		{"with spaces", "abcd efgh ijkl mnop", true},
+		{"one character off", "abcd-efgh-ijkl-mnoq", false},
+		{"truncated", "abcd-efgh-ijkl", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HashRecoveryCode(tt.typed) == want; got != tt.same {
+				t.Fatalf("HashRecoveryCode(%q) matches = %v, want %v", tt.typed, got, tt.same)
+			}
+		})
+	}
+}
+
+func TestIsTOTPCode(t *testing.T) {
+	tests := []struct {
+		code string
+		want bool
+	}{
+		{"123456", true},
+		{"000000", true},
+		{"12345", false},
+		{"1234567", false},
+		{"12345a", false},
+		{"12 456", false},
+		{"", false},
+		{"abcd-efgh-ijkl-mnop", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			if got := IsTOTPCode(tt.code); got != tt.want {
+				t.Fatalf("IsTOTPCode(%q) = %v, want %v", tt.code, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package breaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrOpen is returned instead of calling through while the breaker is open
+var ErrOpen = errors.New("circuit breaker is open")
+
+type State int
+
+const (
+	// calls go through, failures are counted
+	Closed State = iota
+	// the cooldown is over, one call goes through to find out whether the dependency is back
+	HalfOpen
+	// calls fail straight away with ErrOpen until the cooldown is over
+	Open
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case HalfOpen:
+		return "half_open"
+	case Open:
+		return "open"
+	default:
+		return "unknown"
+	}
+}
+
+type Options struct {
+	// consecutive failures that open the breaker
+	Threshold int
+	// how long it stays open before a trial call is let through
+	Cooldown time.Duration
+	// called on every state change, outside the lock
+	OnStateChange func(from, to State)
+}
+
+// Breaker stops calls to a dependency that keeps failing, so requests fail fast instead of each waiting
+// on a timeout, and the dependency isn't hammered while it recovers
+type Breaker struct {
+	opts Options
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	// a trial call is in flight, everyone else still gets ErrOpen
+	probing bool
+}
+
+func New(opts Options) *Breaker {
+	if opts.Threshold <= 0 {
+		opts.Threshold = 1
+	}
+
+	return &Breaker{opts: opts}
+}
+
+// Allow reports whether a call may go ahead. every call it allows must be followed by Record or Release
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+
+	var from State
+	changed := false
+	switch b.state {
+	case Open:
+		if time.Since(b.openedAt) < b.opts.Cooldown {
+			b.mu.Unlock()
+			return ErrOpen
+		}
+		from, changed = b.state, true
+		b.state = HalfOpen
+		b.probing = true
+	case HalfOpen:
+		if b.probing {
+			b.mu.Unlock()
+			return ErrOpen
+		}
+		b.probing = true
+	}
+
+	b.mu.Unlock()
+	if changed {
+		b.notify(from, HalfOpen)
+	}
+
+	return nil
+}
+
+// Record reports how an allowed call went. a success closes the breaker, a failed trial call or
+// Threshold failures in a row open it
+func (b *Breaker) Record(failed bool) {
+	b.mu.Lock()
+
+	from := b.state
+	b.probing = false
+	if failed {
+		b.failures++
+		if b.state == HalfOpen || b.failures >= b.opts.Threshold {
+			b.state = Open
+			b.openedAt = time.Now()
+		}
+	} else {
+		b.failures = 0
+		b.state = Closed
+	}
+	to := b.state
+
+	b.mu.Unlock()
+	if from != to {
+		b.notify(from, to)
+	}
+}
+
+// Release ends an allowed call that says nothing either way about the dependency, such as one the caller
+// gave up on. the state is left as it is, a trial call lets the next call try instead
+func (b *Breaker) Release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+// State is the current state. an open breaker past its cooldown still reads Open until a call tries it
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
+
+func (b *Breaker) notify(from, to State) {
+	if b.opts.OnStateChange != nil {
+		b.opts.OnStateChange(from, to)
+	}
+}
@@ -0,0 +1,180 @@
+package breaker
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+// one thing done to the breaker and what should come of it
+type step struct {
+	// allow, fail, succeed or release
+	action string
+	// for allow, whether ErrOpen is expected
+	open bool
+	// the state afterwards
+	state State
+}
+
+func TestBreaker(t *testing.T) {
+	tests := []struct {
+		name     string
+		cooldown time.Duration
+		steps    []step
+		// every state change, as from->to
+		changes []string
+	}{
+		{
+			name:     "failures below the threshold",
+			cooldown: time.Hour,
+			steps: []step{
+				{"allow", false, Closed}, {"fail", false, Closed},
+				{"allow", false, Closed}, {"fail", false, Closed},
+				{"allow", false, Closed},
+			},
+		},
+		{
+			name:     "a success resets the count",
+			cooldown: time.Hour,
+			steps: []step{
+				{"allow", false, Closed}, {"fail", false, Closed},
+				{"allow", false, Closed}, {"fail", false, Closed},
+				{"allow", false, Closed}, {"succeed", false, Closed},
+				{"allow", false, Closed}, {"fail", false, Closed},
+				{"allow", false, Closed}, {"fail", false, Closed},
+			},
+		},
+		{
+			name:     "threshold failures open it",
+			cooldown: time.Hour,
+			steps: []step{
+				{"allow", false, Closed}, {"fail", false, Closed},
+				{"allow", false, Closed}, {"fail", false, Closed},
+				{"allow", false, Closed}, {"fail", false, Open},
+				{"allow", true, Open},
+				{"allow", true, Open},
+			},
+			changes: []string{"closed->open"},
+		},
+		{
+			name: "a trial call that succeeds closes it",
+			steps: []step{
+				{"allow", false, Closed}, {"fail", false, Closed},
+				{"allow", false, Closed}, {"fail", false, Closed},
+				{"allow", false, Closed}, {"fail", false, Open},
+				{"allow", false, HalfOpen},
+				// only one trial call at a time
+				{"allow", true, HalfOpen},
+				{"succeed", false, Closed},
+				{"allow", false, Closed},
+			},
+			changes: []string{"closed->open", "open->half_open", "half_open->closed"},
+		},
+		{
+			name: "a trial call that fails opens it again",
+			steps: []step{
+				{"allow", false, Closed}, {"fail", false, Closed},
+				{"allow", false, Closed}, {"fail", false, Closed},
+				{"allow", false, Closed}, {"fail", false, Open},
+				{"allow", false, HalfOpen},
+				{"fail", false, Open},
+			},
+			changes: []string{"closed->open", "open->half_open", "half_open->open"},
+		},
+		{
+			name: "a released trial call lets the next call try",
+			steps: []step{
+				{"allow", false, Closed}, {"fail", false, Closed},
+				{"allow", false, Closed}, {"fail", false, Closed},
+				{"allow", false, Closed}, {"fail", false, Open},
+				{"allow", false, HalfOpen},
+				{"release", false, HalfOpen},
+				{"allow", false, HalfOpen},
+				{"allow", true, HalfOpen},
+				{"succeed", false, Closed},
+			},
+			changes: []string{"closed->open", "open->half_open", "half_open->closed"},
+		},
+		{
+			name:     "release doesn't count as a failure",
+			cooldown: time.Hour,
+			steps: []step{
+				{"allow", false, Closed}, {"fail", false, Closed},
+				{"allow", false, Closed}, {"fail", false, Closed},
+				{"allow", false, Closed}, {"release", false, Closed},
+				{"allow", false, Closed},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var changes []string
+			b := New(Options{
+				Threshold: 3,
+				Cooldown:  tt.cooldown,
+				OnStateChange: func(from, to State) {
+					changes = append(changes, fmt.Sprintf("%s->%s", from, to))
+				},
+			})
+
+			for i, s := range tt.steps {
+				switch s.action {
+				case "allow":
+					if err := b.Allow(); errors.Is(err, ErrOpen) != s.open {
+						t.Fatalf("step %d: Allow = %v, want open %v", i, err, s.open)
+					}
+				case "fail":
+					b.Record(true)
+				case "succeed":
+					b.Record(false)
+				case "release":
+					b.Release()
+				}
+
+				if got := b.State(); got != s.state {
+					t.Fatalf("step %d %s: state = %s, want %s", i, s.action, got, s.state)
+				}
+			}
+
+			if !slices.Equal(changes, tt.changes) {
+				t.Fatalf("state changes = %v, want %v", changes, tt.changes)
+			}
+		})
+	}
+}
+
+func TestBreakerCooldown(t *testing.T) {
+	b := New(Options{Threshold: 1, Cooldown: 20 * time.Millisecond})
+
+	b.Allow()
+	b.Record(true)
+	if err := b.Allow(); !errors.Is(err, ErrOpen) {
+		t.Fatalf("Allow during the cooldown = %v, want ErrOpen", err)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	// an open breaker past its cooldown reads open until a call tries it
+	if b.State() != Open {
+		t.Fatalf("state = %s, want open", b.State())
+	}
+	if err := b.Allow(); err != nil {
+		t.Fatalf("Allow after the cooldown = %v, want a trial call", err)
+	}
+	if b.State() != HalfOpen {
+		t.Fatalf("state = %s, want half_open", b.State())
+	}
+}
+
+func TestNewThreshold(t *testing.T) {
+	// a threshold of zero would never open, one failure does instead
+	b := New(Options{Cooldown: time.Hour})
+
+	b.Allow()
+	b.Record(true)
+	if b.State() != Open {
+		t.Fatalf("state = %s, want open", b.State())
+	}
+}
@@ -0,0 +1,70 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisHook puts every command through the breaker. add it before any other hook, so commands it
+// turns away never reach the metrics and tracing hooks
+type RedisHook struct {
+	Breaker *Breaker
+}
+
+func (h RedisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (h RedisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if err := h.Breaker.Allow(); err != nil {
+			cmd.SetErr(err)
+			return err
+		}
+
+		err := next(ctx, cmd)
+		h.settle(ctx, err)
+		return err
+	}
+}
+
+func (h RedisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if err := h.Breaker.Allow(); err != nil {
+			for _, cmd := range cmds {
+				cmd.SetErr(err)
+			}
+			return err
+		}
+
+		err := next(ctx, cmds)
+		h.settle(ctx, err)
+		return err
+	}
+}
+
+// a command the caller cancelled or ran out of time for says nothing about redis, a client that hangs
+// up mid request mustn't count towards opening the breaker
+func (h RedisHook) settle(ctx context.Context, err error) {
+	if ctx.Err() != nil && (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) {
+		h.Breaker.Release()
+		return
+	}
+
+	h.Breaker.Record(redisFailure(err))
+}
+
+// redis answering at all is a success, even with a missing key or an error reply such as WRONGTYPE.
+// timeouts, refused connections and an exhausted pool are failures
+func redisFailure(err error) bool {
+	if err == nil || err == redis.Nil {
+		return false
+	}
+
+	var reply redis.Error
+	return !errors.As(err, &reply)
+}
+
+var _ redis.Hook = RedisHook{}
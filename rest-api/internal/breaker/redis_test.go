@@ -0,0 +1,84 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// an error reply from the server, what redis.Error stands for
+type replyError string
+
+func (e replyError) Error() string { return string(e) }
+func (replyError) RedisError()     {}
+
+func TestRedisFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"no error", nil, false},
+		{"missing key", redis.Nil, false},
+		// redis answered, the command was wrong
+		{"error reply", replyError("WRONGTYPE Operation against a key holding the wrong kind of value"), false},
+		{"refused", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, true},
+		{"pool timeout", redis.ErrPoolTimeout, true},
+		{"deadline", context.DeadlineExceeded, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redisFailure(tt.err); got != tt.want {
+				t.Fatalf("redisFailure(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedisHook(t *testing.T) {
+	refused := &net.OpError{Op: "dial", Err: errors.New("connection refused")}
+
+	b := New(Options{Threshold: 2, Cooldown: time.Hour})
+	hook := RedisHook{Breaker: b}
+
+	var err error
+	calls := 0
+	process := hook.ProcessHook(func(ctx context.Context, cmd redis.Cmder) error {
+		calls++
+		return err
+	})
+
+	// a caller giving up isn't redis failing
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = context.Canceled
+	for range 3 {
+		process(ctx, redis.NewCmd(ctx, "get", "k"))
+	}
+	if b.State() != Closed {
+		t.Fatalf("state after cancelled commands = %s, want closed", b.State())
+	}
+
+	err = refused
+	for range 2 {
+		process(context.Background(), redis.NewCmd(context.Background(), "get", "k"))
+	}
+	if b.State() != Open {
+		t.Fatalf("state after refused connections = %s, want open", b.State())
+	}
+
+	// turned away before reaching redis
+	calls = 0
+	cmd := redis.NewCmd(context.Background(), "get", "k")
+	if got := process(context.Background(), cmd); !errors.Is(got, ErrOpen) || !errors.Is(cmd.Err(), ErrOpen) {
+		t.Fatalf("process while open = %v, cmd error %v, want ErrOpen", got, cmd.Err())
+	}
+	if calls != 0 {
+		t.Fatalf("redis was called %d times while open", calls)
+	}
+}
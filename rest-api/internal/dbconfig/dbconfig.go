@@ -2,7 +2,6 @@ package dbconfig
 
 import (
 	"database/sql"
-	"fmt"
 	"log"
 
 	_ "github.com/lib/pq"
@@ -18,6 +17,6 @@ func ConnectDB(databaseURL string) *sql.DB {
 		log.Fatalf("Database connection failed: %v", err)
 	}
 
-	fmt.Println("Connected to the database successfully")
+	log.Println("Connected to the database successfully")
 	return db
 }
@@ -2,7 +2,10 @@ package dbconfig
 
 import (
 	"context"
-	"fmt"
+	"log"
+
+	"github.com/exzacter/gorestapi/internal/breaker"
+	"github.com/exzacter/gorestapi/internal/metrics"
 	"github.com/exzacter/gorestapi/internal/serverconfig"
 	"github.com/redis/go-redis/v9"
 )
@@ -11,7 +14,12 @@ var Ctx = context.Background()
 
 var RedisClient *redis.Client
 
-func ConnectRedis() *redis.Client {
+// every redis command goes through this, /health reports its state
+var RedisBreaker *breaker.Breaker
+
+// ConnectRedis doesn't fail when redis is down. the api starts degraded and the breaker lets commands
+// through again once redis answers
+func ConnectRedis(config *serverconfig.Config) *redis.Client {
 	addr := serverconfig.GetEnv("REDIS_ADDR", "localhost:6379")
 	password := serverconfig.GetEnv("REDIS_PASSWORD", "")
 
@@ -23,15 +31,26 @@ func ConnectRedis() *redis.Client {
 		DB:       db,
 	})
 
-	// test connection
-	_, err := rdb.Ping(Ctx).Result()
-	if err != nil {
-		panic(fmt.Sprintf("Failed to connect to redis: %w", err))
-	}
+	RedisBreaker = breaker.New(breaker.Options{
+		Threshold: int(config.RedisBreakerThreshold),
+		Cooldown:  config.RedisBreakerCooldown,
+		OnStateChange: func(from, to breaker.State) {
+			log.Printf("Redis circuit breaker %s -> %s", from, to)
+			metrics.RedisCircuit(to.String())
+		},
+	})
+	// first, so commands the breaker turns away never reach the hooks main.go adds
+	rdb.AddHook(breaker.RedisHook{Breaker: RedisBreaker})
 
 	RedisClient = rdb
 
-	fmt.Println("Connected to redis successfully")
+	// test connection
+	if _, err := rdb.Ping(Ctx).Result(); err != nil {
+		log.Printf("Redis is unavailable, starting degraded: %v", err)
+		return rdb
+	}
+
+	log.Println("Connected to redis successfully")
 
 	return rdb
 }
@@ -1,12 +1,99 @@
 package dtos
 
+import "time"
+
 type CreateUserRequest struct {
 	Username string `json:"username" validate:"required,min=3,max=30"`
 	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required,min=8"`
+	// the rest of the rules come from the password policy
+	Password string `json:"password" validate:"required"`
 }
 
 type LoginRequst struct {
 	Username string `json:"username" validate:"required,min=3,max=30"`
 	Password string `json:"password" validate:"required,min=8"`
 }
+
+type CreateBlogRequest struct {
+	Title     string     `json:"title" validate:"required,max=255"`
+	Content   string     `json:"content" validate:"required"`
+	Tags      []string   `json:"tags" validate:"max=10,dive,required,max=50"`
+	Status    string     `json:"status" validate:"omitempty,oneof=draft scheduled published"`
+	PublishAt *time.Time `json:"publish_at"`
+	// publishes under an organisation, the user has to be one of its editors
+	OrganizationID *int32 `json:"organization_id"`
+}
+
+type UpdateBlogRequest struct {
+	Title     string     `json:"title" validate:"required,max=255"`
+	Content   string     `json:"content" validate:"required"`
+	Tags      []string   `json:"tags" validate:"max=10,dive,required,max=50"`
+	Status    string     `json:"status" validate:"omitempty,oneof=draft scheduled published archived"`
+	PublishAt *time.Time `json:"publish_at"`
+}
+
+type CreateCommentRequest struct {
+	Content  string `json:"content" validate:"required,max=5000"`
+	ParentID *int32 `json:"parent_id"`
+}
+
+type UpdateCommentRequest struct {
+	Content string `json:"content" validate:"required,max=5000"`
+}
+
+type ReactionRequest struct {
+	Kind string `json:"kind" validate:"required,oneof=like love laugh wow sad angry"`
+}
+
+type CreateApiKeyRequest struct {
+	Name      string     `json:"name" validate:"required,max=100"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1,dive,oneof=blogs:read blogs:write comments:write reactions:write profile:read profile:write webhooks:manage jobs:manage orgs:read orgs:write"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type ConfirmTwoFactorRequest struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+type MagicLinkRequest struct {
+	Email string `json:"email" validate:"required,email,max=254"`
+}
+
+type VerifyMagicLinkRequest struct {
+	Token string `json:"token" validate:"required,max=1024"`
+}
+
+type LoginTwoFactorRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	// a six digit totp code or one of the recovery codes
+	Code string `json:"code" validate:"required,max=32"`
+}
+
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" validate:"required,url,startswith=http,max=2000"`
+	Events []string `json:"events" validate:"required,min=1,dive,oneof=user.created blog.published comment.created"`
+}
+
+type CreateOrganizationRequest struct {
+	Name string `json:"name" validate:"required,max=100"`
+	// derived from the name when empty
+	Slug string `json:"slug" validate:"omitempty,max=100"`
+}
+
+type InviteMemberRequest struct {
+	Email string `json:"email" validate:"required,email,max=255"`
+	Role  string `json:"role" validate:"required,oneof=owner editor viewer"`
+}
+
+type UpdateMembershipRequest struct {
+	Role string `json:"role" validate:"required,oneof=owner editor viewer"`
+}
+
+type AcceptInvitationRequest struct {
+	Token string `json:"token" validate:"required,max=64"`
+}
+
+type EraseAccountRequest struct {
+	// asked again so a stolen token alone can't erase the account
+	Password string `json:"password" validate:"required"`
+}
@@ -0,0 +1,432 @@
+package exports
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/exzacter/gorestapi/internal/jobs"
+	"github.com/exzacter/gorestapi/internal/storage"
+	"github.com/exzacter/gorestapi/internal/store"
+	"github.com/redis/go-redis/v9"
+)
+
+// states of an export
+const (
+	StatePending = "pending"
+	StateReady   = "ready"
+)
+
+// how long an export may take to build before it is forgotten and the user can ask again
+const pendingTTL = time.Hour
+
+var (
+	ErrNotFound = errors.New("export not found")
+	// the user already has an export being built
+	ErrInProgress = errors.New("export already in progress")
+)
+
+// Payload is what the export jobs carry
+type Payload struct {
+	ExportID string `json:"export_id"`
+	UserID   int32  `json:"user_id"`
+}
+
+var (
+	// builds the zip and stores it
+	Build = jobs.NewType[Payload]("user.export")
+	// deletes the zip once the download link has expired
+	Expire = jobs.NewType[Payload]("user.export.expire")
+)
+
+// Export is the state of one export, kept in redis until its link expires
+type Export struct {
+	ID        string     `json:"id"`
+	UserID    int32      `json:"user_id"`
+	State     string     `json:"state"`
+	Key       string     `json:"key,omitempty"`
+	Size      int64      `json:"size,omitempty"`
+	Created   time.Time  `json:"created"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// Exporter starts exports, builds them in a background job and hands out the finished zip
+type Exporter struct {
+	queries *store.Queries
+	storage storage.Blob
+	redis   *redis.Client
+	queue   *jobs.Queue
+	// how long a finished export can be downloaded for
+	ttl time.Duration
+}
+
+func NewExporter(queries *store.Queries, blob storage.Blob, rdb *redis.Client, queue *jobs.Queue, ttl time.Duration) *Exporter {
+	return &Exporter{
+		queries: queries,
+		storage: blob,
+		redis:   rdb,
+		queue:   queue,
+		ttl:     ttl,
+	}
+}
+
+func exportKey(id string) string {
+	return "export:" + id
+}
+
+// one export at a time per user, holds the id of the one being built
+func pendingKey(userID int32) string {
+	return fmt.Sprintf("export-pending:%d", userID)
+}
+
+// every export of a user, so they can all be discarded when the account is erased
+func userExportsKey(userID int32) string {
+	return fmt.Sprintf("exports-of:%d", userID)
+}
+
+// Register adds the export jobs to a worker, call it before Run
+func (e *Exporter) Register(w *jobs.Worker) {
+	jobs.Handle(w, Build, e.build)
+	jobs.Handle(w, Expire, e.expire)
+}
+
+// Start queues an export for the user. the id is random and doubles as the download token. with an
+// export already being built it returns that one and ErrInProgress
+func (e *Exporter) Start(ctx context.Context, userID int32) (*Export, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+
+	export := &Export{
+		ID:      base64.RawURLEncoding.EncodeToString(raw),
+		UserID:  userID,
+		State:   StatePending,
+		Created: time.Now().UTC(),
+	}
+
+	claimed, err := e.redis.SetNX(ctx, pendingKey(userID), export.ID, pendingTTL).Result()
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		id, err := e.redis.Get(ctx, pendingKey(userID)).Result()
+		if err != nil {
+			return nil, err
+		}
+		existing, err := e.Get(ctx, id)
+		if errors.Is(err, ErrNotFound) {
+			// the export expired just before its pending marker, claim again
+			if err := e.redis.Del(ctx, pendingKey(userID)).Err(); err != nil {
+				return nil, err
+			}
+			return e.Start(ctx, userID)
+		} else if err != nil {
+			return nil, err
+		}
+		return existing, ErrInProgress
+	}
+
+	if err := e.save(ctx, export, pendingTTL); err != nil {
+		return nil, err
+	}
+
+	pipe := e.redis.TxPipeline()
+	pipe.SAdd(ctx, userExportsKey(userID), export.ID)
+	pipe.Expire(ctx, userExportsKey(userID), pendingTTL+e.ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+
+	if _, err := Build.Enqueue(ctx, e.queue, Payload{ExportID: export.ID, UserID: userID}); err != nil {
+		e.redis.Del(ctx, pendingKey(userID), exportKey(export.ID))
+		return nil, err
+	}
+
+	return export, nil
+}
+
+// Get loads an export by id
+func (e *Exporter) Get(ctx context.Context, id string) (*Export, error) {
+	raw, err := e.redis.Get(ctx, exportKey(id)).Bytes()
+	if err == redis.Nil {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	var export Export
+	if err := json.Unmarshal(raw, &export); err != nil {
+		return nil, err
+	}
+
+	return &export, nil
+}
+
+// Open returns the zip of a ready export, the caller must close it. pending and expired exports are
+// ErrNotFound
+func (e *Exporter) Open(ctx context.Context, id string) (io.ReadCloser, *Export, error) {
+	export, err := e.Get(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if export.State != StateReady || export.ExpiresAt == nil || time.Now().After(*export.ExpiresAt) {
+		return nil, nil, ErrNotFound
+	}
+
+	body, _, err := e.storage.Get(ctx, export.Key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil, ErrNotFound
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	return body, export, nil
+}
+
+// Discard deletes every export of the user, finished or not. a build still running finds its export
+// gone and stops
+func (e *Exporter) Discard(ctx context.Context, userID int32) error {
+	ids, err := e.redis.SMembers(ctx, userExportsKey(userID)).Result()
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if err := e.expire(ctx, Payload{ExportID: id, UserID: userID}); err != nil {
+			return err
+		}
+	}
+
+	return e.redis.Del(ctx, userExportsKey(userID), pendingKey(userID)).Err()
+}
+
+func (e *Exporter) save(ctx context.Context, export *Export, ttl time.Duration) error {
+	raw, err := json.Marshal(export)
+	if err != nil {
+		return err
+	}
+
+	return e.redis.Set(ctx, exportKey(export.ID), raw, ttl).Err()
+}
+
+func (e *Exporter) build(ctx context.Context, p Payload) error {
+	export, err := e.Get(ctx, p.ExportID)
+	if errors.Is(err, ErrNotFound) {
+		// took longer than pendingTTL, or the account was erased meanwhile
+		return jobs.Permanent(err)
+	} else if err != nil {
+		return err
+	}
+	if export.State == StateReady {
+		return nil
+	}
+
+	archive, err := e.archive(ctx, p.UserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return jobs.Permanent(err)
+	} else if err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf("exports/%s.zip", export.ID)
+	if err := e.storage.Put(ctx, key, bytes.NewReader(archive), int64(len(archive)), "application/zip"); err != nil {
+		return err
+	}
+
+	expiresAt := time.Now().UTC().Add(e.ttl)
+	export.State = StateReady
+	export.Key = key
+	export.Size = int64(len(archive))
+	export.ExpiresAt = &expiresAt
+
+	// discarded while it was being built
+	if _, err := e.Get(ctx, export.ID); errors.Is(err, ErrNotFound) {
+		e.storage.Delete(ctx, key)
+		return jobs.Permanent(err)
+	} else if err != nil {
+		return err
+	}
+
+	// the zip goes first, a retry after this point only rebuilds it
+	if _, err := Expire.EnqueueAt(ctx, e.queue, expiresAt, p); err != nil {
+		return err
+	}
+	if err := e.save(ctx, export, e.ttl); err != nil {
+		return err
+	}
+
+	return e.redis.Del(ctx, pendingKey(p.UserID)).Err()
+}
+
+func (e *Exporter) expire(ctx context.Context, p Payload) error {
+	if err := e.storage.Delete(ctx, fmt.Sprintf("exports/%s.zip", p.ExportID)); err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return err
+	}
+
+	return e.redis.Del(ctx, exportKey(p.ExportID)).Err()
+}
+
+// what profile.json holds, the user row without the password hash
+type profile struct {
+	ID               int32      `json:"id"`
+	Username         string     `json:"username"`
+	Email            string     `json:"email"`
+	Role             string     `json:"role"`
+	AvatarKey        *string    `json:"avatar_key,omitempty"`
+	TwoFactorEnabled bool       `json:"two_factor_enabled"`
+	Created          *time.Time `json:"created,omitempty"`
+	Updated          *time.Time `json:"updated,omitempty"`
+}
+
+// a login session kept in redis under Session:<user id>:<id>
+type session struct {
+	ID        string     `json:"id"`
+	Data      string     `json:"data"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+func nullString(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// the user's sessions, read from redis as they are when the export is built
+func (e *Exporter) sessions(ctx context.Context, userID int32) ([]session, error) {
+	prefix := fmt.Sprintf("Session:%d:", userID)
+	sessions := []session{}
+
+	iter := e.redis.Scan(ctx, 0, prefix+"*", 0).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		data, err := e.redis.Get(ctx, key).Result()
+		if err == redis.Nil {
+			// expired since the scan
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		s := session{ID: strings.TrimPrefix(key, prefix), Data: data}
+		if ttl, err := e.redis.TTL(ctx, key).Result(); err == nil && ttl > 0 {
+			expiresAt := time.Now().Add(ttl).UTC()
+			s.ExpiresAt = &expiresAt
+		}
+		sessions = append(sessions, s)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// builds the zip, one json file per kind of data
+func (e *Exporter) archive(ctx context.Context, userID int32) ([]byte, error) {
+	user, err := e.queries.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	twoFactor, err := e.queries.GetUserTotp(ctx, userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	blogs, err := e.queries.ExportUserBlogs(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	comments, err := e.queries.ExportUserComments(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	apiKeys, err := e.queries.ListApiKeys(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := e.sessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	organizations, err := e.queries.ListUserOrganizations(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := e.queries.ExportUserMembershipEvents(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// both ways, who the user follows and who follows them
+	follows, err := e.queries.ExportUserFollows(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []struct {
+		name string
+		data any
+	}{
+		{"profile.json", profile{
+			ID:               user.ID,
+			Username:         user.Username,
+			Email:            user.Email,
+			Role:             user.Role,
+			AvatarKey:        nullString(user.AvatarKey),
+			TwoFactorEnabled: twoFactor.ConfirmedAt.Valid,
+			Created:          nullTime(user.Created),
+			Updated:          nullTime(user.Updated),
+		}},
+		{"blogs.json", blogs},
+		{"comments.json", comments},
+		{"api_keys.json", apiKeys},
+		{"sessions.json", sessions},
+		{"organizations.json", organizations},
+		{"audit_events.json", events},
+		{"follows.json", follows},
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, file := range files {
+		w, err := zw.Create(file.name)
+		if err != nil {
+			return nil, err
+		}
+
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(file.data); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
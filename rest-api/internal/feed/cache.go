@@ -0,0 +1,150 @@
+package feed
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// cached feeds pushed in one pipeline
+const pushBatchSize = 500
+
+// a cached feed is a sorted set of blog ids scored by publish time in microseconds, well inside the
+// integers a float64 holds exactly
+func cacheKey(userID int32) string {
+	return fmt.Sprintf("feed:%d", userID)
+}
+
+// ids are zero padded so members with the same score sort by id, like the database does
+func cacheMember(blogID int32) string {
+	return fmt.Sprintf("%010d", blogID)
+}
+
+func cacheScore(t time.Time) int64 {
+	return t.UnixMicro()
+}
+
+func entryFromZ(z redis.Z) (Entry, bool) {
+	member, ok := z.Member.(string)
+	if !ok {
+		return Entry{}, false
+	}
+
+	id, err := strconv.ParseInt(member, 10, 32)
+	if err != nil {
+		return Entry{}, false
+	}
+
+	return Entry{BlogID: int32(id), PublishedAt: time.UnixMicro(int64(z.Score)).UTC()}, true
+}
+
+// reads a page from the user's cached feed. found is false when there is no cached feed, not when it is
+// empty past the cursor
+func (f *Feed) readCache(ctx context.Context, userID int32, after *Cursor, limit int32) (entries []Entry, found bool, err error) {
+	key := cacheKey(userID)
+	max := "+inf"
+
+	pipe := f.redis.Pipeline()
+	exists := pipe.Exists(ctx, key)
+
+	// blogs published in the same microsecond as the cursor come before it or after it by id
+	var ties *redis.ZSliceCmd
+	if after != nil {
+		score := strconv.FormatInt(cacheScore(after.PublishedAt), 10)
+		ties = pipe.ZRangeArgsWithScores(ctx, redis.ZRangeArgs{Key: key, Start: score, Stop: score, ByScore: true, Rev: true})
+		max = "(" + score
+	}
+
+	older := pipe.ZRangeArgsWithScores(ctx, redis.ZRangeArgs{
+		Key:     key,
+		Start:   max,
+		Stop:    "-inf",
+		ByScore: true,
+		Rev:     true,
+		Count:   int64(limit),
+	})
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, false, err
+	}
+	if exists.Val() == 0 {
+		return nil, false, nil
+	}
+
+	if ties != nil {
+		for _, z := range ties.Val() {
+			if entry, ok := entryFromZ(z); ok && entry.BlogID < after.BlogID {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	for _, z := range older.Val() {
+		if entry, ok := entryFromZ(z); ok {
+			entries = append(entries, entry)
+		}
+	}
+
+	if int32(len(entries)) > limit {
+		entries = entries[:limit]
+	}
+
+	return entries, true, nil
+}
+
+// builds the user's cached feed from the database. it expires after CacheTTL however often it is read, so
+// a fan-out that was lost is only missing until then. a feed with nothing in it isn't cached, there is
+// nothing to save by it
+func (f *Feed) fillCache(ctx context.Context, userID int32) error {
+	entries, err := f.queryPage(ctx, userID, nil, int32(f.opts.CacheSize))
+	if err != nil || len(entries) == 0 {
+		return err
+	}
+
+	members := make([]redis.Z, 0, len(entries))
+	for _, entry := range entries {
+		members = append(members, redis.Z{Score: float64(cacheScore(entry.PublishedAt)), Member: cacheMember(entry.BlogID)})
+	}
+
+	// in a transaction, so a reader never sees the feed half built
+	key := cacheKey(userID)
+	_, err = f.redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, key)
+		pipe.ZAdd(ctx, key, members...)
+		pipe.Expire(ctx, key, f.opts.CacheTTL)
+		return nil
+	})
+	return err
+}
+
+// adds the entry to a cached feed that exists and trims it to size. a feed that isn't cached is left
+// alone, adding to it would make a cache that is missing everything published before
+var pushScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+redis.call('ZADD', KEYS[1], ARGV[1], ARGV[2])
+redis.call('ZREMRANGEBYRANK', KEYS[1], 0, -tonumber(ARGV[3]) - 1)
+return 1
+`)
+
+func (f *Feed) push(ctx context.Context, userIDs []int32, entry Entry) error {
+	score := cacheScore(entry.PublishedAt)
+	member := cacheMember(entry.BlogID)
+
+	for start := 0; start < len(userIDs); start += pushBatchSize {
+		batch := userIDs[start:min(start+pushBatchSize, len(userIDs))]
+
+		pipe := f.redis.Pipeline()
+		for _, userID := range batch {
+			pushScript.Eval(ctx, pipe, []string{cacheKey(userID)}, score, member, f.opts.CacheSize)
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package feed
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestEntryFromZ(t *testing.T) {
+	at := time.Date(2026, 1, 2, 3, 4, 5, 123456000, time.UTC)
+
+	tests := []struct {
+		name  string
+		z     redis.Z
+		want  Entry
+		valid bool
+	}{
+		{"cached entry", redis.Z{Member: cacheMember(42), Score: float64(cacheScore(at))}, Entry{BlogID: 42, PublishedAt: at}, true},
+		{"not a string", redis.Z{Member: 42, Score: 1}, Entry{}, false},
+		{"not a number", redis.Z{Member: "blog", Score: 1}, Entry{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := entryFromZ(tt.z)
+			if ok != tt.valid || got != tt.want {
+				t.Fatalf("entryFromZ = %+v, %v, want %+v, %v", got, ok, tt.want, tt.valid)
+			}
+		})
+	}
+}
+
+func TestCacheMemberOrder(t *testing.T) {
+	// redis orders members with the same score as strings, which has to agree with ids as numbers
+	ids := []int32{9, 10, 100, 2147483647, 1}
+	members := make([]string, len(ids))
+	for i, id := range ids {
+		members[i] = cacheMember(id)
+	}
+	sort.Strings(members)
+
+	want := []string{cacheMember(1), cacheMember(9), cacheMember(10), cacheMember(100), cacheMember(2147483647)}
+	for i := range want {
+		if members[i] != want[i] {
+			t.Fatalf("members sort as %v, want %v", members, want)
+		}
+	}
+}
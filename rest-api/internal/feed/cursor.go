@@ -0,0 +1,59 @@
+package feed
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCursor = errors.New("invalid feed cursor")
+
+// Entry is one blog in a feed
+type Entry struct {
+	BlogID      int32
+	PublishedAt time.Time
+}
+
+// Cursor is the last entry of a page, the next page starts after it. publish time and id together are
+// unique and never change while the blog stays published, so new posts can't shift the pages
+type Cursor struct {
+	PublishedAt time.Time
+	BlogID      int32
+}
+
+func (e Entry) Cursor() Cursor {
+	return Cursor{PublishedAt: e.PublishedAt, BlogID: e.BlogID}
+}
+
+// Encode returns the cursor as clients see it, opaque so its format can change. postgres keeps timestamps
+// to the microsecond, so that is all that is encoded
+func (c Cursor) Encode() string {
+	raw := fmt.Sprintf("%d.%d", c.PublishedAt.UnixMicro(), c.BlogID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func ParseCursor(encoded string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	microPart, idPart, found := strings.Cut(string(raw), ".")
+	if !found {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	micro, err := strconv.ParseInt(microPart, 10, 64)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	id, err := strconv.ParseInt(idPart, 10, 32)
+	if err != nil || id <= 0 {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	return Cursor{PublishedAt: time.UnixMicro(micro).UTC(), BlogID: int32(id)}, nil
+}
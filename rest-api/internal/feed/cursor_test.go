@@ -0,0 +1,74 @@
+package feed
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		cursor Cursor
+	}{
+		{"whole seconds", Cursor{PublishedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), BlogID: 7}},
+		{"microseconds", Cursor{PublishedAt: time.Date(2026, 1, 2, 3, 4, 5, 123456000, time.UTC), BlogID: 1}},
+		{"largest id", Cursor{PublishedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), BlogID: 2147483647}},
+		{"before 1970", Cursor{PublishedAt: time.Date(1969, 12, 31, 23, 59, 59, 500000000, time.UTC), BlogID: 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCursor(tt.cursor.Encode())
+			if err != nil {
+				t.Fatalf("ParseCursor: %v", err)
+			}
+			if got != tt.cursor {
+				t.Fatalf("ParseCursor(Encode()) = %+v, want %+v", got, tt.cursor)
+			}
+		})
+	}
+}
+
+func TestCursorEncodeDropsNanoseconds(t *testing.T) {
+	// postgres keeps microseconds, anything finer would never match a row again
+	at := time.Date(2026, 1, 2, 3, 4, 5, 123456789, time.FixedZone("AEST", 10*60*60))
+
+	got, err := ParseCursor(Cursor{PublishedAt: at, BlogID: 7}.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := at.Truncate(time.Microsecond).UTC(); got.PublishedAt != want {
+		t.Fatalf("PublishedAt = %v, want %v", got.PublishedAt, want)
+	}
+}
+
+func TestParseCursorRejects(t *testing.T) {
+	encode := func(raw string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(raw))
+	}
+
+	tests := []struct {
+		name    string
+		encoded string
+	}{
+		{"empty", ""},
+		{"not base64", "!!!"},
+		{"no separator", encode("1767323045000000")},
+		{"time not a number", encode("yesterday.7")},
+		{"id not a number", encode("1767323045000000.seven")},
+		{"id zero", encode("1767323045000000.0")},
+		{"id negative", encode("1767323045000000.-7")},
+		{"id past int32", encode("1767323045000000.2147483648")},
+		{"extra part", encode("1767323045000000.7.1")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseCursor(tt.encoded); !errors.Is(err, ErrInvalidCursor) {
+				t.Fatalf("ParseCursor(%q) = %v, want ErrInvalidCursor", tt.encoded, err)
+			}
+		})
+	}
+}
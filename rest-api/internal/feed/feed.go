@@ -0,0 +1,175 @@
+package feed
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/exzacter/gorestapi/internal/jobs"
+	"github.com/exzacter/gorestapi/internal/store"
+	"github.com/redis/go-redis/v9"
+)
+
+type Options struct {
+	// users following at least this many get their feed cached in redis, 0 turns the cache off
+	CacheMinFollowing int64
+	// newest entries kept in a cached feed, older pages are read from the database
+	CacheSize int64
+	// how long a cached feed is used before it is rebuilt from the database
+	CacheTTL time.Duration
+}
+
+// Published is the payload of the fan-out job
+type Published struct {
+	AuthorID    int32     `json:"author_id"`
+	BlogID      int32     `json:"blog_id"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+// FanOut pushes a newly published blog into the cached feeds of the author's followers
+var FanOut = jobs.Type[Published]{Name: "feed.fanout", MaxAttempts: 5}
+
+// Feed reads the blogs of the authors a user follows, newest first.
+//
+// for most users the feed is built on read, one query over the authors they follow. that query gets slower
+// the more authors there are, so the feeds of users following CacheMinFollowing or more are kept in redis
+// sorted sets instead, filled from the database on first read and kept current by FanOut when a followed
+// author publishes
+type Feed struct {
+	queries *store.Queries
+	redis   *redis.Client
+	queue   *jobs.Queue
+	opts    Options
+}
+
+func New(queries *store.Queries, rdb *redis.Client, queue *jobs.Queue, opts Options) *Feed {
+	return &Feed{
+		queries: queries,
+		redis:   rdb,
+		queue:   queue,
+		opts:    opts,
+	}
+}
+
+// Register adds the fan-out job to a worker, call it before Run
+func (f *Feed) Register(w *jobs.Worker) {
+	jobs.Handle(w, FanOut, f.fanOut)
+}
+
+func (f *Feed) cacheEnabled() bool {
+	return f.opts.CacheMinFollowing > 0
+}
+
+// Page returns up to limit entries of the user's feed after the cursor, or the newest with a nil cursor.
+// a cache that fails is skipped, the database has the same feed
+func (f *Feed) Page(ctx context.Context, userID int32, after *Cursor, limit int32) ([]Entry, error) {
+	if f.cacheEnabled() {
+		counts, err := f.queries.GetFollowCounts(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		if counts.Following >= f.opts.CacheMinFollowing {
+			entries, err := f.cachedPage(ctx, userID, after, limit)
+			if err == nil {
+				return entries, nil
+			}
+			log.Printf("Reading the feed of user %d from the database, the cache failed: %v", userID, err)
+		}
+	}
+
+	return f.queryPage(ctx, userID, after, limit)
+}
+
+// Published queues the fan-out of a blog that just went live. call it once the change is committed
+func (f *Feed) Published(ctx context.Context, authorID, blogID int32, publishedAt time.Time) error {
+	if !f.cacheEnabled() {
+		return nil
+	}
+
+	_, err := FanOut.Enqueue(ctx, f.queue, Published{
+		AuthorID:    authorID,
+		BlogID:      blogID,
+		PublishedAt: publishedAt,
+	})
+	return err
+}
+
+// Forget drops the user's cached feed, call it when who they follow changes. the next read rebuilds it
+func (f *Feed) Forget(ctx context.Context, userID int32) error {
+	if !f.cacheEnabled() {
+		return nil
+	}
+
+	return f.redis.Del(ctx, cacheKey(userID)).Err()
+}
+
+func (f *Feed) queryPage(ctx context.Context, userID int32, after *Cursor, limit int32) ([]Entry, error) {
+	params := store.ListFeedEntriesParams{
+		FollowerID: userID,
+		RowLimit:   limit,
+	}
+	if after != nil {
+		params.BeforePublishedAt = sql.NullTime{Time: after.PublishedAt, Valid: true}
+		params.BeforeID = sql.NullInt32{Int32: after.BlogID, Valid: true}
+	}
+
+	rows, err := f.queries.ListFeedEntries(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, 0, len(rows))
+	for _, row := range rows {
+		entries = append(entries, Entry{BlogID: row.ID, PublishedAt: row.PublishedAt.Time.UTC()})
+	}
+
+	return entries, nil
+}
+
+func (f *Feed) cachedPage(ctx context.Context, userID int32, after *Cursor, limit int32) ([]Entry, error) {
+	entries, found, err := f.readCache(ctx, userID, after, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		if err := f.fillCache(ctx, userID); err != nil {
+			return nil, err
+		}
+		if entries, _, err = f.readCache(ctx, userID, after, limit); err != nil {
+			return nil, err
+		}
+	}
+
+	// the cache only holds the newest CacheSize entries, a page running past them goes on in the database
+	if int32(len(entries)) < limit {
+		from := after
+		if len(entries) > 0 {
+			last := entries[len(entries)-1].Cursor()
+			from = &last
+		}
+
+		older, err := f.queryPage(ctx, userID, from, limit-int32(len(entries)))
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, older...)
+	}
+
+	return entries, nil
+}
+
+func (f *Feed) fanOut(ctx context.Context, p Published) error {
+	followers, err := f.queries.ListHeavyFollowers(ctx, store.ListHeavyFollowersParams{
+		FolloweeID:   p.AuthorID,
+		MinFollowing: f.opts.CacheMinFollowing,
+	})
+	if err != nil {
+		return err
+	}
+
+	// adding is idempotent, a retried job pushes the same entry again without harm
+	return f.push(ctx, followers, Entry{BlogID: p.BlogID, PublishedAt: p.PublishedAt})
+}
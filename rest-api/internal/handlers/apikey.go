@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/exzacter/gorestapi/internal/auth"
+	"github.com/exzacter/gorestapi/internal/dtos/request"
+	"github.com/exzacter/gorestapi/internal/middlewares"
+	"github.com/exzacter/gorestapi/internal/store"
+	"github.com/exzacter/gorestapi/internal/utils"
+	"github.com/exzacter/gorestapi/internal/validate"
+)
+
+func apiKeyIDFromPath(r *http.Request) (int32, error) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 32)
+	if err != nil || id <= 0 {
+		return 0, errors.New("invalid api key id")
+	}
+	return int32(id), nil
+}
+
+// create an api key. the full key is only in this response, after that just the prefix is known
+func (h *Handler) CreateApiKeyHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := r.Context().Value(middlewares.PrincipalKey).(*auth.Principal)
+		if !ok {
+			utils.RespondWithError(w, http.StatusBadRequest, "Please login to continue")
+			return
+		}
+
+		var req dtos.CreateApiKeyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+			return
+		}
+
+		if err := validate.Validate(&req); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		var expiresAt sql.NullTime
+		if req.ExpiresAt != nil {
+			if !req.ExpiresAt.After(time.Now()) {
+				utils.RespondWithError(w, http.StatusBadRequest, "expires_at must be in the future")
+				return
+			}
+			expiresAt = sql.NullTime{Time: req.ExpiresAt.UTC(), Valid: true}
+		}
+
+		key, prefix, secretHash, err := auth.GenerateAPIKey()
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error creating api key")
+			return
+		}
+
+		slices.Sort(req.Scopes)
+		created, err := h.Queries.CreateApiKey(r.Context(), store.CreateApiKeyParams{
+			UserID:     int32(principal.UserID),
+			Name:       req.Name,
+			Prefix:     prefix,
+			SecretHash: secretHash,
+			Scopes:     slices.Compact(req.Scopes),
+			ExpiresAt:  expiresAt,
+		})
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error creating api key")
+			return
+		}
+
+		utils.RespondWithSucess(w, http.StatusCreated, "api key created, store it now as it won't be shown again", map[string]interface{}{
+			"key":     key,
+			"api_key": created,
+		})
+	}
+}
+
+// list the current users api keys, revoked ones included
+func (h *Handler) ListApiKeysHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := r.Context().Value(middlewares.PrincipalKey).(*auth.Principal)
+		if !ok {
+			utils.RespondWithError(w, http.StatusBadRequest, "Please login to continue")
+			return
+		}
+
+		keys, err := h.Queries.ListApiKeys(r.Context(), int32(principal.UserID))
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error fetching api keys")
+			return
+		}
+
+		utils.RespondWithSucess(w, http.StatusOK, "Success", keys)
+	}
+}
+
+// revoke one of the current users api keys, it stops working straight away
+func (h *Handler) RevokeApiKeyHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := r.Context().Value(middlewares.PrincipalKey).(*auth.Principal)
+		if !ok {
+			utils.RespondWithError(w, http.StatusBadRequest, "Please login to continue")
+			return
+		}
+
+		id, err := apiKeyIDFromPath(r)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		revoked, err := h.Queries.RevokeApiKey(r.Context(), store.RevokeApiKeyParams{
+			ID:     id,
+			UserID: int32(principal.UserID),
+		})
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error revoking api key")
+			return
+		}
+
+		// someone elses key, an unknown id and an already revoked key all look the same
+		if revoked == 0 {
+			utils.RespondWithNotFound(w)
+			return
+		}
+
+		utils.RespondWithSucess(w, http.StatusOK, "api key revoked", nil)
+	}
+}
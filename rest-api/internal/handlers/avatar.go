@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+
+	"github.com/exzacter/gorestapi/internal/auth"
+	"github.com/exzacter/gorestapi/internal/middlewares"
+	"github.com/exzacter/gorestapi/internal/store"
+	"github.com/exzacter/gorestapi/internal/utils"
+)
+
+// sniffed content types we accept, mapped to the extension used in the storage key
+var avatarTypes = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+}
+
+const (
+	avatarMinSide = 32
+	avatarMaxSide = 4096
+)
+
+// upload a new avatar as multipart/form-data in the "avatar" field
+func (h *Handler) UploadAvatarHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := r.Context().Value(middlewares.PrincipalKey).(*auth.Principal)
+		if !ok {
+			utils.RespondWithError(w, http.StatusBadRequest, "Please login to continue")
+			return
+		}
+
+		// the avatar is part of the profile, so replacing it needs the profile's ETag
+		user, err := h.Queries.GetUser(r.Context(), int32(principal.UserID))
+		if err != nil {
+			utils.RespondWithError(w, http.StatusNotFound, "User not found")
+			return
+		}
+		if !utils.RequireIfMatch(w, r, userVersion(user.ID, user.Updated)) {
+			return
+		}
+
+		maxBytes := h.Config.AvatarMaxBytes
+
+		// cap the whole body, the multipart framing needs a little room on top of the file itself
+		r.Body = http.MaxBytesReader(w, r.Body, maxBytes+64<<10)
+
+		file, _, err := r.FormFile("avatar")
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				utils.RespondWithError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("avatar must be at most %d bytes", maxBytes))
+				return
+			}
+			utils.RespondWithError(w, http.StatusBadRequest, "avatar file is required")
+			return
+		}
+		defer file.Close()
+
+		data, err := io.ReadAll(io.LimitReader(file, maxBytes+1))
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "error reading avatar")
+			return
+		}
+
+		if int64(len(data)) > maxBytes {
+			utils.RespondWithError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("avatar must be at most %d bytes", maxBytes))
+			return
+		}
+
+		contentType, ext, err := checkAvatar(data)
+		var invalid *avatarError
+		if errors.As(err, &invalid) {
+			utils.RespondWithError(w, invalid.status, invalid.message)
+			return
+		}
+
+		// content addressed, the same image always gets the same key so it can be cached forever
+		key := fmt.Sprintf("avatars/%x%s", sha256.Sum256(data), ext)
+
+		if err := h.Storage.Put(r.Context(), key, bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error storing avatar")
+			return
+		}
+
+		updated, err := h.Queries.UpdateUserAvatar(r.Context(), store.UpdateUserAvatarParams{
+			ID:        int32(principal.UserID),
+			AvatarKey: sql.NullString{String: key, Valid: true},
+			Updated:   user.Updated,
+		})
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error saving avatar")
+			return
+		}
+		if updated == 0 {
+			// the blob stays, keys are content addressed and the same image may be uploaded again
+			utils.RespondWithPreconditionFailed(w)
+			return
+		}
+
+		// profile is cached in redis, drop it so the new avatar shows up
+		h.Redis.Del(r.Context(), fmt.Sprintf("user:%d", principal.UserID))
+
+		utils.RespondWithSucess(w, http.StatusOK, "avatar updated", map[string]string{
+			"avatar_key": key,
+			"avatar_url": "/v1/files/" + key,
+		})
+	}
+}
+
+// why an upload was refused, and the status to answer with
+type avatarError struct {
+	status  int
+	message string
+}
+
+func (e *avatarError) Error() string {
+	return e.message
+}
+
+// checks the upload is an image we accept, returning its sniffed content type and the extension for its key
+func checkAvatar(data []byte) (contentType, ext string, err error) {
+	// trust the bytes, not the filename or the client supplied content type
+	contentType = http.DetectContentType(data)
+	ext, ok := avatarTypes[contentType]
+	if !ok {
+		return "", "", &avatarError{http.StatusUnsupportedMediaType, "avatar must be a png, jpeg or gif"}
+	}
+
+	// check the dimensions from the header before decoding the whole image
+	imgConfig, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", "", &avatarError{http.StatusBadRequest, "avatar is not a valid image"}
+	}
+
+	if imgConfig.Width < avatarMinSide || imgConfig.Height < avatarMinSide || imgConfig.Width > avatarMaxSide || imgConfig.Height > avatarMaxSide {
+		return "", "", &avatarError{http.StatusBadRequest, fmt.Sprintf("avatar must be between %dx%d and %dx%d pixels", avatarMinSide, avatarMinSide, avatarMaxSide, avatarMaxSide)}
+	}
+
+	if _, _, err := image.Decode(bytes.NewReader(data)); err != nil {
+		return "", "", &avatarError{http.StatusBadRequest, "avatar is not a valid image"}
+	}
+
+	return contentType, ext, nil
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/exzacter/gorestapi/internal/storage"
+)
+
+func testPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	img.Set(0, 0, color.RGBA{R: 255, A: 255})
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestCheckAvatar(t *testing.T) {
+	valid := testPNG(t, 64, 64)
+
+	tests := []struct {
+		name   string
+		data   []byte
+		status int
+	}{
+		{"png", valid, 0},
+		{"too small", testPNG(t, 16, 16), http.StatusBadRequest},
+		{"too large", testPNG(t, avatarMaxSide+1, 40), http.StatusBadRequest},
+		{"svg", []byte(`<svg xmlns="http://www.w3.org/2000/svg"></svg>`), http.StatusUnsupportedMediaType},
+		{"text", []byte("hello"), http.StatusUnsupportedMediaType},
+		// the header says png, the rest doesn't decode
+		{"truncated png", valid[:len(valid)/2], http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contentType, ext, err := checkAvatar(tt.data)
+
+			if tt.status == 0 {
+				if err != nil {
+					t.Fatalf("checkAvatar: %v", err)
+				}
+				if contentType != "image/png" || ext != ".png" {
+					t.Fatalf("checkAvatar = %q, %q, want image/png, .png", contentType, ext)
+				}
+				return
+			}
+
+			var invalid *avatarError
+			if !errors.As(err, &invalid) || invalid.status != tt.status {
+				t.Fatalf("checkAvatar error = %v, want status %d", err, tt.status)
+			}
+		})
+	}
+}
+
+func TestServeFileHandler(t *testing.T) {
+	local, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := testPNG(t, 64, 64)
+	key := "avatars/0123abcd.png"
+	if err := local.Put(context.Background(), key, bytes.NewReader(data), int64(len(data)), "image/png"); err != nil {
+		t.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /files/{key...}", (&Handler{Storage: local}).ServeFileHandler())
+
+	serve := func(path, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve("/files/"+key, "")
+	if rec.Code != http.StatusOK || !bytes.Equal(rec.Body.Bytes(), data) {
+		t.Fatalf("GET = %d with %d bytes, want 200 with the avatar", rec.Code, rec.Body.Len())
+	}
+	if rec.Header().Get("Content-Type") != "image/png" || !strings.Contains(rec.Header().Get("Cache-Control"), "immutable") {
+		t.Fatalf("headers = %v", rec.Header())
+	}
+
+	if rec := serve("/files/"+key, rec.Header().Get("ETag")); rec.Code != http.StatusNotModified {
+		t.Fatalf("conditional GET = %d, want 304", rec.Code)
+	}
+	if rec := serve("/files/avatars/missing.png", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("missing avatar = %d, want 404", rec.Code)
+	}
+	// only avatars are public
+	if rec := serve("/files/exports/1.zip", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("non avatar key = %d, want 404", rec.Code)
+	}
+}
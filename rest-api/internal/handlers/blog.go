@@ -0,0 +1,564 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/exzacter/gorestapi/internal/auth"
+	"github.com/exzacter/gorestapi/internal/dtos/request"
+	"github.com/exzacter/gorestapi/internal/markdown"
+	"github.com/exzacter/gorestapi/internal/metrics"
+	"github.com/exzacter/gorestapi/internal/middlewares"
+	"github.com/exzacter/gorestapi/internal/models"
+	"github.com/exzacter/gorestapi/internal/sse"
+	"github.com/exzacter/gorestapi/internal/store"
+	"github.com/exzacter/gorestapi/internal/utils"
+	"github.com/exzacter/gorestapi/internal/validate"
+	"github.com/exzacter/gorestapi/internal/webhooks"
+)
+
+// length of the plain text summary stored with each blog
+const summaryLength = 200
+
+// how long rendered html stays in redis, updates clear it straight away
+const blogHTMLCacheTTL = time.Hour
+
+// keyed by version, so a read that started before an update can only cache html under the old version's
+// key, where nothing will look for it
+func blogHTMLCacheKey(id int32, updated sql.NullTime) string {
+	return "blog:html:" + blogVersion(id, updated)
+}
+
+// parse the {id} path value into a blog id
+func blogIDFromPath(r *http.Request) (int32, error) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 32)
+	if err != nil || id <= 0 {
+		return 0, errors.New("invalid blog id")
+	}
+	return int32(id), nil
+}
+
+func blogVersion(id int32, updated sql.NullTime) string {
+	return utils.Version("blog", id, updated)
+}
+
+// the json formats also carry the comment and reaction counts, which change without the blog's updated
+// timestamp moving, so they are part of the variant
+func blogETag(blog store.GetBlogRow, format string) string {
+	variant := format
+	if format == formatMarkdown || format == formatHTML {
+		sum := sha256.Sum256(fmt.Appendf(nil, "%d %s", blog.CommentCount, blog.ReactionCounts))
+		variant = fmt.Sprintf("%s-%x", format, sum[:4])
+	}
+
+	return utils.ETag(blogVersion(blog.ID, blog.Updated), variant)
+}
+
+// loads a blog that readers are allowed to see, anything not yet published (or archived) is a 404
+func (h *Handler) publishedBlog(w http.ResponseWriter, r *http.Request, id int32) (store.GetBlogRow, bool) {
+	blog, err := h.Queries.GetBlog(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && blog.Status != models.BlogStatusPublished) {
+		utils.RespondWithNotFound(w)
+		return store.GetBlogRow{}, false
+	} else if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "error fetching blog")
+		return store.GetBlogRow{}, false
+	}
+
+	return blog, true
+}
+
+// works out what to store for a requested status. fallback is used when no status was sent,
+// and current is the existing publish time so re-saving a published blog keeps its date
+func resolvePublishState(status, fallback string, publishAt *time.Time, current sql.NullTime) (string, sql.NullTime, error) {
+	if status == "" {
+		status = fallback
+	}
+
+	switch status {
+	case models.BlogStatusScheduled:
+		if publishAt == nil && current.Valid {
+			publishAt = &current.Time
+		}
+		if publishAt == nil || !publishAt.After(time.Now()) {
+			return "", sql.NullTime{}, errors.New("publish_at must be in the future for scheduled blogs")
+		}
+		return status, sql.NullTime{Time: *publishAt, Valid: true}, nil
+	case models.BlogStatusPublished:
+		if current.Valid && !current.Time.After(time.Now()) {
+			return status, current, nil
+		}
+		return status, sql.NullTime{Time: time.Now(), Valid: true}, nil
+	case models.BlogStatusArchived:
+		return status, current, nil
+	default:
+		return models.BlogStatusDraft, sql.NullTime{}, nil
+	}
+}
+
+// organisation blogs can be edited by any of its editors, the rest only by their author
+func (h *Handler) canEditBlog(ctx context.Context, blog store.GetBlogRow, userID int64) (bool, error) {
+	if !blog.OrganizationID.Valid {
+		return int64(blog.UserID) == userID, nil
+	}
+
+	role, err := h.orgRole(ctx, blog.OrganizationID.Int32, userID)
+	return auth.OrgRoleAtLeast(role, auth.OrgRoleEditor), err
+}
+
+// create blog, published straight away unless a status is given
+func (h *Handler) CreateBlogHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := r.Context().Value(middlewares.PrincipalKey).(*auth.Principal)
+		if !ok {
+			utils.RespondWithError(w, http.StatusBadRequest, "Please login to continue")
+			return
+		}
+
+		var req dtos.CreateBlogRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+			return
+		}
+
+		if err := validate.Validate(&req); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		status, publishAt, err := resolvePublishState(req.Status, models.BlogStatusPublished, req.PublishAt, sql.NullTime{})
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		// only editors and owners publish under an organisation
+		var organizationID sql.NullInt32
+		if req.OrganizationID != nil {
+			role, err := h.orgRole(r.Context(), *req.OrganizationID, principal.UserID)
+			if err != nil {
+				utils.RespondWithError(w, http.StatusInternalServerError, "error fetching membership")
+				return
+			}
+			if !auth.OrgRoleAtLeast(role, auth.OrgRoleEditor) {
+				utils.RespondWithError(w, http.StatusForbidden, "Only editors of the organisation can publish for it")
+				return
+			}
+			organizationID = nullInt32(*req.OrganizationID)
+		}
+
+		// the blog and its tags are written together
+		tx, err := h.DB.BeginTx(r.Context(), nil)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error creating blog")
+			return
+		}
+		defer tx.Rollback()
+
+		qtx := h.withTx(tx)
+
+		created, err := qtx.CreateBlog(r.Context(), store.CreateBlogParams{
+			Title:          req.Title,
+			Content:        req.Content,
+			Summary:        markdown.Summary(req.Content, summaryLength),
+			UserID:         int32(principal.UserID),
+			Status:         status,
+			PublishAt:      publishAt,
+			OrganizationID: organizationID,
+		})
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error creating blog")
+			return
+		}
+
+		if err := setBlogTags(r.Context(), qtx, created.ID, req.Tags); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error saving tags")
+			return
+		}
+
+		if created.Status == models.BlogStatusPublished {
+			if err := enqueueBlogPublished(r.Context(), qtx, created.ID, created.Title, created.UserID, created.PublishAt); err != nil {
+				utils.RespondWithError(w, http.StatusInternalServerError, "error creating blog")
+				return
+			}
+		}
+
+		if err := tx.Commit(); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error creating blog")
+			return
+		}
+
+		if created.Status == models.BlogStatusPublished {
+			h.publishStreamEvent(r.Context(), sse.EventBlogPublished, sse.Blog{
+				ID:     created.ID,
+				Title:  created.Title,
+				UserID: created.UserID,
+			})
+			h.pushToFeeds(r.Context(), created.UserID, created.ID, created.PublishAt)
+		}
+
+		blog, err := h.Queries.GetBlog(r.Context(), created.ID)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error fetching blog")
+			return
+		}
+
+		utils.RespondWithSucess(w, http.StatusCreated, "blog created", blog)
+	}
+}
+
+// list blogs, newest first. ?tag=go&tag=api filters by tag, any tag matches unless ?match=all
+func (h *Handler) ListBlogsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		page, limit, offset := utils.ParsePagination(r)
+
+		blogs, err := h.Queries.ListBlogs(r.Context(), store.ListBlogsParams{
+			Tags:      utils.SlugifyAll(r.URL.Query()["tag"]),
+			MatchAll:  r.URL.Query().Get("match") == "all",
+			RowLimit:  limit,
+			RowOffset: offset,
+		})
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error fetching blogs")
+			return
+		}
+
+		utils.RespondWithSucess(w, http.StatusOK, "Success", map[string]interface{}{
+			"blogs": blogs,
+			"page":  page,
+			"limit": limit,
+		})
+	}
+}
+
+// get a single published blog
+func (h *Handler) GetBlogHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := blogIDFromPath(r)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		blog, ok := h.publishedBlog(w, r, id)
+		if !ok {
+			return
+		}
+
+		// the same url can return different bodies depending on Accept
+		w.Header().Add("Vary", "Accept")
+
+		format := contentFormat(r)
+		if utils.NotModified(w, r, blogETag(blog, format)) {
+			return
+		}
+		if format == formatMarkdownBody {
+			w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(blog.Content))
+			return
+		}
+
+		if format == formatMarkdown {
+			utils.RespondWithSucess(w, http.StatusOK, "Success", blogResponse{GetBlogRow: blog, ContentFormat: formatMarkdown})
+			return
+		}
+
+		rendered, err := h.renderBlogHTML(r.Context(), blog)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error rendering blog")
+			return
+		}
+
+		if format == formatHTMLBody {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(rendered))
+			return
+		}
+
+		blog.Content = rendered
+		utils.RespondWithSucess(w, http.StatusOK, "Success", blogResponse{GetBlogRow: blog, ContentFormat: formatHTML})
+	}
+}
+
+// ways a single blog can be returned
+const (
+	formatMarkdown     = "markdown"      // json envelope, content is markdown
+	formatHTML         = "html"          // json envelope, content is sanitised html
+	formatHTMLBody     = "html-body"     // the sanitised html on its own as text/html
+	formatMarkdownBody = "markdown-body" // the markdown on its own as text/markdown
+)
+
+// single blog response, content_format says whether content is markdown or html
+type blogResponse struct {
+	store.GetBlogRow
+	ContentFormat string `json:"content_format"`
+}
+
+// picks how blog content should be returned. ?format= wins and keeps the json envelope, otherwise
+// an Accept header of text/html or text/markdown gets the content on its own
+func contentFormat(r *http.Request) string {
+	switch r.URL.Query().Get("format") {
+	case "html":
+		return formatHTML
+	case "markdown":
+		return formatMarkdown
+	}
+
+	accept := r.Header.Get("Accept")
+	switch {
+	case strings.Contains(accept, "application/json"):
+		return formatMarkdown
+	case strings.Contains(accept, "text/html"):
+		return formatHTMLBody
+	case strings.Contains(accept, "text/markdown"):
+		return formatMarkdownBody
+	}
+
+	return formatMarkdown
+}
+
+// renders the blog to sanitised html, using the redis copy when there is one
+func (h *Handler) renderBlogHTML(ctx context.Context, blog store.GetBlogRow) (string, error) {
+	key := blogHTMLCacheKey(blog.ID, blog.Updated)
+	if cached, err := h.Redis.Get(ctx, key).Result(); err == nil {
+		metrics.CacheLookup("blog_html", true)
+		return cached, nil
+	}
+	metrics.CacheLookup("blog_html", false)
+
+	rendered, err := markdown.ToHTML(blog.Content)
+	if err != nil {
+		return "", err
+	}
+
+	h.Redis.Set(ctx, key, rendered, blogHTMLCacheTTL)
+
+	return rendered, nil
+}
+
+// list the current users blogs in every status, so drafts and scheduled posts can be found again
+func (h *Handler) MyBlogsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := r.Context().Value(middlewares.PrincipalKey).(*auth.Principal)
+		if !ok {
+			utils.RespondWithError(w, http.StatusBadRequest, "Please login to continue")
+			return
+		}
+
+		page, limit, offset := utils.ParsePagination(r)
+
+		blogs, err := h.Queries.ListBlogsByAuthor(r.Context(), store.ListBlogsByAuthorParams{
+			UserID: int32(principal.UserID),
+			Limit:  limit,
+			Offset: offset,
+		})
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error fetching blogs")
+			return
+		}
+
+		utils.RespondWithSucess(w, http.StatusOK, "Success", map[string]interface{}{
+			"blogs": blogs,
+			"page":  page,
+			"limit": limit,
+		})
+	}
+}
+
+// update a blog and replace its tags, only the author can do this
+func (h *Handler) UpdateBlogHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := r.Context().Value(middlewares.PrincipalKey).(*auth.Principal)
+		if !ok {
+			utils.RespondWithError(w, http.StatusBadRequest, "Please login to continue")
+			return
+		}
+
+		id, err := blogIDFromPath(r)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		var req dtos.UpdateBlogRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+			return
+		}
+
+		if err := validate.Validate(&req); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		existing, err := h.Queries.GetBlog(r.Context(), id)
+		if errors.Is(err, sql.ErrNoRows) {
+			utils.RespondWithNotFound(w)
+			return
+		} else if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error fetching blog")
+			return
+		}
+
+		if allowed, err := h.canEditBlog(r.Context(), existing, principal.UserID); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error fetching membership")
+			return
+		} else if !allowed {
+			utils.RespondWithError(w, http.StatusForbidden, "You can only edit your own blogs")
+			return
+		}
+
+		// editors send back the ETag they loaded, so one can't silently overwrite another's changes
+		if !utils.RequireIfMatch(w, r, blogVersion(existing.ID, existing.Updated)) {
+			return
+		}
+
+		status, publishAt, err := resolvePublishState(req.Status, existing.Status, req.PublishAt, existing.PublishAt)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		// readers never see the blog with its old tags removed but the new ones missing
+		tx, err := h.DB.BeginTx(r.Context(), nil)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error updating blog")
+			return
+		}
+		defer tx.Rollback()
+
+		qtx := h.withTx(tx)
+
+		updated, err := qtx.UpdateBlog(r.Context(), store.UpdateBlogParams{
+			ID:        id,
+			Title:     req.Title,
+			Content:   req.Content,
+			Summary:   markdown.Summary(req.Content, summaryLength),
+			Status:    status,
+			PublishAt: publishAt,
+			Updated:   existing.Updated,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			// saved by someone else between the read above and this update
+			utils.RespondWithPreconditionFailed(w)
+			return
+		} else if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error updating blog")
+			return
+		}
+
+		if err := setBlogTags(r.Context(), qtx, id, req.Tags); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error saving tags")
+			return
+		}
+
+		// only the move to published fires the webhook, editing a live blog doesn't
+		if existing.Status != models.BlogStatusPublished && updated.Status == models.BlogStatusPublished {
+			if err := enqueueBlogPublished(r.Context(), qtx, updated.ID, updated.Title, updated.UserID, updated.PublishAt); err != nil {
+				utils.RespondWithError(w, http.StatusInternalServerError, "error updating blog")
+				return
+			}
+		}
+
+		if err := tx.Commit(); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error updating blog")
+			return
+		}
+
+		// drafts and scheduled blogs stay off the stream until they are published
+		if updated.Status == models.BlogStatusPublished {
+			eventType := sse.EventBlogUpdated
+			if existing.Status != models.BlogStatusPublished {
+				eventType = sse.EventBlogPublished
+				h.pushToFeeds(r.Context(), updated.UserID, updated.ID, updated.PublishAt)
+			}
+			h.publishStreamEvent(r.Context(), eventType, sse.Blog{
+				ID:     updated.ID,
+				Title:  updated.Title,
+				UserID: updated.UserID,
+			})
+		}
+
+		// the new version has its own key, this only cleans up the old one
+		if err := h.Redis.Del(r.Context(), blogHTMLCacheKey(id, existing.Updated)).Err(); err != nil {
+			log.Printf("Failed to clear rendered html for blog %d: %v", id, err)
+		}
+
+		blog, err := h.Queries.GetBlog(r.Context(), id)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error fetching blog")
+			return
+		}
+
+		w.Header().Set("ETag", utils.ETag(blogVersion(blog.ID, blog.Updated), ""))
+		utils.RespondWithSucess(w, http.StatusOK, "blog updated", blog)
+	}
+}
+
+// full-text search over title and content, ranked by relevance
+func (h *Handler) SearchBlogsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := strings.TrimSpace(r.URL.Query().Get("q"))
+		if query == "" {
+			utils.RespondWithError(w, http.StatusBadRequest, "q is required")
+			return
+		}
+
+		// optional ?author= filter by user id
+		var authorID sql.NullInt32
+		if author := r.URL.Query().Get("author"); author != "" {
+			id, err := strconv.ParseInt(author, 10, 32)
+			if err != nil {
+				utils.RespondWithError(w, http.StatusBadRequest, "invalid author")
+				return
+			}
+			authorID = sql.NullInt32{Int32: int32(id), Valid: true}
+		}
+
+		page, limit, offset := utils.ParsePagination(r)
+
+		results, err := h.Queries.SearchBlogs(r.Context(), store.SearchBlogsParams{
+			Query:     query,
+			AuthorID:  authorID,
+			RowLimit:  limit,
+			RowOffset: offset,
+		})
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error searching blogs")
+			return
+		}
+
+		// every row carries the full match count, so read it off the first one
+		var total int64
+		if len(results) > 0 {
+			total = results[0].Total
+		}
+
+		utils.RespondWithSucess(w, http.StatusOK, "Success", map[string]interface{}{
+			"results": results,
+			"page":    page,
+			"limit":   limit,
+			"total":   total,
+		})
+	}
+}
+
+func enqueueBlogPublished(ctx context.Context, q *store.Queries, id int32, title string, userID int32, publishAt sql.NullTime) error {
+	return webhooks.Enqueue(ctx, q, webhooks.EventBlogPublished, webhooks.BlogPublished{
+		ID:        id,
+		Title:     title,
+		UserID:    userID,
+		PublishAt: publishAt.Time,
+	})
+}
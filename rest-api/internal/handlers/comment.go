@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/exzacter/gorestapi/internal/auth"
+	"github.com/exzacter/gorestapi/internal/dtos/request"
+	"github.com/exzacter/gorestapi/internal/middlewares"
+	"github.com/exzacter/gorestapi/internal/sse"
+	"github.com/exzacter/gorestapi/internal/store"
+	"github.com/exzacter/gorestapi/internal/utils"
+	"github.com/exzacter/gorestapi/internal/validate"
+	"github.com/exzacter/gorestapi/internal/webhooks"
+)
+
+// parse the {commentID} path value
+func commentIDFromPath(r *http.Request) (int32, error) {
+	id, err := strconv.ParseInt(r.PathValue("commentID"), 10, 32)
+	if err != nil || id <= 0 {
+		return 0, errors.New("invalid comment id")
+	}
+	return int32(id), nil
+}
+
+// checks the users role in the db rather than trusting the token, so demoting an admin takes effect straight away
+func (h *Handler) isAdmin(ctx context.Context, userID int64) bool {
+	role, err := h.Queries.GetUserRole(ctx, int32(userID))
+	return err == nil && role == auth.RoleAdmin
+}
+
+// loads the comment and makes sure it belongs to the blog in the url
+func (h *Handler) commentForBlog(w http.ResponseWriter, r *http.Request) (store.BlogComment, bool) {
+	blogID, err := blogIDFromPath(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return store.BlogComment{}, false
+	}
+
+	commentID, err := commentIDFromPath(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return store.BlogComment{}, false
+	}
+
+	comment, err := h.Queries.GetBlogComment(r.Context(), commentID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && comment.BlogID != blogID) {
+		utils.RespondWithNotFound(w)
+		return store.BlogComment{}, false
+	} else if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "error fetching comment")
+		return store.BlogComment{}, false
+	}
+
+	return comment, true
+}
+
+// list comments on a blog, oldest first. replies carry parent_id so clients can build the thread
+func (h *Handler) ListCommentsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		blogID, err := blogIDFromPath(r)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if _, ok := h.publishedBlog(w, r, blogID); !ok {
+			return
+		}
+
+		comments, err := h.Queries.ListBlogComments(r.Context(), blogID)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error fetching comments")
+			return
+		}
+
+		utils.RespondWithSucess(w, http.StatusOK, "Success", comments)
+	}
+}
+
+// create a comment or a reply
+func (h *Handler) CreateCommentHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := r.Context().Value(middlewares.PrincipalKey).(*auth.Principal)
+		if !ok {
+			utils.RespondWithError(w, http.StatusBadRequest, "Please login to continue")
+			return
+		}
+
+		blogID, err := blogIDFromPath(r)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		var req dtos.CreateCommentRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+			return
+		}
+
+		if err := validate.Validate(&req); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if _, ok := h.publishedBlog(w, r, blogID); !ok {
+			return
+		}
+
+		// replies must point at a comment on the same blog
+		var parentID sql.NullInt32
+		if req.ParentID != nil {
+			parent, err := h.Queries.GetBlogComment(r.Context(), *req.ParentID)
+			if err != nil || parent.BlogID != blogID {
+				utils.RespondWithError(w, http.StatusBadRequest, "invalid parent comment")
+				return
+			}
+			parentID = sql.NullInt32{Int32: parent.ID, Valid: true}
+		}
+
+		// the comment.created webhook is queued in the same transaction as the comment
+		tx, err := h.DB.BeginTx(r.Context(), nil)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error creating comment")
+			return
+		}
+		defer tx.Rollback()
+
+		qtx := h.withTx(tx)
+
+		comment, err := qtx.CreateBlogComment(r.Context(), store.CreateBlogCommentParams{
+			BlogID:   blogID,
+			UserID:   int32(principal.UserID),
+			ParentID: parentID,
+			Content:  req.Content,
+		})
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error creating comment")
+			return
+		}
+
+		if err := webhooks.Enqueue(r.Context(), qtx, webhooks.EventCommentCreated, webhooks.CommentCreated{
+			ID:       comment.ID,
+			BlogID:   comment.BlogID,
+			UserID:   comment.UserID,
+			ParentID: req.ParentID,
+			Content:  comment.Content,
+		}); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error creating comment")
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error creating comment")
+			return
+		}
+
+		h.publishStreamEvent(r.Context(), sse.EventCommentCreated, sse.Comment{
+			ID:       comment.ID,
+			BlogID:   comment.BlogID,
+			UserID:   comment.UserID,
+			ParentID: req.ParentID,
+			Content:  comment.Content,
+		})
+
+		utils.RespondWithSucess(w, http.StatusCreated, "comment created", comment)
+	}
+}
+
+// edit a comment, only the author can do this
+func (h *Handler) UpdateCommentHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := r.Context().Value(middlewares.PrincipalKey).(*auth.Principal)
+		if !ok {
+			utils.RespondWithError(w, http.StatusBadRequest, "Please login to continue")
+			return
+		}
+
+		var req dtos.UpdateCommentRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+			return
+		}
+
+		if err := validate.Validate(&req); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		comment, ok := h.commentForBlog(w, r)
+		if !ok {
+			return
+		}
+
+		if int64(comment.UserID) != principal.UserID {
+			utils.RespondWithError(w, http.StatusForbidden, "You can only edit your own comments")
+			return
+		}
+
+		// the user_id check is repeated in the query so the update stays safe on its own
+		updated, err := h.Queries.UpdateBlogComment(r.Context(), store.UpdateBlogCommentParams{
+			ID:      comment.ID,
+			Content: req.Content,
+			UserID:  int32(principal.UserID),
+		})
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error updating comment")
+			return
+		}
+
+		utils.RespondWithSucess(w, http.StatusOK, "comment updated", updated)
+	}
+}
+
+// delete a comment, allowed for the author or an admin. replies are removed with it
+func (h *Handler) DeleteCommentHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := r.Context().Value(middlewares.PrincipalKey).(*auth.Principal)
+		if !ok {
+			utils.RespondWithError(w, http.StatusBadRequest, "Please login to continue")
+			return
+		}
+
+		comment, ok := h.commentForBlog(w, r)
+		if !ok {
+			return
+		}
+
+		if int64(comment.UserID) != principal.UserID && !h.isAdmin(r.Context(), principal.UserID) {
+			utils.RespondWithError(w, http.StatusForbidden, "You can only delete your own comments")
+			return
+		}
+
+		if _, err := h.Queries.DeleteBlogComment(r.Context(), comment.ID); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error deleting comment")
+			return
+		}
+
+		utils.RespondWithSucess(w, http.StatusOK, "comment deleted", nil)
+	}
+}
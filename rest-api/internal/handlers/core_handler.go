@@ -3,7 +3,14 @@ package handlers
 import (
 	"database/sql"
 
+	"github.com/exzacter/gorestapi/internal/feed"
+	"github.com/exzacter/gorestapi/internal/jobs"
+	"github.com/exzacter/gorestapi/internal/password"
+	"github.com/exzacter/gorestapi/internal/serverconfig"
+	"github.com/exzacter/gorestapi/internal/sse"
+	"github.com/exzacter/gorestapi/internal/storage"
 	"github.com/exzacter/gorestapi/internal/store"
+	"github.com/exzacter/gorestapi/internal/tracing"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -13,12 +20,35 @@ type Handler struct {
 	// Query stores
 	Queries *store.Queries
 	Redis   *redis.Client
+	// uploaded files (avatars)
+	Storage storage.Blob
+	// background jobs, enqueue with a jobs.Type
+	Jobs *jobs.Queue
+	// password hashing and the policy new passwords must meet
+	Passwords *password.Manager
+	// live blog activity for GET /blogs/stream, publish once a change is committed
+	Stream *sse.Broker
+	// the blogs of followed authors, with the fan-out cache for users following many
+	Feed   *feed.Feed
+	Config *serverconfig.Config
 }
 
-func NewHandlers(db *sql.DB, queries *store.Queries, redisClient *redis.Client) *Handler {
+func NewHandlers(db *sql.DB, queries *store.Queries, redisClient *redis.Client, blobStore storage.Blob, jobQueue *jobs.Queue, passwords *password.Manager, stream *sse.Broker, feeds *feed.Feed, config *serverconfig.Config) *Handler {
 	return &Handler{
-		DB:      db,
-		Queries: queries,
-		Redis:   redisClient,
+		DB:        db,
+		Queries:   queries,
+		Redis:     redisClient,
+		Storage:   blobStore,
+		Jobs:      jobQueue,
+		Passwords: passwords,
+		Stream:    stream,
+		Feed:      feeds,
+		Config:    config,
 	}
 }
+
+// queries bound to a transaction. Queries.WithTx would use the bare tx and lose the query spans, so
+// the tx gets the same tracing wrapper as the db
+func (h *Handler) withTx(tx *sql.Tx) *store.Queries {
+	return store.New(tracing.WrapDB(tx))
+}
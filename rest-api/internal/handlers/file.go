@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/exzacter/gorestapi/internal/storage"
+	"github.com/exzacter/gorestapi/internal/utils"
+)
+
+// serve an uploaded file. keys are content addressed so the response never changes and can be cached for a year
+func (h *Handler) ServeFileHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.PathValue("key")
+
+		// only public uploads are served here, exports have their own expiring link
+		if !strings.HasPrefix(key, "avatars/") {
+			utils.RespondWithNotFound(w)
+			return
+		}
+
+		// the hash in the key doubles as the etag
+		etag := `"` + path.Base(key) + `"`
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		body, info, err := h.Storage.Get(r.Context(), key)
+		if errors.Is(err, storage.ErrNotFound) {
+			utils.RespondWithNotFound(w)
+			return
+		} else if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error fetching file")
+			return
+		}
+		defer body.Close()
+
+		w.Header().Set("Content-Type", info.ContentType)
+		w.Header().Set("Content-Length", strconv.FormatInt(info.Size, 10))
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		w.Header().Set("ETag", etag)
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.WriteHeader(http.StatusOK)
+
+		io.Copy(w, body)
+	}
+}
@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/exzacter/gorestapi/internal/auth"
+	"github.com/exzacter/gorestapi/internal/feed"
+	"github.com/exzacter/gorestapi/internal/middlewares"
+	"github.com/exzacter/gorestapi/internal/store"
+	"github.com/exzacter/gorestapi/internal/utils"
+)
+
+func userIDFromPath(r *http.Request) (int32, error) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 32)
+	if err != nil || id <= 0 {
+		return 0, errors.New("invalid user id")
+	}
+	return int32(id), nil
+}
+
+// the user in the path, answering 404 for disabled accounts too
+func (h *Handler) pathUser(w http.ResponseWriter, r *http.Request) (store.User, bool) {
+	userID, err := userIDFromPath(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return store.User{}, false
+	}
+
+	user, err := h.Queries.GetUser(r.Context(), userID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && user.DisabledAt.Valid) {
+		utils.RespondWithNotFound(w)
+		return store.User{}, false
+	} else if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "error fetching user")
+		return store.User{}, false
+	}
+
+	return user, true
+}
+
+// follow another user, following someone twice is a no-op
+func (h *Handler) FollowUserHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := r.Context().Value(middlewares.PrincipalKey).(*auth.Principal)
+		if !ok {
+			utils.RespondWithError(w, http.StatusBadRequest, "Please login to continue")
+			return
+		}
+
+		user, ok := h.pathUser(w, r)
+		if !ok {
+			return
+		}
+
+		followerID := int32(principal.UserID)
+		if user.ID == followerID {
+			utils.RespondWithError(w, http.StatusBadRequest, "you can't follow yourself")
+			return
+		}
+
+		added, err := h.Queries.FollowUser(r.Context(), store.FollowUserParams{
+			FollowerID: followerID,
+			FolloweeID: user.ID,
+		})
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error following user")
+			return
+		}
+
+		if added > 0 {
+			h.forgetFeed(r.Context(), followerID)
+		}
+
+		utils.RespondWithSucess(w, http.StatusOK, "following", map[string]interface{}{
+			"id":       user.ID,
+			"username": user.Username,
+		})
+	}
+}
+
+// stop following a user
+func (h *Handler) UnfollowUserHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := r.Context().Value(middlewares.PrincipalKey).(*auth.Principal)
+		if !ok {
+			utils.RespondWithError(w, http.StatusBadRequest, "Please login to continue")
+			return
+		}
+
+		userID, err := userIDFromPath(r)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		followerID := int32(principal.UserID)
+		removed, err := h.Queries.UnfollowUser(r.Context(), store.UnfollowUserParams{
+			FollowerID: followerID,
+			FolloweeID: userID,
+		})
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error unfollowing user")
+			return
+		}
+
+		if removed == 0 {
+			utils.RespondWithNotFound(w)
+			return
+		}
+
+		h.forgetFeed(r.Context(), followerID)
+
+		utils.RespondWithSucess(w, http.StatusOK, "unfollowed", nil)
+	}
+}
+
+// who follows a user, most recent first
+func (h *Handler) ListFollowersHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := h.pathUser(w, r)
+		if !ok {
+			return
+		}
+
+		page, limit, offset := utils.ParsePagination(r)
+
+		counts, err := h.Queries.GetFollowCounts(r.Context(), user.ID)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error fetching followers")
+			return
+		}
+
+		followers, err := h.Queries.ListFollowers(r.Context(), store.ListFollowersParams{
+			FolloweeID: user.ID,
+			Limit:      limit,
+			Offset:     offset,
+		})
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error fetching followers")
+			return
+		}
+
+		utils.RespondWithSucess(w, http.StatusOK, "Success", map[string]interface{}{
+			"followers": followers,
+			"total":     counts.Followers,
+			"page":      page,
+			"limit":     limit,
+		})
+	}
+}
+
+// who a user follows, most recent first
+func (h *Handler) ListFollowingHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := h.pathUser(w, r)
+		if !ok {
+			return
+		}
+
+		page, limit, offset := utils.ParsePagination(r)
+
+		counts, err := h.Queries.GetFollowCounts(r.Context(), user.ID)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error fetching following")
+			return
+		}
+
+		following, err := h.Queries.ListFollowing(r.Context(), store.ListFollowingParams{
+			FollowerID: user.ID,
+			Limit:      limit,
+			Offset:     offset,
+		})
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error fetching following")
+			return
+		}
+
+		utils.RespondWithSucess(w, http.StatusOK, "Success", map[string]interface{}{
+			"following": following,
+			"total":     counts.Following,
+			"page":      page,
+			"limit":     limit,
+		})
+	}
+}
+
+// blogs from the authors the user follows, newest first. the next page is ?cursor=<next_cursor>, which is
+// null on the last page. a page can come back short when blogs were unpublished since the feed was cached
+func (h *Handler) FeedHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := r.Context().Value(middlewares.PrincipalKey).(*auth.Principal)
+		if !ok {
+			utils.RespondWithError(w, http.StatusBadRequest, "Please login to continue")
+			return
+		}
+
+		// only the limit, pages are found by cursor
+		_, limit, _ := utils.ParsePagination(r)
+
+		var after *feed.Cursor
+		if raw := r.URL.Query().Get("cursor"); raw != "" {
+			cursor, err := feed.ParseCursor(raw)
+			if err != nil {
+				utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			after = &cursor
+		}
+
+		entries, err := h.Feed.Page(r.Context(), int32(principal.UserID), after, limit)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error fetching feed")
+			return
+		}
+
+		blogs := []store.ListFeedBlogsRow{}
+		if len(entries) > 0 {
+			ids := make([]int32, 0, len(entries))
+			for _, entry := range entries {
+				ids = append(ids, entry.BlogID)
+			}
+
+			blogs, err = h.Queries.ListFeedBlogs(r.Context(), ids)
+			if err != nil {
+				utils.RespondWithError(w, http.StatusInternalServerError, "error fetching feed")
+				return
+			}
+		}
+
+		var next *string
+		if int32(len(entries)) == limit {
+			cursor := entries[len(entries)-1].Cursor().Encode()
+			next = &cursor
+		}
+
+		utils.RespondWithSucess(w, http.StatusOK, "Success", map[string]interface{}{
+			"blogs":       blogs,
+			"next_cursor": next,
+			"limit":       limit,
+		})
+	}
+}
+
+// a cached feed built from the old follows would be wrong, the next read builds a new one
+func (h *Handler) forgetFeed(ctx context.Context, userID int32) {
+	if err := h.Feed.Forget(context.WithoutCancel(ctx), userID); err != nil {
+		log.Printf("Failed to drop the cached feed of user %d: %v", userID, err)
+	}
+}
+
+// queues the blog for the cached feeds of the author's followers. call it once the blog is committed as
+// published
+func (h *Handler) pushToFeeds(ctx context.Context, authorID, blogID int32, publishAt sql.NullTime) {
+	if err := h.Feed.Published(context.WithoutCancel(ctx), authorID, blogID, publishAt.Time); err != nil {
+		log.Printf("Failed to queue the feed fan-out of blog %d: %v", blogID, err)
+	}
+}
@@ -3,13 +3,24 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+
+	"github.com/exzacter/gorestapi/internal/breaker"
+	"github.com/exzacter/gorestapi/internal/dbconfig"
 )
 
 func (h *Handler) HealthHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
-		response := map[string]string{
+		response := map[string]interface{}{
 			"message": "Server is Okay",
+			"redis":   dbconfig.RedisBreaker.State().String(),
+		}
+
+		// still a 200, the api keeps serving without redis and a load balancer shouldn't take every
+		// instance out at once over it
+		if dbconfig.RedisBreaker.State() != breaker.Closed {
+			response["message"] = "Server is degraded, redis is unavailable"
+			response["degraded"] = true
 		}
 
 		// encode the response as JSON and send back to client
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/exzacter/gorestapi/internal/jobs"
+	"github.com/exzacter/gorestapi/internal/utils"
+)
+
+// queue depth by state and the failed jobs, most recent first
+func (h *Handler) ListJobsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := h.requireAdmin(w, r); !ok {
+			return
+		}
+
+		page, limit, offset := utils.ParsePagination(r)
+
+		stats, err := h.Jobs.Stats(r.Context())
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error fetching job stats")
+			return
+		}
+
+		failed, err := h.Jobs.Failed(r.Context(), int(offset), int(limit))
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error fetching failed jobs")
+			return
+		}
+
+		utils.RespondWithSucess(w, http.StatusOK, "Success", map[string]interface{}{
+			"stats":  stats,
+			"failed": failed,
+			"page":   page,
+			"limit":  limit,
+		})
+	}
+}
+
+// put a failed job back on the queue with fresh attempts
+func (h *Handler) RetryJobHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := h.requireAdmin(w, r); !ok {
+			return
+		}
+
+		job, err := h.Jobs.Requeue(r.Context(), r.PathValue("id"))
+		if errors.Is(err, jobs.ErrNotFound) {
+			utils.RespondWithNotFound(w)
+			return
+		} else if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error queueing job")
+			return
+		}
+
+		utils.RespondWithSucess(w, http.StatusAccepted, "job queued", job)
+	}
+}
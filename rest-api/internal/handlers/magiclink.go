@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/exzacter/gorestapi/internal/auth"
+	"github.com/exzacter/gorestapi/internal/dtos/request"
+	"github.com/exzacter/gorestapi/internal/mailer"
+	"github.com/exzacter/gorestapi/internal/metrics"
+	"github.com/exzacter/gorestapi/internal/utils"
+	"github.com/exzacter/gorestapi/internal/validate"
+	"github.com/redis/go-redis/v9"
+)
+
+// the window MAGIC_LINK_RATE_LIMIT counts links in
+const magicLinkRateWindow = time.Hour
+
+// holds the email a link was sent to until it is used or expires
+func magicLinkKey(nonceHash string) string {
+	return "magic-link:" + nonceHash
+}
+
+// counts links sent to an address, hashed so the keys don't list everyone's email
+func magicLinkRateKey(email string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(email)))
+	return "magic-link-rate:" + hex.EncodeToString(sum[:])
+}
+
+// where links and the rate limit counters are kept, redisMagicLinkStore in the api
+type magicLinkStore interface {
+	// counts a link asked for the email, returns the count in the current window and how long the window has left
+	CountRequest(ctx context.Context, email string) (count int64, resetIn time.Duration, err error)
+	Save(ctx context.Context, nonceHash, email string, ttl time.Duration) error
+	Delete(ctx context.Context, nonceHash string) error
+	// returns the email a link was sent to and forgets it, "" when it was used already or expired
+	Consume(ctx context.Context, nonceHash string) (string, error)
+}
+
+type redisMagicLinkStore struct {
+	client *redis.Client
+}
+
+func (s redisMagicLinkStore) CountRequest(ctx context.Context, email string) (int64, time.Duration, error) {
+	key := magicLinkRateKey(email)
+
+	// the window starts with the counter in the same transaction, so the counter can't be left without
+	// an expiry and lock the address out for good
+	var count *redis.IntCmd
+	var ttl *redis.DurationCmd
+	if _, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.SetNX(ctx, key, 0, magicLinkRateWindow)
+		count = pipe.Incr(ctx, key)
+		ttl = pipe.TTL(ctx, key)
+		return nil
+	}); err != nil {
+		return 0, 0, err
+	}
+
+	return count.Val(), ttl.Val(), nil
+}
+
+func (s redisMagicLinkStore) Save(ctx context.Context, nonceHash, email string, ttl time.Duration) error {
+	return s.client.Set(ctx, magicLinkKey(nonceHash), email, ttl).Err()
+}
+
+func (s redisMagicLinkStore) Delete(ctx context.Context, nonceHash string) error {
+	return s.client.Del(ctx, magicLinkKey(nonceHash)).Err()
+}
+
+// GETDEL is what makes a link single use, only one request can get the stored email back
+func (s redisMagicLinkStore) Consume(ctx context.Context, nonceHash string) (string, error) {
+	email, err := s.client.GetDel(ctx, magicLinkKey(nonceHash)).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return email, err
+}
+
+// whether another link may be sent to the email, and how long until it may when it can't
+func magicLinkAllowed(ctx context.Context, links magicLinkStore, email string, limit int64) (bool, time.Duration, error) {
+	count, resetIn, err := links.CountRequest(ctx, email)
+	if err != nil {
+		return false, 0, err
+	}
+	if count > limit {
+		return false, resetIn, nil
+	}
+
+	return true, 0, nil
+}
+
+// the email a login link token was sent to, ErrInvalidMagicLink when it is forged, expired or used. a
+// forged or expired token never reaches the store
+func consumeMagicLink(ctx context.Context, links magicLinkStore, token string, secretKey []byte) (string, error) {
+	email, nonceHash, err := auth.ParseMagicLinkToken(token, secretKey)
+	if err != nil {
+		return "", err
+	}
+
+	stored, err := links.Consume(ctx, nonceHash)
+	if err != nil {
+		return "", err
+	}
+	// the stored email is what the link was sent to, the token is only trusted as far as it agrees
+	if stored == "" || stored != email {
+		return "", auth.ErrInvalidMagicLink
+	}
+
+	return email, nil
+}
+
+// the same answer whether or not the email has an account, so the endpoint can't be used to find users
+const magicLinkSent = "if an account uses that email, a login link is on its way"
+
+// email a single use login link. the rate limit counts every request for the address, known or not
+func (h *Handler) RequestMagicLinkHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		var req dtos.MagicLinkRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+			return
+		}
+
+		if err := validate.Validate(&req); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		links := redisMagicLinkStore{h.Redis}
+		allowed, retryAfter, err := magicLinkAllowed(ctx, links, req.Email, h.Config.MagicLinkRateLimit)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Internal error")
+			return
+		}
+		if !allowed {
+			if retryAfter > 0 {
+				w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+			}
+			utils.RespondWithError(w, http.StatusTooManyRequests, "too many login links requested, try again later")
+			return
+		}
+
+		users, err := h.Queries.ListUsersByEmail(ctx, req.Email)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Internal error")
+			return
+		}
+		// an email shared by accounts that only differ in case can't say which one to log in
+		if len(users) != 1 {
+			if len(users) > 1 {
+				log.Printf("Refused a magic link for an email used by more than one account")
+			}
+			utils.RespondWithSucess(w, http.StatusAccepted, magicLinkSent, nil)
+			return
+		}
+		user := users[0]
+
+		// the token is bound to the address the account had when it was asked for
+		jwtKey := []byte(os.Getenv("JWT_SECRET_KEY"))
+		token, nonceHash, err := auth.GenerateMagicLinkToken(user.Email, time.Now().Add(h.Config.MagicLinkTTL), jwtKey)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Internal error")
+			return
+		}
+
+		link, err := url.Parse(h.Config.MagicLinkURL)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Internal error")
+			return
+		}
+		query := link.Query()
+		query.Set("token", token)
+		link.RawQuery = query.Encode()
+
+		if err := links.Save(ctx, nonceHash, strings.ToLower(user.Email), h.Config.MagicLinkTTL); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Internal error")
+			return
+		}
+
+		// the link logs whoever has it in, so the job only carries the id of the message
+		if err := mailer.EnqueueStored(ctx, h.Jobs, h.Redis, mailer.Message{
+			To:      user.Email,
+			Subject: "Your login link",
+			Text: fmt.Sprintf("Hi %s,\n\nOpen this link to log in, it works once and expires in %s:\n\n%s\n\nIf you didn't ask for it you can ignore this email.\n",
+				user.Username, h.Config.MagicLinkTTL, link),
+		}, h.Config.MagicLinkTTL); err != nil {
+			links.Delete(ctx, nonceHash)
+			utils.RespondWithError(w, http.StatusInternalServerError, "Internal error")
+			return
+		}
+
+		utils.RespondWithSucess(w, http.StatusAccepted, magicLinkSent, nil)
+	}
+}
+
+// swap the token from a login link for a jwt, or a 2fa challenge when 2fa is on. it is a POST the
+// page behind the link makes, so mail scanners that open links can't use the token up
+func (h *Handler) VerifyMagicLinkHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		var req dtos.VerifyMagicLinkRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+			return
+		}
+
+		if err := validate.Validate(&req); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		email, err := consumeMagicLink(ctx, redisMagicLinkStore{h.Redis}, req.Token, []byte(os.Getenv("JWT_SECRET_KEY")))
+		if errors.Is(err, auth.ErrInvalidMagicLink) {
+			metrics.Login(false)
+			utils.RespondWithError(w, http.StatusUnauthorized, err.Error())
+			return
+		} else if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Internal error")
+			return
+		}
+
+		// the account must still have the address the link was sent to, and be the only one with it
+		users, err := h.Queries.ListUsersByEmail(ctx, email)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Internal error")
+			return
+		}
+		if len(users) != 1 {
+			metrics.Login(false)
+			utils.RespondWithError(w, http.StatusUnauthorized, auth.ErrInvalidMagicLink.Error())
+			return
+		}
+
+		h.completeFirstFactor(ctx, w, users[0].ID, users[0].Username)
+	}
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/exzacter/gorestapi/internal/auth"
+)
+
+// keeps links and counters in memory, every counter in one window that never ends
+type fakeMagicLinkStore struct {
+	counts map[string]int64
+	links  map[string]string
+	// how many times Consume was asked
+	consumed int
+}
+
+func newFakeMagicLinkStore() *fakeMagicLinkStore {
+	return &fakeMagicLinkStore{counts: map[string]int64{}, links: map[string]string{}}
+}
+
+func (f *fakeMagicLinkStore) CountRequest(ctx context.Context, email string) (int64, time.Duration, error) {
+	key := magicLinkRateKey(email)
+	f.counts[key]++
+	return f.counts[key], 20 * time.Minute, nil
+}
+
+func (f *fakeMagicLinkStore) Save(ctx context.Context, nonceHash, email string, ttl time.Duration) error {
+	f.links[nonceHash] = email
+	return nil
+}
+
+func (f *fakeMagicLinkStore) Delete(ctx context.Context, nonceHash string) error {
+	delete(f.links, nonceHash)
+	return nil
+}
+
+func (f *fakeMagicLinkStore) Consume(ctx context.Context, nonceHash string) (string, error) {
+	f.consumed++
+	email := f.links[nonceHash]
+	delete(f.links, nonceHash)
+	return email, nil
+}
+
+func TestMagicLinkAllowed(t *testing.T) {
+	links := newFakeMagicLinkStore()
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		email      string
+		allowed    bool
+		retryAfter time.Duration
+	}{
+		{"first", "ada@example.com", true, 0},
+		{"second", "ada@example.com", true, 0},
+		{"third", "ada@example.com", true, 0},
+		{"over the limit", "ada@example.com", false, 20 * time.Minute},
+		// the counter is per address, however it is typed
+		{"other case", "ADA@example.com", false, 20 * time.Minute},
+		{"other address", "grace@example.com", true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allowed, retryAfter, err := magicLinkAllowed(ctx, links, tt.email, 3)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if allowed != tt.allowed || retryAfter != tt.retryAfter {
+				t.Fatalf("magicLinkAllowed = %v, %v, want %v, %v", allowed, retryAfter, tt.allowed, tt.retryAfter)
+			}
+		})
+	}
+}
+
+func TestMagicLinkRateKey(t *testing.T) {
+	key := magicLinkRateKey("Ada@Example.com")
+	if key != magicLinkRateKey("ada@example.com") {
+		t.Fatal("the rate key depends on case")
+	}
+	if key == magicLinkRateKey("grace@example.com") {
+		t.Fatal("two addresses share a rate key")
+	}
+	// the keys mustn't list everyone's email
+	if strings.Contains(strings.ToLower(key), "ada") {
+		t.Fatalf("rate key %q contains the email", key)
+	}
+}
+
+func TestConsumeMagicLink(t *testing.T) {
+	key := []byte("test-secret")
+	ctx := context.Background()
+
+	issue := func(t *testing.T, links *fakeMagicLinkStore, email, stored string, expires time.Time) string {
+		t.Helper()
+		token, nonceHash, err := auth.GenerateMagicLinkToken(email, expires, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		links.Save(ctx, nonceHash, stored, time.Minute)
+		return token
+	}
+
+	t.Run("single use", func(t *testing.T) {
+		links := newFakeMagicLinkStore()
+		token := issue(t, links, "ada@example.com", "ada@example.com", time.Now().Add(time.Minute))
+
+		email, err := consumeMagicLink(ctx, links, token, key)
+		if err != nil || email != "ada@example.com" {
+			t.Fatalf("first use = %q, %v, want ada@example.com", email, err)
+		}
+		if _, err := consumeMagicLink(ctx, links, token, key); !errors.Is(err, auth.ErrInvalidMagicLink) {
+			t.Fatalf("second use = %v, want ErrInvalidMagicLink", err)
+		}
+	})
+
+	tests := []struct {
+		name  string
+		token func(links *fakeMagicLinkStore) string
+		// whether the store should have been asked, forged and expired links never reach it
+		consumed int
+	}{
+		{
+			name: "never saved",
+			token: func(links *fakeMagicLinkStore) string {
+				return issue(t, newFakeMagicLinkStore(), "ada@example.com", "ada@example.com", time.Now().Add(time.Minute))
+			},
+			consumed: 1,
+		},
+		{
+			name: "stored for another address",
+			token: func(links *fakeMagicLinkStore) string {
+				return issue(t, links, "ada@example.com", "eve@example.com", time.Now().Add(time.Minute))
+			},
+			consumed: 1,
+		},
+		{
+			name: "expired",
+			token: func(links *fakeMagicLinkStore) string {
+				return issue(t, links, "ada@example.com", "ada@example.com", time.Now().Add(-time.Second))
+			},
+		},
+		{
+			name: "forged",
+			token: func(links *fakeMagicLinkStore) string {
+				token := issue(t, links, "ada@example.com", "ada@example.com", time.Now().Add(time.Minute))
+				return token + "A"
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			links := newFakeMagicLinkStore()
+			token := tt.token(links)
+
+			if _, err := consumeMagicLink(ctx, links, token, key); !errors.Is(err, auth.ErrInvalidMagicLink) {
+				t.Fatalf("consumeMagicLink = %v, want ErrInvalidMagicLink", err)
+			}
+			if links.consumed != tt.consumed {
+				t.Fatalf("store asked %d times, want %d", links.consumed, tt.consumed)
+			}
+		})
+	}
+}
@@ -0,0 +1,740 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/exzacter/gorestapi/internal/auth"
+	"github.com/exzacter/gorestapi/internal/dtos/request"
+	"github.com/exzacter/gorestapi/internal/middlewares"
+	"github.com/exzacter/gorestapi/internal/store"
+	"github.com/exzacter/gorestapi/internal/utils"
+	"github.com/exzacter/gorestapi/internal/validate"
+)
+
+// how long an invitation can be accepted for
+const invitationTTL = 7 * 24 * time.Hour
+
+// actions in membership_events.action
+const (
+	membershipCreated           = "created"
+	membershipInvited           = "invited"
+	membershipInvitationRevoked = "invitation_revoked"
+	membershipJoined            = "joined"
+	membershipRoleChanged       = "role_changed"
+	membershipRemoved           = "removed"
+	membershipLeft              = "left"
+)
+
+func orgIDFromPath(r *http.Request) (int32, error) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 32)
+	if err != nil || id <= 0 {
+		return 0, errors.New("invalid organisation id")
+	}
+	return int32(id), nil
+}
+
+func memberIDFromPath(r *http.Request) (int32, error) {
+	id, err := strconv.ParseInt(r.PathValue("userID"), 10, 32)
+	if err != nil || id <= 0 {
+		return 0, errors.New("invalid user id")
+	}
+	return int32(id), nil
+}
+
+func invitationIDFromPath(r *http.Request) (int32, error) {
+	id, err := strconv.ParseInt(r.PathValue("invitationID"), 10, 32)
+	if err != nil || id <= 0 {
+		return 0, errors.New("invalid invitation id")
+	}
+	return int32(id), nil
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func nullInt32(id int32) sql.NullInt32 {
+	return sql.NullInt32{Int32: id, Valid: true}
+}
+
+// the caller's role in an organisation, "" when they aren't a member
+func (h *Handler) orgRole(ctx context.Context, orgID int32, userID int64) (string, error) {
+	role, err := h.Queries.GetMembershipRole(ctx, store.GetMembershipRoleParams{
+		OrganizationID: orgID,
+		UserID:         int32(userID),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+
+	return role, err
+}
+
+// loads the organisation in the url and makes sure the caller has at least the given role in it.
+// outsiders get a 404 so organisations can't be discovered by id
+func (h *Handler) requireOrgRole(w http.ResponseWriter, r *http.Request, min string) (*auth.Principal, int32, bool) {
+	principal, ok := r.Context().Value(middlewares.PrincipalKey).(*auth.Principal)
+	if !ok {
+		utils.RespondWithError(w, http.StatusBadRequest, "Please login to continue")
+		return nil, 0, false
+	}
+
+	orgID, err := orgIDFromPath(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return nil, 0, false
+	}
+
+	role, err := h.orgRole(r.Context(), orgID, principal.UserID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "error fetching membership")
+		return nil, 0, false
+	}
+	if role == "" {
+		utils.RespondWithNotFound(w)
+		return nil, 0, false
+	}
+	if !auth.OrgRoleAtLeast(role, min) {
+		utils.RespondWithError(w, http.StatusForbidden, "Your role in this organisation doesn't allow this")
+		return nil, 0, false
+	}
+
+	return principal, orgID, true
+}
+
+// create an organisation, the creator is its first owner
+func (h *Handler) CreateOrganizationHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := r.Context().Value(middlewares.PrincipalKey).(*auth.Principal)
+		if !ok {
+			utils.RespondWithError(w, http.StatusBadRequest, "Please login to continue")
+			return
+		}
+
+		var req dtos.CreateOrganizationRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+			return
+		}
+
+		if err := validate.Validate(&req); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		slug := req.Slug
+		if slug == "" {
+			slug = req.Name
+		}
+		slug = utils.Slugify(slug)
+		if slug == "" {
+			utils.RespondWithError(w, http.StatusBadRequest, "slug needs at least one letter or digit")
+			return
+		}
+
+		// the organisation never exists without an owner
+		tx, err := h.DB.BeginTx(r.Context(), nil)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error creating organisation")
+			return
+		}
+		defer tx.Rollback()
+
+		qtx := h.withTx(tx)
+
+		org, err := qtx.CreateOrganization(r.Context(), store.CreateOrganizationParams{
+			Name: strings.TrimSpace(req.Name),
+			Slug: slug,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			utils.RespondWithError(w, http.StatusConflict, "An organisation with this slug already exists")
+			return
+		} else if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error creating organisation")
+			return
+		}
+
+		if _, err := qtx.CreateMembership(r.Context(), store.CreateMembershipParams{
+			OrganizationID: org.ID,
+			UserID:         int32(principal.UserID),
+			Role:           auth.OrgRoleOwner,
+		}); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error creating organisation")
+			return
+		}
+
+		if err := qtx.CreateMembershipEvent(r.Context(), store.CreateMembershipEventParams{
+			OrganizationID: org.ID,
+			ActorID:        nullInt32(int32(principal.UserID)),
+			UserID:         nullInt32(int32(principal.UserID)),
+			Action:         membershipCreated,
+			Role:           nullString(auth.OrgRoleOwner),
+		}); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error creating organisation")
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error creating organisation")
+			return
+		}
+
+		utils.RespondWithSucess(w, http.StatusCreated, "organisation created", org)
+	}
+}
+
+// organisations the caller belongs to, with their role in each
+func (h *Handler) ListMyOrganizationsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := r.Context().Value(middlewares.PrincipalKey).(*auth.Principal)
+		if !ok {
+			utils.RespondWithError(w, http.StatusBadRequest, "Please login to continue")
+			return
+		}
+
+		orgs, err := h.Queries.ListUserOrganizations(r.Context(), int32(principal.UserID))
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error fetching organisations")
+			return
+		}
+
+		utils.RespondWithSucess(w, http.StatusOK, "Success", orgs)
+	}
+}
+
+// one organisation, members only
+func (h *Handler) GetOrganizationHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, orgID, ok := h.requireOrgRole(w, r, auth.OrgRoleViewer)
+		if !ok {
+			return
+		}
+
+		org, err := h.Queries.GetOrganization(r.Context(), orgID)
+		if errors.Is(err, sql.ErrNoRows) {
+			utils.RespondWithNotFound(w)
+			return
+		} else if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error fetching organisation")
+			return
+		}
+
+		utils.RespondWithSucess(w, http.StatusOK, "Success", org)
+	}
+}
+
+// everyone in the organisation and their role
+func (h *Handler) ListMembersHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, orgID, ok := h.requireOrgRole(w, r, auth.OrgRoleViewer)
+		if !ok {
+			return
+		}
+
+		members, err := h.Queries.ListMemberships(r.Context(), orgID)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error fetching members")
+			return
+		}
+
+		utils.RespondWithSucess(w, http.StatusOK, "Success", members)
+	}
+}
+
+// change a member's role, owners only. the last owner can't be demoted
+func (h *Handler) UpdateMemberHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, orgID, ok := h.requireOrgRole(w, r, auth.OrgRoleOwner)
+		if !ok {
+			return
+		}
+
+		userID, err := memberIDFromPath(r)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		var req dtos.UpdateMembershipRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+			return
+		}
+
+		if err := validate.Validate(&req); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		tx, err := h.DB.BeginTx(r.Context(), nil)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error updating member")
+			return
+		}
+		defer tx.Rollback()
+
+		qtx := h.withTx(tx)
+
+		// the owner count below stays true until commit
+		if err := qtx.LockOrganization(r.Context(), orgID); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error updating member")
+			return
+		}
+
+		previous, err := qtx.GetMembershipRole(r.Context(), store.GetMembershipRoleParams{
+			OrganizationID: orgID,
+			UserID:         userID,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			utils.RespondWithNotFound(w)
+			return
+		} else if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error updating member")
+			return
+		}
+
+		if previous == req.Role {
+			utils.RespondWithSucess(w, http.StatusOK, "member updated", nil)
+			return
+		}
+
+		if previous == auth.OrgRoleOwner {
+			if ok, err := hasOtherOwner(r.Context(), qtx, orgID); err != nil {
+				utils.RespondWithError(w, http.StatusInternalServerError, "error updating member")
+				return
+			} else if !ok {
+				utils.RespondWithError(w, http.StatusConflict, "An organisation needs at least one owner")
+				return
+			}
+		}
+
+		if err := qtx.UpdateMembershipRole(r.Context(), store.UpdateMembershipRoleParams{
+			OrganizationID: orgID,
+			UserID:         userID,
+			Role:           req.Role,
+		}); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error updating member")
+			return
+		}
+
+		if err := qtx.CreateMembershipEvent(r.Context(), store.CreateMembershipEventParams{
+			OrganizationID: orgID,
+			ActorID:        nullInt32(int32(principal.UserID)),
+			UserID:         nullInt32(userID),
+			Action:         membershipRoleChanged,
+			Role:           nullString(req.Role),
+			PreviousRole:   nullString(previous),
+		}); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error updating member")
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error updating member")
+			return
+		}
+
+		utils.RespondWithSucess(w, http.StatusOK, "member updated", nil)
+	}
+}
+
+// owners remove anyone, everyone else can only remove themselves, which is leaving
+func (h *Handler) RemoveMemberHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, orgID, ok := h.requireOrgRole(w, r, auth.OrgRoleViewer)
+		if !ok {
+			return
+		}
+
+		userID, err := memberIDFromPath(r)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		leaving := int64(userID) == principal.UserID
+
+		tx, err := h.DB.BeginTx(r.Context(), nil)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error removing member")
+			return
+		}
+		defer tx.Rollback()
+
+		qtx := h.withTx(tx)
+
+		if err := qtx.LockOrganization(r.Context(), orgID); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error removing member")
+			return
+		}
+
+		// read again under the lock, the caller may have been demoted since requireOrgRole
+		callerRole, err := qtx.GetMembershipRole(r.Context(), store.GetMembershipRoleParams{
+			OrganizationID: orgID,
+			UserID:         int32(principal.UserID),
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			utils.RespondWithNotFound(w)
+			return
+		} else if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error removing member")
+			return
+		}
+		if !leaving && callerRole != auth.OrgRoleOwner {
+			utils.RespondWithError(w, http.StatusForbidden, "Your role in this organisation doesn't allow this")
+			return
+		}
+
+		role, err := qtx.GetMembershipRole(r.Context(), store.GetMembershipRoleParams{
+			OrganizationID: orgID,
+			UserID:         userID,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			utils.RespondWithNotFound(w)
+			return
+		} else if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error removing member")
+			return
+		}
+
+		if role == auth.OrgRoleOwner {
+			if ok, err := hasOtherOwner(r.Context(), qtx, orgID); err != nil {
+				utils.RespondWithError(w, http.StatusInternalServerError, "error removing member")
+				return
+			} else if !ok {
+				utils.RespondWithError(w, http.StatusConflict, "An organisation needs at least one owner")
+				return
+			}
+		}
+
+		if err := qtx.DeleteMembership(r.Context(), store.DeleteMembershipParams{
+			OrganizationID: orgID,
+			UserID:         userID,
+		}); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error removing member")
+			return
+		}
+
+		action := membershipRemoved
+		if leaving {
+			action = membershipLeft
+		}
+		if err := qtx.CreateMembershipEvent(r.Context(), store.CreateMembershipEventParams{
+			OrganizationID: orgID,
+			ActorID:        nullInt32(int32(principal.UserID)),
+			UserID:         nullInt32(userID),
+			Action:         action,
+			PreviousRole:   nullString(role),
+		}); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error removing member")
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error removing member")
+			return
+		}
+
+		utils.RespondWithSucess(w, http.StatusOK, "member removed", nil)
+	}
+}
+
+// whether an owner other than the one being demoted or removed would be left. only call it with the
+// organisation locked
+func hasOtherOwner(ctx context.Context, q *store.Queries, orgID int32) (bool, error) {
+	owners, err := q.CountOrganizationOwners(ctx, orgID)
+	return owners > 1, err
+}
+
+// invite someone by email, owners only. the token is only in this response, send it to the invitee
+func (h *Handler) InviteMemberHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, orgID, ok := h.requireOrgRole(w, r, auth.OrgRoleOwner)
+		if !ok {
+			return
+		}
+
+		var req dtos.InviteMemberRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+			return
+		}
+
+		if err := validate.Validate(&req); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		token, tokenHash, err := auth.GenerateInvitationToken()
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error creating invitation")
+			return
+		}
+
+		tx, err := h.DB.BeginTx(r.Context(), nil)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error creating invitation")
+			return
+		}
+		defer tx.Rollback()
+
+		qtx := h.withTx(tx)
+
+		invitation, err := qtx.CreateOrganizationInvitation(r.Context(), store.CreateOrganizationInvitationParams{
+			OrganizationID: orgID,
+			Email:          req.Email,
+			Role:           req.Role,
+			TokenHash:      tokenHash,
+			InvitedBy:      nullInt32(int32(principal.UserID)),
+			ExpiresAt:      time.Now().Add(invitationTTL),
+		})
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error creating invitation")
+			return
+		}
+
+		if err := qtx.CreateMembershipEvent(r.Context(), store.CreateMembershipEventParams{
+			OrganizationID: orgID,
+			ActorID:        nullInt32(int32(principal.UserID)),
+			Action:         membershipInvited,
+			Role:           nullString(req.Role),
+			Email:          nullString(req.Email),
+		}); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error creating invitation")
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error creating invitation")
+			return
+		}
+
+		utils.RespondWithSucess(w, http.StatusCreated, "invitation created, send the token to the invitee as it won't be shown again", map[string]interface{}{
+			"invitation": invitation,
+			"token":      token,
+		})
+	}
+}
+
+// invitations that haven't been accepted and haven't expired, owners only
+func (h *Handler) ListInvitationsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, orgID, ok := h.requireOrgRole(w, r, auth.OrgRoleOwner)
+		if !ok {
+			return
+		}
+
+		invitations, err := h.Queries.ListOrganizationInvitations(r.Context(), orgID)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error fetching invitations")
+			return
+		}
+
+		utils.RespondWithSucess(w, http.StatusOK, "Success", invitations)
+	}
+}
+
+// withdraw an invitation before it is accepted, owners only
+func (h *Handler) RevokeInvitationHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, orgID, ok := h.requireOrgRole(w, r, auth.OrgRoleOwner)
+		if !ok {
+			return
+		}
+
+		invitationID, err := invitationIDFromPath(r)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		tx, err := h.DB.BeginTx(r.Context(), nil)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error revoking invitation")
+			return
+		}
+		defer tx.Rollback()
+
+		qtx := h.withTx(tx)
+
+		revoked, err := qtx.DeleteOrganizationInvitation(r.Context(), store.DeleteOrganizationInvitationParams{
+			ID:             invitationID,
+			OrganizationID: orgID,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			utils.RespondWithNotFound(w)
+			return
+		} else if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error revoking invitation")
+			return
+		}
+
+		if err := qtx.CreateMembershipEvent(r.Context(), store.CreateMembershipEventParams{
+			OrganizationID: orgID,
+			ActorID:        nullInt32(int32(principal.UserID)),
+			Action:         membershipInvitationRevoked,
+			Role:           nullString(revoked.Role),
+			Email:          nullString(revoked.Email),
+		}); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error revoking invitation")
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error revoking invitation")
+			return
+		}
+
+		utils.RespondWithSucess(w, http.StatusOK, "invitation revoked", nil)
+	}
+}
+
+// join an organisation with an invitation token. it only works for the account the invitation was
+// sent to, a leaked token is no use to anyone else
+func (h *Handler) AcceptInvitationHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := r.Context().Value(middlewares.PrincipalKey).(*auth.Principal)
+		if !ok {
+			utils.RespondWithError(w, http.StatusBadRequest, "Please login to continue")
+			return
+		}
+
+		var req dtos.AcceptInvitationRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+			return
+		}
+
+		if err := validate.Validate(&req); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		user, err := h.Queries.GetUser(r.Context(), int32(principal.UserID))
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error fetching user")
+			return
+		}
+
+		tx, err := h.DB.BeginTx(r.Context(), nil)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error accepting invitation")
+			return
+		}
+		defer tx.Rollback()
+
+		qtx := h.withTx(tx)
+
+		invitation, err := qtx.AcceptOrganizationInvitation(r.Context(), store.AcceptOrganizationInvitationParams{
+			TokenHash: auth.HashInvitationToken(strings.TrimSpace(req.Token)),
+			Email:     user.Email,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid or expired invitation")
+			return
+		} else if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error accepting invitation")
+			return
+		}
+
+		added, err := qtx.CreateMembership(r.Context(), store.CreateMembershipParams{
+			OrganizationID: invitation.OrganizationID,
+			UserID:         user.ID,
+			Role:           invitation.Role,
+		})
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error accepting invitation")
+			return
+		}
+		// rolling back leaves the invitation for whoever should have had it
+		if added == 0 {
+			utils.RespondWithError(w, http.StatusConflict, "You are already a member of this organisation")
+			return
+		}
+
+		if err := qtx.CreateMembershipEvent(r.Context(), store.CreateMembershipEventParams{
+			OrganizationID: invitation.OrganizationID,
+			ActorID:        nullInt32(user.ID),
+			UserID:         nullInt32(user.ID),
+			Action:         membershipJoined,
+			Role:           nullString(invitation.Role),
+			Email:          nullString(invitation.Email),
+		}); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error accepting invitation")
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error accepting invitation")
+			return
+		}
+
+		utils.RespondWithSucess(w, http.StatusOK, "invitation accepted", map[string]interface{}{
+			"organization_id": invitation.OrganizationID,
+			"role":            invitation.Role,
+		})
+	}
+}
+
+// who joined, left, was invited or changed role, newest first, owners only
+func (h *Handler) ListMembershipEventsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, orgID, ok := h.requireOrgRole(w, r, auth.OrgRoleOwner)
+		if !ok {
+			return
+		}
+
+		page, limit, offset := utils.ParsePagination(r)
+
+		events, err := h.Queries.ListMembershipEvents(r.Context(), store.ListMembershipEventsParams{
+			OrganizationID: orgID,
+			Limit:          limit,
+			Offset:         offset,
+		})
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error fetching events")
+			return
+		}
+
+		utils.RespondWithSucess(w, http.StatusOK, "Success", map[string]interface{}{
+			"events": events,
+			"page":   page,
+			"limit":  limit,
+		})
+	}
+}
+
+// the organisation's blogs in every status, drafts included, for its members
+func (h *Handler) ListOrganizationBlogsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, orgID, ok := h.requireOrgRole(w, r, auth.OrgRoleViewer)
+		if !ok {
+			return
+		}
+
+		page, limit, offset := utils.ParsePagination(r)
+
+		blogs, err := h.Queries.ListOrganizationBlogs(r.Context(), store.ListOrganizationBlogsParams{
+			OrganizationID: orgID,
+			Limit:          limit,
+			Offset:         offset,
+		})
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error fetching blogs")
+			return
+		}
+
+		utils.RespondWithSucess(w, http.StatusOK, "Success", map[string]interface{}{
+			"blogs": blogs,
+			"page":  page,
+			"limit": limit,
+		})
+	}
+}
@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/exzacter/gorestapi/internal/auth"
+	"github.com/exzacter/gorestapi/internal/dtos/request"
+	"github.com/exzacter/gorestapi/internal/exports"
+	"github.com/exzacter/gorestapi/internal/middlewares"
+	"github.com/exzacter/gorestapi/internal/store"
+	"github.com/exzacter/gorestapi/internal/utils"
+	"github.com/exzacter/gorestapi/internal/validate"
+)
+
+func (h *Handler) exporter() *exports.Exporter {
+	return exports.NewExporter(h.Queries, h.Storage, h.Redis, h.Jobs, h.Config.ExportLinkTTL)
+}
+
+// what the export endpoints return, the download link only once the zip is ready
+func exportResponse(export *exports.Export) map[string]interface{} {
+	response := map[string]interface{}{
+		"id":         export.ID,
+		"state":      export.State,
+		"created":    export.Created,
+		"status_url": "/v1/users/export/" + export.ID,
+	}
+	if export.State == exports.StateReady {
+		response["download_url"] = "/v1/users/export/" + export.ID + "/download"
+		response["expires_at"] = export.ExpiresAt
+		response["size"] = export.Size
+	}
+
+	return response
+}
+
+// start building a zip of everything stored about the caller. poll the status url for the download link
+func (h *Handler) StartExportHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := r.Context().Value(middlewares.PrincipalKey).(*auth.Principal)
+		if !ok {
+			utils.RespondWithError(w, http.StatusBadRequest, "Please login to continue")
+			return
+		}
+
+		export, err := h.exporter().Start(r.Context(), int32(principal.UserID))
+		if errors.Is(err, exports.ErrInProgress) {
+			utils.RespondWithSucess(w, http.StatusConflict, "An export is already being prepared", exportResponse(export))
+			return
+		} else if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error starting export")
+			return
+		}
+
+		utils.RespondWithSucess(w, http.StatusAccepted, "export started", exportResponse(export))
+	}
+}
+
+// state of one of the caller's exports
+func (h *Handler) GetExportHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := r.Context().Value(middlewares.PrincipalKey).(*auth.Principal)
+		if !ok {
+			utils.RespondWithError(w, http.StatusBadRequest, "Please login to continue")
+			return
+		}
+
+		export, err := h.exporter().Get(r.Context(), r.PathValue("id"))
+		if errors.Is(err, exports.ErrNotFound) || (err == nil && int64(export.UserID) != principal.UserID) {
+			utils.RespondWithNotFound(w)
+			return
+		} else if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error fetching export")
+			return
+		}
+
+		utils.RespondWithSucess(w, http.StatusOK, "Success", exportResponse(export))
+	}
+}
+
+// download a finished export. the unguessable id is the credential, so the link works without a token
+// until it expires, the same way a presigned url would
+func (h *Handler) DownloadExportHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, export, err := h.exporter().Open(r.Context(), r.PathValue("id"))
+		if errors.Is(err, exports.ErrNotFound) {
+			utils.RespondWithNotFound(w)
+			return
+		} else if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error fetching export")
+			return
+		}
+		defer body.Close()
+
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Length", strconv.FormatInt(export.Size, 10))
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="export-%s.zip"`, export.Created.Format("2006-01-02")))
+		w.Header().Set("Cache-Control", "private, no-store")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.WriteHeader(http.StatusOK)
+
+		io.Copy(w, body)
+	}
+}
+
+// erase the caller's account. personal blogs, reactions, credentials and memberships are deleted,
+// comments, organisation blogs and the membership history stay without anything that identifies the user.
+// it all happens in one transaction, then every token the user holds stops working
+func (h *Handler) EraseAccountHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		principal, ok := ctx.Value(middlewares.PrincipalKey).(*auth.Principal)
+		if !ok {
+			utils.RespondWithError(w, http.StatusBadRequest, "Please login to continue")
+			return
+		}
+
+		var req dtos.EraseAccountRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+			return
+		}
+
+		if err := validate.Validate(&req); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		userID := int32(principal.UserID)
+		user, err := h.Queries.GetUser(ctx, userID)
+		if errors.Is(err, sql.ErrNoRows) {
+			utils.RespondWithNotFound(w)
+			return
+		} else if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error fetching user")
+			return
+		}
+
+		if matched, _, _ := h.Passwords.Verify(user.Password, req.Password); !matched {
+			utils.RespondWithError(w, http.StatusUnauthorized, "invalid credentials")
+			return
+		}
+
+		tx, err := h.DB.BeginTx(ctx, nil)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error erasing account")
+			return
+		}
+		defer tx.Rollback()
+
+		qtx := h.withTx(tx)
+
+		// the other members need an owner, so ownership has to be handed over first
+		blocking, err := qtx.CountSoleOwnedOrganizations(ctx, userID)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error erasing account")
+			return
+		}
+		if blocking > 0 {
+			utils.RespondWithError(w, http.StatusConflict, "Make someone else an owner of your organisations first")
+			return
+		}
+
+		// organisations nobody else is in go, their blogs become personal and are deleted next
+		if err := qtx.DeleteSoleMemberOrganizations(ctx, userID); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error erasing account")
+			return
+		}
+
+		blogs, err := qtx.DeleteUserBlogs(ctx, userID)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error erasing account")
+			return
+		}
+
+		if err := qtx.AnonymiseUserComments(ctx, userID); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error erasing account")
+			return
+		}
+
+		if err := qtx.DeleteUserReactions(ctx, userID); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error erasing account")
+			return
+		}
+
+		if err := qtx.DeleteUserFollows(ctx, userID); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error erasing account")
+			return
+		}
+
+		if err := qtx.DeleteUserMemberships(ctx, userID); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error erasing account")
+			return
+		}
+
+		if err := qtx.DeleteInvitationsForEmail(ctx, user.Email); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error erasing account")
+			return
+		}
+
+		if err := qtx.AnonymiseMembershipEvents(ctx, store.AnonymiseMembershipEventsParams{
+			UserID: userID,
+			Email:  user.Email,
+		}); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error erasing account")
+			return
+		}
+
+		// api keys and 2fa, the password goes with the rest of the user row below
+		if err := qtx.DeleteUserApiKeys(ctx, userID); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error erasing account")
+			return
+		}
+
+		if err := qtx.DeleteUserTotp(ctx, userID); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error erasing account")
+			return
+		}
+
+		if err := qtx.DeleteRecoveryCodes(ctx, userID); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error erasing account")
+			return
+		}
+
+		if err := qtx.EraseUser(ctx, userID); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error erasing account")
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error erasing account")
+			return
+		}
+
+		// the data is gone, failures from here on only leave copies behind that expire by themselves,
+		// so they are logged rather than failing the request
+		revokedAt := time.Now()
+		middlewares.RememberRevokedUser(principal.UserID, revokedAt)
+		if err := h.Redis.Set(ctx, auth.TokensRevokedKey(principal.UserID), revokedAt.Unix(), auth.TokenLifetime).Err(); err != nil {
+			log.Printf("Failed to revoke tokens for erased user %d: %v", userID, err)
+		}
+
+		cacheKeys := []string{fmt.Sprintf("user:%d", userID)}
+		for _, blog := range blogs {
+			cacheKeys = append(cacheKeys, blogHTMLCacheKey(blog.ID, blog.Updated))
+		}
+		if err := h.Redis.Del(ctx, cacheKeys...).Err(); err != nil {
+			log.Printf("Failed to clear cache for erased user %d: %v", userID, err)
+		}
+
+		if err := h.cleanUserSession(fmt.Sprint(userID)); err != nil {
+			log.Printf("Failed to clear sessions for erased user %d: %v", userID, err)
+		}
+
+		h.forgetFeed(ctx, userID)
+
+		if err := h.exporter().Discard(ctx, userID); err != nil {
+			log.Printf("Failed to discard exports for erased user %d: %v", userID, err)
+		}
+
+		// avatars are stored by content, another user may have the same one
+		if user.AvatarKey.Valid {
+			if users, err := h.Queries.CountAvatarUsers(ctx, user.AvatarKey); err == nil && users == 0 {
+				if err := h.Storage.Delete(ctx, user.AvatarKey.String); err != nil {
+					log.Printf("Failed to delete avatar for erased user %d: %v", userID, err)
+				}
+			}
+		}
+
+		utils.RespondWithSucess(w, http.StatusOK, "account erased", nil)
+	}
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/exzacter/gorestapi/internal/auth"
+	"github.com/exzacter/gorestapi/internal/dtos/request"
+	"github.com/exzacter/gorestapi/internal/middlewares"
+	"github.com/exzacter/gorestapi/internal/store"
+	"github.com/exzacter/gorestapi/internal/utils"
+	"github.com/exzacter/gorestapi/internal/validate"
+)
+
+// add a reaction to a blog. reacting twice with the same kind is a no-op
+func (h *Handler) AddReactionHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := r.Context().Value(middlewares.PrincipalKey).(*auth.Principal)
+		if !ok {
+			utils.RespondWithError(w, http.StatusBadRequest, "Please login to continue")
+			return
+		}
+
+		blogID, err := blogIDFromPath(r)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		var req dtos.ReactionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+			return
+		}
+
+		if err := validate.Validate(&req); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if _, ok := h.publishedBlog(w, r, blogID); !ok {
+			return
+		}
+
+		err = h.Queries.AddBlogReaction(r.Context(), store.AddBlogReactionParams{
+			UserID: int32(principal.UserID),
+			BlogID: blogID,
+			Kind:   req.Kind,
+		})
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error adding reaction")
+			return
+		}
+
+		utils.RespondWithSucess(w, http.StatusOK, "reaction added", req.Kind)
+	}
+}
+
+// remove the current users reaction of the given kind
+func (h *Handler) RemoveReactionHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := r.Context().Value(middlewares.PrincipalKey).(*auth.Principal)
+		if !ok {
+			utils.RespondWithError(w, http.StatusBadRequest, "Please login to continue")
+			return
+		}
+
+		blogID, err := blogIDFromPath(r)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		removed, err := h.Queries.RemoveBlogReaction(r.Context(), store.RemoveBlogReactionParams{
+			UserID: int32(principal.UserID),
+			BlogID: blogID,
+			Kind:   r.PathValue("kind"),
+		})
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error removing reaction")
+			return
+		}
+
+		if removed == 0 {
+			utils.RespondWithNotFound(w)
+			return
+		}
+
+		utils.RespondWithSucess(w, http.StatusOK, "reaction removed", nil)
+	}
+}
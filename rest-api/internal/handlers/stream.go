@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/exzacter/gorestapi/internal/sse"
+	"github.com/exzacter/gorestapi/internal/utils"
+)
+
+// how long an EventSource waits before reconnecting, sent once per connection
+const streamRetry = 3 * time.Second
+
+// where the stream handler gets events from, *sse.Broker in the api
+type eventStream interface {
+	Subscribe() (<-chan sse.Event, func())
+	Since(ctx context.Context, id string) ([]sse.Event, error)
+}
+
+// live blog activity as server-sent events. a client reconnecting with Last-Event-ID (or ?last_event_id=,
+// which EventSource can't send as a header on its first connect) gets what it missed first
+func (h *Handler) BlogStreamHandler() http.HandlerFunc {
+	return streamHandler(h.Stream, h.Config.SSEHeartbeatInterval)
+}
+
+func streamHandler(stream eventStream, heartbeatInterval time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lastID := r.Header.Get("Last-Event-ID")
+		if lastID == "" {
+			lastID = r.URL.Query().Get("last_event_id")
+		}
+		if lastID != "" && !sse.ValidID(lastID) {
+			utils.RespondWithError(w, http.StatusBadRequest, "invalid Last-Event-ID")
+			return
+		}
+
+		// subscribed before the backlog is read, so nothing published in between is lost. what shows up in
+		// both is skipped by id below
+		events, unsubscribe := stream.Subscribe()
+		defer unsubscribe()
+
+		var backlog []sse.Event
+		if lastID != "" {
+			var err error
+			backlog, err = stream.Since(r.Context(), lastID)
+			if err != nil {
+				w.Header().Set("Retry-After", fmt.Sprintf("%d", int(streamRetry.Seconds())))
+				utils.RespondWithError(w, http.StatusServiceUnavailable, "event history unavailable")
+				return
+			}
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		// nginx would otherwise buffer the stream
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		rc := http.NewResponseController(w)
+		if _, err := fmt.Fprintf(w, "retry: %d\n\n", streamRetry.Milliseconds()); err != nil {
+			return
+		}
+
+		for _, event := range backlog {
+			if err := writeStreamEvent(w, event); err != nil {
+				return
+			}
+			lastID = event.ID
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+
+		// comments keep proxies and load balancers from closing an idle connection
+		heartbeat := time.NewTicker(heartbeatInterval)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-heartbeat.C:
+				if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+					return
+				}
+			case event, ok := <-events:
+				if !ok {
+					// too slow or shutting down, the client reconnects and resumes from lastID
+					return
+				}
+				if lastID != "" && !sse.After(event.ID, lastID) {
+					continue
+				}
+				if err := writeStreamEvent(w, event); err != nil {
+					return
+				}
+				lastID = event.ID
+			}
+
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func writeStreamEvent(w http.ResponseWriter, event sse.Event) error {
+	_, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data)
+	return err
+}
+
+// tells stream clients about a committed change. the change stands either way, so a failure is only logged.
+// the request may be gone by now, the event still goes out
+func (h *Handler) publishStreamEvent(ctx context.Context, eventType string, data interface{}) {
+	if err := h.Stream.Publish(context.WithoutCancel(ctx), eventType, data); err != nil {
+		log.Printf("Failed to publish %s stream event: %v", eventType, err)
+	}
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/exzacter/gorestapi/internal/sse"
+)
+
+// hands out a backlog and a live channel of events given up front, closed once they are read so the
+// handler returns like it does for a subscriber that fell behind
+type fakeEventStream struct {
+	backlog []sse.Event
+	live    []sse.Event
+	err     error
+	// the id Since was asked for, "" when it wasn't
+	since string
+}
+
+func (f *fakeEventStream) Subscribe() (<-chan sse.Event, func()) {
+	ch := make(chan sse.Event, len(f.live))
+	for _, event := range f.live {
+		ch <- event
+	}
+	close(ch)
+	return ch, func() {}
+}
+
+func (f *fakeEventStream) Since(ctx context.Context, id string) ([]sse.Event, error) {
+	f.since = id
+	return f.backlog, f.err
+}
+
+func streamEvents(ids ...string) []sse.Event {
+	events := make([]sse.Event, 0, len(ids))
+	for _, id := range ids {
+		events = append(events, sse.Event{ID: id, Type: sse.EventBlogPublished, Data: []byte(`{}`)})
+	}
+	return events
+}
+
+var streamIDLine = regexp.MustCompile(`(?m)^id: (.+)$`)
+
+func TestBlogStreamResume(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		query   string
+		backlog []sse.Event
+		live    []sse.Event
+		// the id Since should be asked for, and the ids the client should get in order
+		since string
+		want  []string
+	}{
+		{
+			name: "fresh connection",
+			live: streamEvents("5-0", "6-0"),
+			want: []string{"5-0", "6-0"},
+		},
+		{
+			name:    "backlog first",
+			header:  "1-0",
+			backlog: streamEvents("2-0", "3-0"),
+			live:    streamEvents("4-0"),
+			since:   "1-0",
+			want:    []string{"2-0", "3-0", "4-0"},
+		},
+		{
+			// published between subscribing and reading the backlog, so in both
+			name:    "events in the backlog and live are sent once",
+			header:  "1-0",
+			backlog: streamEvents("2-0", "3-0"),
+			live:    streamEvents("2-0", "3-0", "4-0"),
+			since:   "1-0",
+			want:    []string{"2-0", "3-0", "4-0"},
+		},
+		{
+			name:   "live events older than Last-Event-ID are skipped",
+			header: "5-0",
+			live:   streamEvents("4-0", "5-0", "5-1"),
+			since:  "5-0",
+			want:   []string{"5-1"},
+		},
+		{
+			name:    "query parameter",
+			query:   "1-0",
+			backlog: streamEvents("2-0"),
+			since:   "1-0",
+			want:    []string{"2-0"},
+		},
+		{
+			name:    "header over query",
+			header:  "2-0",
+			query:   "1-0",
+			backlog: streamEvents("3-0"),
+			since:   "2-0",
+			want:    []string{"3-0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream := &fakeEventStream{backlog: tt.backlog, live: tt.live}
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/blogs/stream", nil)
+			if tt.header != "" {
+				req.Header.Set("Last-Event-ID", tt.header)
+			}
+			if tt.query != "" {
+				req.URL.RawQuery = "last_event_id=" + tt.query
+			}
+			rec := httptest.NewRecorder()
+
+			streamHandler(stream, time.Hour)(rec, req)
+
+			if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "text/event-stream" {
+				t.Fatalf("got %d %q, want an event stream", rec.Code, rec.Header().Get("Content-Type"))
+			}
+			if stream.since != tt.since {
+				t.Fatalf("Since asked for %q, want %q", stream.since, tt.since)
+			}
+
+			var got []string
+			for _, match := range streamIDLine.FindAllStringSubmatch(rec.Body.String(), -1) {
+				got = append(got, match[1])
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("sent %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBlogStreamRefuses(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		query  string
+		err    error
+		status int
+	}{
+		{"invalid header", "not-an-id", "", nil, http.StatusBadRequest},
+		{"invalid query", "", "not-an-id", nil, http.StatusBadRequest},
+		// read as special ids by XRANGE, never passed on
+		{"special id", "+", "", nil, http.StatusBadRequest},
+		{"history unavailable", "1-0", "", errors.New("redis is down"), http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/blogs/stream", nil)
+			if tt.header != "" {
+				req.Header.Set("Last-Event-ID", tt.header)
+			}
+			if tt.query != "" {
+				req.URL.RawQuery = "last_event_id=" + tt.query
+			}
+			rec := httptest.NewRecorder()
+
+			streamHandler(&fakeEventStream{err: tt.err}, time.Hour)(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if tt.status == http.StatusServiceUnavailable && rec.Header().Get("Retry-After") == "" {
+				t.Fatal("no Retry-After on a 503")
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/exzacter/gorestapi/internal/store"
+	"github.com/exzacter/gorestapi/internal/utils"
+)
+
+// replaces every tag on a blog with the given names. q should come from h.withTx so the
+// delete and re-insert happen together
+func setBlogTags(ctx context.Context, q *store.Queries, blogID int32, names []string) error {
+	if err := q.DeleteBlogTags(ctx, blogID); err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		slug := utils.Slugify(name)
+		if slug == "" || seen[slug] {
+			continue
+		}
+		seen[slug] = true
+
+		// first writer picks the display name, later posts reuse the existing tag
+		tag, err := q.UpsertTag(ctx, store.UpsertTagParams{
+			Name: strings.TrimSpace(name),
+			Slug: slug,
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := q.AddBlogTag(ctx, store.AddBlogTagParams{BlogID: blogID, TagID: tag.ID}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// list every tag with how many blogs use it
+func (h *Handler) ListTagsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tags, err := h.Queries.ListTags(r.Context())
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error fetching tags")
+			return
+		}
+
+		utils.RespondWithSucess(w, http.StatusOK, "Success", tags)
+	}
+}
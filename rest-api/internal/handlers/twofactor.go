@@ -0,0 +1,290 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/exzacter/gorestapi/internal/auth"
+	"github.com/exzacter/gorestapi/internal/dtos/request"
+	"github.com/exzacter/gorestapi/internal/metrics"
+	"github.com/exzacter/gorestapi/internal/middlewares"
+	"github.com/exzacter/gorestapi/internal/store"
+	"github.com/exzacter/gorestapi/internal/utils"
+	"github.com/exzacter/gorestapi/internal/validate"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// how long a user has to enter their code after the password step
+	loginChallengeTTL = 5 * time.Minute
+	// wrong codes allowed per challenge before the password has to be entered again
+	loginChallengeAttempts = 5
+	// a totp code stays valid for up to 90 seconds with clock drift allowed, so remember it that long
+	usedTOTPCodeTTL = 90 * time.Second
+)
+
+func loginChallengeKey(token string) string {
+	return "2fa:challenge:" + token
+}
+
+// creates the short lived token handed out instead of a jwt when 2fa is on. it lives in redis so it
+// can be used once and can never pass AuthMiddle the way a signed token could
+func (h *Handler) createLoginChallenge(ctx context.Context, userID int32) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(raw)
+	if err := h.Redis.Set(ctx, loginChallengeKey(token), userID, loginChallengeTTL).Err(); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// start 2fa enrolment. returns a new secret and provisioning uri, 2fa stays off until confirmed
+func (h *Handler) EnrollTwoFactorHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := r.Context().Value(middlewares.PrincipalKey).(*auth.Principal)
+		if !ok {
+			utils.RespondWithError(w, http.StatusBadRequest, "Please login to continue")
+			return
+		}
+
+		secret, uri, err := auth.GenerateTOTP(principal.Username)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error creating 2fa secret")
+			return
+		}
+
+		// enrolling again before confirming just replaces the pending secret
+		stored, err := h.Queries.UpsertUserTotp(r.Context(), store.UpsertUserTotpParams{
+			UserID: int32(principal.UserID),
+			Secret: secret,
+		})
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error creating 2fa secret")
+			return
+		}
+		if stored == 0 {
+			utils.RespondWithError(w, http.StatusConflict, "2fa is already enabled")
+			return
+		}
+
+		utils.RespondWithSucess(w, http.StatusOK, "scan the provisioning uri, then confirm with a code", map[string]string{
+			"secret":           secret,
+			"provisioning_uri": uri,
+		})
+	}
+}
+
+// confirm enrolment with the first code from the app. turns 2fa on and returns the recovery codes, once
+func (h *Handler) ConfirmTwoFactorHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := r.Context().Value(middlewares.PrincipalKey).(*auth.Principal)
+		if !ok {
+			utils.RespondWithError(w, http.StatusBadRequest, "Please login to continue")
+			return
+		}
+
+		var req dtos.ConfirmTwoFactorRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+			return
+		}
+
+		if err := validate.Validate(&req); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		userID := int32(principal.UserID)
+		pending, err := h.Queries.GetUserTotp(r.Context(), userID)
+		if err == sql.ErrNoRows {
+			utils.RespondWithError(w, http.StatusBadRequest, "start enrolment first")
+			return
+		} else if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error confirming 2fa")
+			return
+		}
+		if pending.ConfirmedAt.Valid {
+			utils.RespondWithError(w, http.StatusConflict, "2fa is already enabled")
+			return
+		}
+
+		if !auth.ValidateTOTP(req.Code, pending.Secret) {
+			utils.RespondWithError(w, http.StatusBadRequest, "invalid code")
+			return
+		}
+
+		codes, hashes, err := auth.GenerateRecoveryCodes(auth.RecoveryCodeCount)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error confirming 2fa")
+			return
+		}
+
+		// switching 2fa on and storing the recovery codes happen together
+		tx, err := h.DB.BeginTx(r.Context(), nil)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error confirming 2fa")
+			return
+		}
+		defer tx.Rollback()
+
+		qtx := h.withTx(tx)
+
+		confirmed, err := qtx.ConfirmUserTotp(r.Context(), userID)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error confirming 2fa")
+			return
+		}
+		if confirmed == 0 {
+			utils.RespondWithError(w, http.StatusConflict, "2fa is already enabled")
+			return
+		}
+
+		if err := qtx.DeleteRecoveryCodes(r.Context(), userID); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error confirming 2fa")
+			return
+		}
+
+		if err := qtx.CreateRecoveryCodes(r.Context(), store.CreateRecoveryCodesParams{
+			UserID:     userID,
+			CodeHashes: hashes,
+		}); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error confirming 2fa")
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error confirming 2fa")
+			return
+		}
+
+		utils.RespondWithSucess(w, http.StatusOK, "2fa enabled, store the recovery codes now as they won't be shown again", map[string][]string{
+			"recovery_codes": codes,
+		})
+	}
+}
+
+// second login step, swaps the challenge token and a totp or recovery code for the real jwt
+func (h *Handler) LoginTwoFactorHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		var req dtos.LoginTwoFactorRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+			return
+		}
+
+		if err := validate.Validate(&req); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		challengeKey := loginChallengeKey(req.ChallengeToken)
+		stored, err := h.Redis.Get(ctx, challengeKey).Result()
+		if err == redis.Nil {
+			utils.RespondWithError(w, http.StatusUnauthorized, "challenge expired, please login again")
+			return
+		} else if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Internal error")
+			return
+		}
+
+		userID, err := strconv.ParseInt(stored, 10, 32)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Internal error")
+			return
+		}
+
+		// guessing six digits is easy with unlimited tries, so each challenge only gets a few
+		attemptsKey := challengeKey + ":attempts"
+		attempts, err := h.Redis.Incr(ctx, attemptsKey).Result()
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Internal error")
+			return
+		}
+		h.Redis.Expire(ctx, attemptsKey, loginChallengeTTL)
+		if attempts > loginChallengeAttempts {
+			h.Redis.Del(ctx, challengeKey, attemptsKey)
+			metrics.Login(false)
+			utils.RespondWithError(w, http.StatusUnauthorized, "too many attempts, please login again")
+			return
+		}
+
+		ok, err := h.verifySecondFactor(ctx, int32(userID), req.Code)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Internal error")
+			return
+		}
+		if !ok {
+			metrics.Login(false)
+			utils.RespondWithError(w, http.StatusUnauthorized, "invalid code")
+			return
+		}
+
+		// taking the challenge out of redis is what makes it single use, GETDEL hands it to exactly one of
+		// the requests that got this far
+		consumed, err := h.Redis.GetDel(ctx, challengeKey).Result()
+		if err == redis.Nil || (err == nil && consumed != stored) {
+			utils.RespondWithError(w, http.StatusUnauthorized, "challenge expired, please login again")
+			return
+		} else if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Internal error")
+			return
+		}
+		h.Redis.Del(ctx, attemptsKey)
+
+		user, err := h.Queries.GetUser(ctx, int32(userID))
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Internal error")
+			return
+		}
+		// disabled between the password step and this one
+		if user.DisabledAt.Valid {
+			metrics.Login(false)
+			utils.RespondWithError(w, http.StatusForbidden, "account disabled")
+			return
+		}
+
+		h.respondWithLoginToken(w, user.ID, user.Username)
+	}
+}
+
+// checks a totp code, refusing one that was already used, or spends a recovery code
+func (h *Handler) verifySecondFactor(ctx context.Context, userID int32, code string) (bool, error) {
+	if !auth.IsTOTPCode(code) {
+		used, err := h.Queries.UseRecoveryCode(ctx, store.UseRecoveryCodeParams{
+			UserID:   userID,
+			CodeHash: auth.HashRecoveryCode(code),
+		})
+		return used == 1, err
+	}
+
+	secret, err := h.Queries.GetUserTotp(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+
+	if !auth.ValidateTOTP(code, secret.Secret) {
+		return false, nil
+	}
+
+	// a code seen over someones shoulder must not work a second time
+	fresh, err := h.Redis.SetNX(ctx, fmt.Sprintf("2fa:used:%d:%s", userID, code), 1, usedTOTPCodeTTL).Result()
+	if err != nil {
+		return false, err
+	}
+
+	return fresh, nil
+}
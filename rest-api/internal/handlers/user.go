@@ -2,8 +2,11 @@ package handlers
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"os"
 	"strings"
@@ -11,10 +14,13 @@ import (
 
 	"github.com/exzacter/gorestapi/internal/auth"
 	"github.com/exzacter/gorestapi/internal/dtos/request"
+	"github.com/exzacter/gorestapi/internal/metrics"
 	"github.com/exzacter/gorestapi/internal/middlewares"
+	"github.com/exzacter/gorestapi/internal/password"
 	"github.com/exzacter/gorestapi/internal/store"
 	"github.com/exzacter/gorestapi/internal/utils"
 	"github.com/exzacter/gorestapi/internal/validate"
+	"github.com/exzacter/gorestapi/internal/webhooks"
 )
 
 // extract function
@@ -64,9 +70,9 @@ func (h *Handler) cleanUserSession(userID string) error {
 func (h *Handler) LogoutHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 
-		// extract jwt claims from the context
-		claims, ok := r.Context().Value(middlewares.UserClaimsKey).(*auth.Claims)
-		if !ok {
+		// extract the principal from the context, only jwt logins have a session to end
+		principal, ok := r.Context().Value(middlewares.PrincipalKey).(*auth.Principal)
+		if !ok || principal.Claims == nil {
 			utils.RespondWithError(w, http.StatusBadRequest, "Please login to continue")
 			return
 		}
@@ -79,13 +85,16 @@ func (h *Handler) LogoutHandler() http.HandlerFunc {
 		}
 
 		// convert expireat to time.Time
-		expirationTime := claims.ExpiresAt.Time
+		expirationTime := principal.Claims.ExpiresAt.Time
 		now := time.Now()
 		ttl := expirationTime.Sub(now)
 		if ttl <= 0 {
 			ttl = 5 * time.Minute
 		}
 
+		// remembered here first, so this instance refuses the token even if redis is down
+		middlewares.RememberRevokedToken(tokenString, expirationTime)
+
 		// blacklist token in redis
 		err := h.Redis.Set(r.Context(), tokenString, "blacklisted", ttl).Err()
 		if err != nil {
@@ -94,7 +103,7 @@ func (h *Handler) LogoutHandler() http.HandlerFunc {
 		}
 
 		// clean user session in redis
-		userIDStr := fmt.Sprintf("%d", claims.UserID)
+		userIDStr := fmt.Sprintf("%d", principal.UserID)
 		if err := h.cleanUserSession(userIDStr); err != nil {
 			fmt.Printf("Error cleaning session for %s: %v", userIDStr, err)
 		}
@@ -103,26 +112,35 @@ func (h *Handler) LogoutHandler() http.HandlerFunc {
 	}
 }
 
+func userVersion(id int32, updated sql.NullTime) string {
+	return utils.Version("user", id, updated)
+}
+
 // profile
 func (h *Handler) UserProfile() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		claims, ok := r.Context().Value(middlewares.UserClaimsKey).(*auth.Claims)
+		principal, ok := r.Context().Value(middlewares.PrincipalKey).(*auth.Principal)
 		if !ok {
 			utils.RespondWithError(w, http.StatusBadRequest, "Please login to continue")
 			return
 		}
 
-		userID := claims.UserID
+		userID := principal.UserID
 
 		// check redis first
-		cacheKey := fmt.Sprintf("user:%f", userID)
+		cacheKey := fmt.Sprintf("user:%d", userID)
 		if cached, err := h.Redis.Get(r.Context(), cacheKey).Result(); err == nil {
 			var user store.User
 			if err := json.Unmarshal([]byte(cached), &user); err == nil {
+				metrics.CacheLookup("user_profile", true)
+				if utils.NotModified(w, r, utils.ETag(userVersion(user.ID, user.Updated), "")) {
+					return
+				}
 				utils.RespondWithSucess(w, http.StatusOK, "Success (from redis cache)", user)
 				return
 			}
 		}
+		metrics.CacheLookup("user_profile", false)
 
 		// fallback to db
 		user, err := h.Queries.GetUser(r.Context(), int32(userID))
@@ -135,6 +153,10 @@ func (h *Handler) UserProfile() http.HandlerFunc {
 		userJSON, _ := json.Marshal(user)
 		h.Redis.Set(r.Context(), cacheKey, userJSON, 5*time.Minute)
 
+		if utils.NotModified(w, r, utils.ETag(userVersion(user.ID, user.Updated), "")) {
+			return
+		}
+
 		utils.RespondWithSucess(w, http.StatusOK, "Success", user)
 	}
 }
@@ -160,29 +182,94 @@ func (h *Handler) LoginUserHandler() http.HandlerFunc {
 		// fetch user from the db using store queries
 		user, err := h.Queries.GetUserByUsernameOrEmail(ctx, req.Username)
 		if err != nil {
+			metrics.Login(false)
 			utils.RespondWithError(w, http.StatusUnauthorized, "invalid credentials")
 			return
 		}
 
-		if !utils.ComparePassword(user.Password, req.Password) {
+		matched, rehash, err := h.Passwords.Verify(user.Password, req.Password)
+		if err != nil {
+			// an erased account, or a hash nothing here made
+			log.Printf("Unverifiable password hash for user %d: %v", user.ID, err)
+		}
+		if !matched {
+			metrics.Login(false)
 			utils.RespondWithError(w, http.StatusUnauthorized, "invalid credentials")
 			return
 		}
 
-		jwtKey := []byte(os.Getenv("JWT_SECRET_KEY"))
-		token, err := auth.GenerateJWT(int64(user.ID), user.Username, jwtKey)
+		// only said once the password is right, so it can't be used to find disabled accounts
+		if user.DisabledAt.Valid {
+			metrics.Login(false)
+			utils.RespondWithError(w, http.StatusForbidden, "account disabled")
+			return
+		}
+
+		// the hash was made with older params, this is the only time the password is at hand to redo it
+		if rehash {
+			h.upgradePasswordHash(ctx, user.ID, user.Password, req.Password)
+		}
+
+		h.completeFirstFactor(ctx, w, user.ID, user.Username)
+	}
+}
+
+// called once the password or a magic link checks out. with 2fa on that only earns a challenge,
+// LoginTwoFactorHandler hands out the jwt
+func (h *Handler) completeFirstFactor(ctx context.Context, w http.ResponseWriter, userID int32, username string) {
+	twoFactor, err := h.Queries.GetUserTotp(ctx, userID)
+	if err != nil && err != sql.ErrNoRows {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Internal error")
+		return
+	}
+	if err == nil && twoFactor.ConfirmedAt.Valid {
+		challenge, err := h.createLoginChallenge(ctx, userID)
 		if err != nil {
-			utils.RespondWithError(w, http.StatusInternalServerError, "Error generating a token")
+			utils.RespondWithError(w, http.StatusInternalServerError, "Internal error")
 			return
 		}
 
-		utils.RespondWithSucess(w, http.StatusOK, "Login successful", map[string]string{
-			"token": token,
+		utils.RespondWithSucess(w, http.StatusOK, "Two-factor code required", map[string]interface{}{
+			"two_factor_required": true,
+			"challenge_token":     challenge,
+			"expires_in":          int(loginChallengeTTL.Seconds()),
 		})
+		return
+	}
+
+	h.respondWithLoginToken(w, userID, username)
+}
 
+// replaces a hash made with outdated params. the login goes ahead even if this fails, the next one tries again
+func (h *Handler) upgradePasswordHash(ctx context.Context, userID int32, oldHash, password string) {
+	newHash, err := h.Passwords.Hash(password)
+	if err == nil {
+		err = h.Queries.UpgradePasswordHash(ctx, store.UpgradePasswordHashParams{
+			NewHash: newHash,
+			ID:      userID,
+			OldHash: oldHash,
+		})
+	}
+	if err != nil {
+		log.Printf("Failed to upgrade password hash for user %d: %v", userID, err)
 	}
 }
 
+// the last step of every login, with or without 2fa
+func (h *Handler) respondWithLoginToken(w http.ResponseWriter, userID int32, username string) {
+	jwtKey := []byte(os.Getenv("JWT_SECRET_KEY"))
+	token, err := auth.GenerateJWT(int64(userID), username, jwtKey)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Error generating a token")
+		return
+	}
+
+	metrics.Login(true)
+	utils.RespondWithSucess(w, http.StatusOK, "Login successful", map[string]string{
+		"token": token,
+	})
+}
+
 // Create user
 func (h *Handler) CreateUserHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -202,18 +289,57 @@ func (h *Handler) CreateUserHandler() http.HandlerFunc {
 			return
 		}
 
-		hashedPassword, err := utils.HashPassword(req.Password)
+		if err := h.Passwords.Check(ctx, req.Password, req.Username, req.Email); err != nil {
+			var violations password.Violations
+			if errors.As(err, &violations) {
+				utils.RespondWithError(w, http.StatusBadRequest, violations.Error())
+				return
+			}
+			utils.RespondWithError(w, http.StatusInternalServerError, "error checking password")
+			return
+		}
+
+		hashedPassword, err := h.Passwords.Hash(req.Password)
 		if err != nil {
 			utils.RespondWithError(w, http.StatusInternalServerError, "error while hashing password")
 			return
 		}
-		_, err = h.Queries.CreateUser(ctx, store.CreateUserParams{
+		// the user.created webhook is queued in the same transaction as the user
+		tx, err := h.DB.BeginTx(ctx, nil)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error creating user")
+			return
+		}
+		defer tx.Rollback()
+
+		qtx := h.withTx(tx)
+
+		// the insert names the timestamp columns, so they have to be given or they are stored as NULL
+		now := sql.NullTime{Time: time.Now(), Valid: true}
+		created, err := qtx.CreateUser(ctx, store.CreateUserParams{
 			Username: req.Username,
 			Email:    req.Email,
 			Password: hashedPassword,
+			Created:  now,
+			Updated:  now,
 		})
+		if errors.Is(err, sql.ErrNoRows) {
+			utils.RespondWithError(w, http.StatusConflict, "An account with this email already exists")
+			return
+		} else if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error creating user")
+			return
+		}
 
-		if err != nil {
+		if err := webhooks.Enqueue(ctx, qtx, webhooks.EventUserCreated, webhooks.UserCreated{
+			ID:       created.ID,
+			Username: created.Username,
+		}); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error creating user")
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
 			utils.RespondWithError(w, http.StatusInternalServerError, "error creating user")
 			return
 		}
@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/exzacter/gorestapi/internal/auth"
+	"github.com/exzacter/gorestapi/internal/dtos/request"
+	"github.com/exzacter/gorestapi/internal/middlewares"
+	"github.com/exzacter/gorestapi/internal/store"
+	"github.com/exzacter/gorestapi/internal/utils"
+	"github.com/exzacter/gorestapi/internal/validate"
+	"github.com/exzacter/gorestapi/internal/webhooks"
+)
+
+func webhookIDFromPath(r *http.Request) (int32, error) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 32)
+	if err != nil || id <= 0 {
+		return 0, errors.New("invalid webhook id")
+	}
+	return int32(id), nil
+}
+
+func deliveryIDFromPath(r *http.Request) (int32, error) {
+	id, err := strconv.ParseInt(r.PathValue("deliveryID"), 10, 32)
+	if err != nil || id <= 0 {
+		return 0, errors.New("invalid delivery id")
+	}
+	return int32(id), nil
+}
+
+// webhooks see events from every user and jobs carry anyone's data, so only admins manage them
+func (h *Handler) requireAdmin(w http.ResponseWriter, r *http.Request) (*auth.Principal, bool) {
+	principal, ok := r.Context().Value(middlewares.PrincipalKey).(*auth.Principal)
+	if !ok {
+		utils.RespondWithError(w, http.StatusBadRequest, "Please login to continue")
+		return nil, false
+	}
+
+	if !h.isAdmin(r.Context(), principal.UserID) {
+		utils.RespondWithError(w, http.StatusForbidden, "Only admins can do this")
+		return nil, false
+	}
+
+	return principal, true
+}
+
+// register a webhook. the signing secret is only in this response
+func (h *Handler) CreateWebhookHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := h.requireAdmin(w, r)
+		if !ok {
+			return
+		}
+
+		var req dtos.CreateWebhookRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+			return
+		}
+
+		if err := validate.Validate(&req); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		secret, err := webhooks.NewSecret()
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error creating webhook")
+			return
+		}
+
+		slices.Sort(req.Events)
+		webhook, err := h.Queries.CreateWebhook(r.Context(), store.CreateWebhookParams{
+			UserID: int32(principal.UserID),
+			Url:    req.URL,
+			Secret: secret,
+			Events: slices.Compact(req.Events),
+		})
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error creating webhook")
+			return
+		}
+
+		utils.RespondWithSucess(w, http.StatusCreated, "webhook created, store the secret now as it won't be shown again", webhook)
+	}
+}
+
+// list every webhook, without secrets
+func (h *Handler) ListWebhooksHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := h.requireAdmin(w, r); !ok {
+			return
+		}
+
+		hooks, err := h.Queries.ListWebhooks(r.Context())
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error fetching webhooks")
+			return
+		}
+
+		utils.RespondWithSucess(w, http.StatusOK, "Success", hooks)
+	}
+}
+
+// delete a webhook and its delivery log
+func (h *Handler) DeleteWebhookHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := h.requireAdmin(w, r); !ok {
+			return
+		}
+
+		id, err := webhookIDFromPath(r)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		deleted, err := h.Queries.DeleteWebhook(r.Context(), id)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error deleting webhook")
+			return
+		}
+		if deleted == 0 {
+			utils.RespondWithNotFound(w)
+			return
+		}
+
+		utils.RespondWithSucess(w, http.StatusOK, "webhook deleted", nil)
+	}
+}
+
+// delivery log for one webhook, newest first, with attempts and the last response or error
+func (h *Handler) ListWebhookDeliveriesHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := h.requireAdmin(w, r); !ok {
+			return
+		}
+
+		id, err := webhookIDFromPath(r)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		page, limit, offset := utils.ParsePagination(r)
+
+		deliveries, err := h.Queries.ListWebhookDeliveries(r.Context(), store.ListWebhookDeliveriesParams{
+			WebhookID: id,
+			Limit:     limit,
+			Offset:    offset,
+		})
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error fetching deliveries")
+			return
+		}
+
+		utils.RespondWithSucess(w, http.StatusOK, "Success", map[string]interface{}{
+			"deliveries": deliveries,
+			"page":       page,
+			"limit":      limit,
+		})
+	}
+}
+
+// send a delivery again, dead or not. the dispatcher picks it up on its next run
+func (h *Handler) RedeliverWebhookHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := h.requireAdmin(w, r); !ok {
+			return
+		}
+
+		id, err := webhookIDFromPath(r)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		deliveryID, err := deliveryIDFromPath(r)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		delivery, err := h.Queries.RedeliverWebhookDelivery(r.Context(), store.RedeliverWebhookDeliveryParams{
+			ID:        deliveryID,
+			WebhookID: id,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			utils.RespondWithNotFound(w)
+			return
+		} else if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "error queueing delivery")
+			return
+		}
+
+		utils.RespondWithSucess(w, http.StatusAccepted, "delivery queued", delivery)
+	}
+}
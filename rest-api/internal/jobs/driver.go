@@ -0,0 +1,48 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// returned by Ack, Retry and Fail when the reservation ran out and the job went back on the queue
+	ErrLeaseLost = errors.New("job lease expired")
+	// returned by Requeue when there is no failed job with the id
+	ErrNotFound = errors.New("job not found")
+)
+
+// how many failed jobs are kept for the admin endpoint, the oldest are dropped past this
+const maxFailed = 1000
+
+// Driver is where jobs are kept between being enqueued and finished. a job is only removed when it is
+// acked, so one whose worker dies comes back once its visibility timeout passes (at least once delivery)
+type Driver interface {
+	// adds a job that becomes due at job.RunAt
+	Push(ctx context.Context, job Job) error
+	// takes the next due job, counts the attempt and hides the job from other workers for visibility.
+	// returns nil when nothing is due
+	Reserve(ctx context.Context, visibility time.Duration) (*Job, error)
+	// removes a finished job
+	Ack(ctx context.Context, job *Job) error
+	// puts a job back to run again at runAt, keeping job.LastError
+	Retry(ctx context.Context, job *Job, runAt time.Time) error
+	// moves a job to the failed list
+	Fail(ctx context.Context, job *Job) error
+	Stats(ctx context.Context) (Stats, error)
+	// failed jobs, most recent first
+	Failed(ctx context.Context, offset, limit int) ([]Job, error)
+	// moves a failed job back onto the queue with its attempts reset
+	Requeue(ctx context.Context, id string) (Job, error)
+}
+
+// Stats is how many jobs are in each state
+type Stats struct {
+	// due and waiting for a worker
+	Ready int64 `json:"ready"`
+	// waiting for their run_at, including retries backing off
+	Delayed  int64 `json:"delayed"`
+	InFlight int64 `json:"in_flight"`
+	Failed   int64 `json:"failed"`
+}
@@ -0,0 +1,113 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// jobs get this many attempts unless their Type says otherwise
+const DefaultMaxAttempts = 10
+
+// Job is one unit of work as the driver stores it. the payload stays raw json until a handler decodes
+// it into the type it was enqueued with
+type Job struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	Payload     json.RawMessage `json:"payload"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	RunAt       time.Time       `json:"run_at"`
+	EnqueuedAt  time.Time       `json:"enqueued_at"`
+	LastError   string          `json:"last_error,omitempty"`
+	FailedAt    *time.Time      `json:"failed_at,omitempty"`
+
+	// when the current reservation runs out, in unix milliseconds. Ack, Retry and Fail only apply while
+	// it is still the job's lease, so a worker that overran can't settle a job someone else picked up
+	lease int64
+}
+
+// Type ties a job name to its payload, so producers and handlers can't disagree on the shape
+type Type[T any] struct {
+	Name string
+	// 0 means DefaultMaxAttempts
+	MaxAttempts int
+}
+
+func NewType[T any](name string) Type[T] {
+	return Type[T]{Name: name}
+}
+
+// Enqueue queues a job that is due straight away
+func (t Type[T]) Enqueue(ctx context.Context, q *Queue, payload T) (Job, error) {
+	return t.EnqueueAt(ctx, q, time.Now(), payload)
+}
+
+// EnqueueIn queues a job that becomes due after delay
+func (t Type[T]) EnqueueIn(ctx context.Context, q *Queue, delay time.Duration, payload T) (Job, error) {
+	return t.EnqueueAt(ctx, q, time.Now().Add(delay), payload)
+}
+
+// EnqueueAt queues a job that becomes due at runAt
+func (t Type[T]) EnqueueAt(ctx context.Context, q *Queue, runAt time.Time, payload T) (Job, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return Job{}, fmt.Errorf("encoding %s payload: %w", t.Name, err)
+	}
+
+	maxAttempts := t.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+
+	id, err := newID()
+	if err != nil {
+		return Job{}, err
+	}
+
+	job := Job{
+		ID:          id,
+		Type:        t.Name,
+		Payload:     raw,
+		MaxAttempts: maxAttempts,
+		RunAt:       runAt,
+		EnqueuedAt:  time.Now(),
+	}
+
+	if err := q.driver.Push(ctx, job); err != nil {
+		return Job{}, err
+	}
+
+	return job, nil
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps an error a handler returns when trying again can't help, such as a payload that
+// refers to a deleted row. the job fails straight away instead of using up its retries
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+func isPermanent(err error) bool {
+	var p permanentError
+	return errors.As(err, &p)
+}
@@ -0,0 +1,212 @@
+package jobs
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+type memoryState int
+
+const (
+	memoryQueued memoryState = iota
+	memoryInFlight
+	memoryFailed
+)
+
+type memoryEntry struct {
+	job   Job
+	state memoryState
+	// run_at while queued, the lease deadline while in flight and when it failed once failed
+	at time.Time
+}
+
+// Memory keeps jobs in process. it behaves like the redis driver, visibility timeouts included, so
+// it can stand in for it in tests. set Now to control the clock
+type Memory struct {
+	Now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		Now:     time.Now,
+		entries: make(map[string]*memoryEntry),
+	}
+}
+
+func (m *Memory) Push(ctx context.Context, job Job) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.entries[job.ID] = &memoryEntry{job: job, state: memoryQueued, at: job.RunAt}
+	return nil
+}
+
+func (m *Memory) Reserve(ctx context.Context, visibility time.Duration) (*Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.Now()
+
+	var next *memoryEntry
+	for _, entry := range m.entries {
+		// a reservation that ran out goes back on the queue
+		if entry.state == memoryInFlight && !entry.at.After(now) {
+			entry.state = memoryQueued
+		}
+
+		if entry.state == memoryQueued && !entry.at.After(now) && (next == nil || entry.at.Before(next.at)) {
+			next = entry
+		}
+	}
+
+	if next == nil {
+		return nil, nil
+	}
+
+	next.state = memoryInFlight
+	next.at = now.Add(visibility)
+	next.job.Attempts++
+	next.job.lease = next.at.UnixMilli()
+
+	job := next.job
+	return &job, nil
+}
+
+// the entry for a job that still holds its lease
+func (m *Memory) leased(job *Job) (*memoryEntry, error) {
+	entry, ok := m.entries[job.ID]
+	if !ok || entry.state != memoryInFlight || entry.job.lease != job.lease {
+		return nil, ErrLeaseLost
+	}
+
+	return entry, nil
+}
+
+func (m *Memory) Ack(ctx context.Context, job *Job) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, err := m.leased(job); err != nil {
+		return err
+	}
+
+	delete(m.entries, job.ID)
+	return nil
+}
+
+func (m *Memory) Retry(ctx context.Context, job *Job, runAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, err := m.leased(job)
+	if err != nil {
+		return err
+	}
+
+	entry.job.LastError = job.LastError
+	entry.job.RunAt = runAt
+	entry.state = memoryQueued
+	entry.at = runAt
+	return nil
+}
+
+func (m *Memory) Fail(ctx context.Context, job *Job) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, err := m.leased(job)
+	if err != nil {
+		return err
+	}
+
+	now := m.Now()
+	entry.job.LastError = job.LastError
+	entry.job.FailedAt = &now
+	entry.state = memoryFailed
+	entry.at = now
+
+	// same cap as redis, drop the oldest failures
+	failed := m.failed()
+	for _, old := range failed[min(len(failed), maxFailed):] {
+		delete(m.entries, old.job.ID)
+	}
+	return nil
+}
+
+// failed entries, most recent first
+func (m *Memory) failed() []*memoryEntry {
+	var failed []*memoryEntry
+	for _, entry := range m.entries {
+		if entry.state == memoryFailed {
+			failed = append(failed, entry)
+		}
+	}
+
+	slices.SortFunc(failed, func(a, b *memoryEntry) int {
+		return b.at.Compare(a.at)
+	})
+	return failed
+}
+
+func (m *Memory) Stats(ctx context.Context) (Stats, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.Now()
+
+	var stats Stats
+	for _, entry := range m.entries {
+		switch {
+		case entry.state == memoryFailed:
+			stats.Failed++
+		case entry.state == memoryInFlight:
+			stats.InFlight++
+		case entry.at.After(now):
+			stats.Delayed++
+		default:
+			stats.Ready++
+		}
+	}
+
+	return stats, nil
+}
+
+func (m *Memory) Failed(ctx context.Context, offset, limit int) ([]Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	failed := m.failed()
+	failed = failed[min(offset, len(failed)):]
+	failed = failed[:min(limit, len(failed))]
+
+	jobs := make([]Job, 0, len(failed))
+	for _, entry := range failed {
+		jobs = append(jobs, entry.job)
+	}
+
+	return jobs, nil
+}
+
+func (m *Memory) Requeue(ctx context.Context, id string) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.entries[id]
+	if !ok || entry.state != memoryFailed {
+		return Job{}, ErrNotFound
+	}
+
+	now := m.Now()
+	entry.job.Attempts = 0
+	entry.job.FailedAt = nil
+	entry.job.RunAt = now
+	entry.state = memoryQueued
+	entry.at = now
+
+	return entry.job, nil
+}
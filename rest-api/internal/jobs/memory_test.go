@@ -0,0 +1,139 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type testPayload struct {
+	N int `json:"n"`
+}
+
+var testType = Type[testPayload]{Name: "test.job", MaxAttempts: 3}
+
+// a memory driver on a clock the test moves by hand
+func newTestMemory() (*Memory, *time.Time) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	m := NewMemory()
+	m.Now = func() time.Time { return now }
+	return m, &now
+}
+
+func TestMemoryReservesDueJobsInOrder(t *testing.T) {
+	ctx := context.Background()
+	m, now := newTestMemory()
+	q := NewQueue(m)
+
+	later, err := testType.EnqueueAt(ctx, q, now.Add(time.Minute), testPayload{N: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, _ := testType.EnqueueAt(ctx, q, now.Add(-2*time.Second), testPayload{N: 2})
+	second, _ := testType.EnqueueAt(ctx, q, now.Add(-time.Second), testPayload{N: 3})
+
+	// held well past the clock moving on below
+	for _, want := range []string{first.ID, second.ID} {
+		job, err := m.Reserve(ctx, time.Hour)
+		if err != nil || job == nil {
+			t.Fatalf("Reserve = %v, %v, want job %s", job, err, want)
+		}
+		if job.ID != want || job.Attempts != 1 {
+			t.Fatalf("reserved %s attempt %d, want %s attempt 1", job.ID, job.Attempts, want)
+		}
+	}
+
+	// not due yet
+	if job, _ := m.Reserve(ctx, time.Minute); job != nil {
+		t.Fatalf("reserved %s before its run_at", job.ID)
+	}
+
+	stats, _ := m.Stats(ctx)
+	if stats != (Stats{Delayed: 1, InFlight: 2}) {
+		t.Fatalf("Stats = %+v", stats)
+	}
+
+	*now = now.Add(time.Minute)
+	if job, _ := m.Reserve(ctx, time.Minute); job == nil || job.ID != later.ID {
+		t.Fatalf("Reserve = %v, want %s once due", job, later.ID)
+	}
+}
+
+func TestMemoryVisibilityTimeout(t *testing.T) {
+	ctx := context.Background()
+	m, now := newTestMemory()
+
+	if _, err := testType.Enqueue(ctx, NewQueue(m), testPayload{}); err != nil {
+		t.Fatal(err)
+	}
+	// Enqueue stamps the real time, move the test clock past it
+	*now = time.Now().Add(time.Second)
+
+	stale, _ := m.Reserve(ctx, time.Minute)
+	if stale == nil {
+		t.Fatal("nothing reserved")
+	}
+	if job, _ := m.Reserve(ctx, time.Minute); job != nil {
+		t.Fatal("an in flight job was reserved twice")
+	}
+
+	// the worker died, the job comes back once the reservation runs out
+	*now = now.Add(time.Minute)
+	fresh, _ := m.Reserve(ctx, time.Minute)
+	if fresh == nil || fresh.ID != stale.ID || fresh.Attempts != 2 {
+		t.Fatalf("Reserve = %+v, want %s on attempt 2", fresh, stale.ID)
+	}
+
+	// the first worker can't settle a job it no longer holds
+	if err := m.Ack(ctx, stale); !errors.Is(err, ErrLeaseLost) {
+		t.Fatalf("Ack with an expired lease = %v, want ErrLeaseLost", err)
+	}
+	if err := m.Ack(ctx, fresh); err != nil {
+		t.Fatalf("Ack: %v", err)
+	}
+
+	stats, _ := m.Stats(ctx)
+	if stats != (Stats{}) {
+		t.Fatalf("Stats after ack = %+v, want empty", stats)
+	}
+}
+
+func TestMemoryFailAndRequeue(t *testing.T) {
+	ctx := context.Background()
+	m, now := newTestMemory()
+	q := NewQueue(m)
+
+	if _, err := testType.EnqueueAt(ctx, q, *now, testPayload{}); err != nil {
+		t.Fatal(err)
+	}
+
+	job, _ := m.Reserve(ctx, time.Minute)
+	job.LastError = "boom"
+	if err := m.Fail(ctx, job); err != nil {
+		t.Fatalf("Fail: %v", err)
+	}
+
+	failed, _ := q.Failed(ctx, 0, 10)
+	if len(failed) != 1 || failed[0].ID != job.ID || failed[0].LastError != "boom" || failed[0].FailedAt == nil {
+		t.Fatalf("Failed = %+v", failed)
+	}
+	if job, _ := m.Reserve(ctx, time.Minute); job != nil {
+		t.Fatal("a failed job was reserved")
+	}
+
+	requeued, err := q.Requeue(ctx, job.ID)
+	if err != nil {
+		t.Fatalf("Requeue: %v", err)
+	}
+	if requeued.Attempts != 0 || requeued.FailedAt != nil {
+		t.Fatalf("requeued job %+v still carries its failure", requeued)
+	}
+	if job, _ := m.Reserve(ctx, time.Minute); job == nil || job.Attempts != 1 {
+		t.Fatalf("Reserve after Requeue = %+v, want attempt 1", job)
+	}
+
+	if _, err := q.Requeue(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Requeue of an unknown id = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,28 @@
+package jobs
+
+import (
+	"context"
+)
+
+// Queue is the producer side, handlers enqueue through it with Type.Enqueue and the admin endpoint
+// reads from it
+type Queue struct {
+	driver Driver
+}
+
+func NewQueue(driver Driver) *Queue {
+	return &Queue{driver: driver}
+}
+
+func (q *Queue) Stats(ctx context.Context) (Stats, error) {
+	return q.driver.Stats(ctx)
+}
+
+func (q *Queue) Failed(ctx context.Context, offset, limit int) ([]Job, error) {
+	return q.driver.Failed(ctx, offset, limit)
+}
+
+// Requeue gives a failed job a fresh set of attempts, due straight away
+func (q *Queue) Requeue(ctx context.Context, id string) (Job, error) {
+	return q.driver.Requeue(ctx, id)
+}
@@ -0,0 +1,288 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis keeps jobs in five keys under a prefix, all in one hash slot so the scripts work on a cluster:
+//
+//	{prefix}:queued    sorted set of ids scored by when they are due
+//	{prefix}:inflight  sorted set of reserved ids scored by when their lease runs out
+//	{prefix}:failed    sorted set of ids scored by when they failed
+//	{prefix}:data      hash of id to job json
+//	{prefix}:attempts  hash of id to attempts so far
+//
+// every move between the sets happens in a lua script, so a job is always in exactly one of them
+type Redis struct {
+	rdb      *redis.Client
+	queued   string
+	inflight string
+	failed   string
+	data     string
+	attempts string
+}
+
+func NewRedis(rdb *redis.Client, prefix string) *Redis {
+	key := func(name string) string {
+		return fmt.Sprintf("{%s}:%s", prefix, name)
+	}
+
+	return &Redis{
+		rdb:      rdb,
+		queued:   key("queued"),
+		inflight: key("inflight"),
+		failed:   key("failed"),
+		data:     key("data"),
+		attempts: key("attempts"),
+	}
+}
+
+// puts expired reservations back on the queue, then moves the first due job to inflight
+var reserveScript = redis.NewScript(`
+local expired = redis.call('ZRANGEBYSCORE', KEYS[2], '-inf', ARGV[1], 'LIMIT', 0, 100)
+for _, id in ipairs(expired) do
+	redis.call('ZREM', KEYS[2], id)
+	redis.call('ZADD', KEYS[1], ARGV[1], id)
+end
+
+local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, 1)
+if #ids == 0 then
+	return false
+end
+
+local id = ids[1]
+redis.call('ZREM', KEYS[1], id)
+local data = redis.call('HGET', KEYS[3], id)
+if not data then
+	redis.call('HDEL', KEYS[4], id)
+	return false
+end
+
+redis.call('ZADD', KEYS[2], ARGV[2], id)
+local attempts = redis.call('HINCRBY', KEYS[4], id, 1)
+return {data, attempts}
+`)
+
+// finishes a reservation if the lease is still ours. with no job json the job is acked, otherwise it
+// is stored and moved to KEYS[2] scored by ARGV[4], which for the failed set is then trimmed to ARGV[5]
+var settleScript = redis.NewScript(`
+local lease = redis.call('ZSCORE', KEYS[1], ARGV[1])
+if not lease or tonumber(lease) ~= tonumber(ARGV[2]) then
+	return 0
+end
+redis.call('ZREM', KEYS[1], ARGV[1])
+
+if ARGV[3] == '' then
+	redis.call('HDEL', KEYS[3], ARGV[1])
+	redis.call('HDEL', KEYS[4], ARGV[1])
+	return 1
+end
+
+redis.call('HSET', KEYS[3], ARGV[1], ARGV[3])
+redis.call('ZADD', KEYS[2], ARGV[4], ARGV[1])
+
+local keep = tonumber(ARGV[5])
+if keep > 0 then
+	for _, old in ipairs(redis.call('ZRANGE', KEYS[2], 0, -keep - 1)) do
+		redis.call('ZREM', KEYS[2], old)
+		redis.call('HDEL', KEYS[3], old)
+		redis.call('HDEL', KEYS[4], old)
+	end
+end
+return 1
+`)
+
+// moves a failed job back to the queue
+var requeueScript = redis.NewScript(`
+if redis.call('ZREM', KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+redis.call('HSET', KEYS[3], ARGV[1], ARGV[3])
+redis.call('HDEL', KEYS[4], ARGV[1])
+redis.call('ZADD', KEYS[2], ARGV[2], ARGV[1])
+return 1
+`)
+
+func (r *Redis) Push(ctx context.Context, job Job) error {
+	raw, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, r.data, job.ID, raw)
+		pipe.ZAdd(ctx, r.queued, redis.Z{Score: float64(job.RunAt.UnixMilli()), Member: job.ID})
+		return nil
+	})
+	return err
+}
+
+func (r *Redis) Reserve(ctx context.Context, visibility time.Duration) (*Job, error) {
+	now := time.Now()
+	lease := now.Add(visibility).UnixMilli()
+
+	res, err := reserveScript.Run(ctx, r.rdb,
+		[]string{r.queued, r.inflight, r.data, r.attempts},
+		now.UnixMilli(), lease,
+	).Slice()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	raw, _ := res[0].(string)
+	attempts, _ := res[1].(int64)
+
+	var job Job
+	if err := json.Unmarshal([]byte(raw), &job); err != nil {
+		return nil, fmt.Errorf("decoding job: %w", err)
+	}
+	job.Attempts = int(attempts)
+	job.lease = lease
+
+	return &job, nil
+}
+
+func (r *Redis) settle(ctx context.Context, job *Job, target string, score int64, keep int) error {
+	var raw []byte
+	if target != "" {
+		var err error
+		if raw, err = json.Marshal(job); err != nil {
+			return err
+		}
+	} else {
+		target = r.queued
+	}
+
+	settled, err := settleScript.Run(ctx, r.rdb,
+		[]string{r.inflight, target, r.data, r.attempts},
+		job.ID, job.lease, raw, score, keep,
+	).Int()
+	if err != nil {
+		return err
+	}
+	if settled == 0 {
+		return ErrLeaseLost
+	}
+
+	return nil
+}
+
+func (r *Redis) Ack(ctx context.Context, job *Job) error {
+	return r.settle(ctx, job, "", 0, 0)
+}
+
+func (r *Redis) Retry(ctx context.Context, job *Job, runAt time.Time) error {
+	retry := *job
+	retry.RunAt = runAt
+	return r.settle(ctx, &retry, r.queued, runAt.UnixMilli(), 0)
+}
+
+func (r *Redis) Fail(ctx context.Context, job *Job) error {
+	now := time.Now()
+	failed := *job
+	failed.FailedAt = &now
+	return r.settle(ctx, &failed, r.failed, now.UnixMilli(), maxFailed)
+}
+
+func (r *Redis) Stats(ctx context.Context) (Stats, error) {
+	now := strconv.FormatInt(time.Now().UnixMilli(), 10)
+
+	var ready, queued, inflight, failed *redis.IntCmd
+	_, err := r.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		ready = pipe.ZCount(ctx, r.queued, "-inf", now)
+		queued = pipe.ZCard(ctx, r.queued)
+		inflight = pipe.ZCard(ctx, r.inflight)
+		failed = pipe.ZCard(ctx, r.failed)
+		return nil
+	})
+	if err != nil {
+		return Stats{}, err
+	}
+
+	return Stats{
+		Ready:    ready.Val(),
+		Delayed:  queued.Val() - ready.Val(),
+		InFlight: inflight.Val(),
+		Failed:   failed.Val(),
+	}, nil
+}
+
+func (r *Redis) Failed(ctx context.Context, offset, limit int) ([]Job, error) {
+	ids, err := r.rdb.ZRevRange(ctx, r.failed, int64(offset), int64(offset+limit-1)).Result()
+	if err != nil || len(ids) == 0 {
+		return []Job{}, err
+	}
+
+	var data, attempts *redis.SliceCmd
+	if _, err := r.rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		data = pipe.HMGet(ctx, r.data, ids...)
+		attempts = pipe.HMGet(ctx, r.attempts, ids...)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	jobs := make([]Job, 0, len(ids))
+	for i, value := range data.Val() {
+		raw, ok := value.(string)
+		if !ok {
+			continue
+		}
+
+		var job Job
+		if err := json.Unmarshal([]byte(raw), &job); err != nil {
+			return nil, fmt.Errorf("decoding job %s: %w", ids[i], err)
+		}
+		if count, ok := attempts.Val()[i].(string); ok {
+			job.Attempts, _ = strconv.Atoi(count)
+		}
+
+		jobs = append(jobs, job)
+	}
+
+	return jobs, nil
+}
+
+func (r *Redis) Requeue(ctx context.Context, id string) (Job, error) {
+	raw, err := r.rdb.HGet(ctx, r.data, id).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return Job{}, ErrNotFound
+	} else if err != nil {
+		return Job{}, err
+	}
+
+	var job Job
+	if err := json.Unmarshal(raw, &job); err != nil {
+		return Job{}, fmt.Errorf("decoding job %s: %w", id, err)
+	}
+
+	job.Attempts = 0
+	job.FailedAt = nil
+	job.RunAt = time.Now()
+
+	if raw, err = json.Marshal(job); err != nil {
+		return Job{}, err
+	}
+
+	requeued, err := requeueScript.Run(ctx, r.rdb,
+		[]string{r.failed, r.queued, r.data, r.attempts},
+		id, job.RunAt.UnixMilli(), raw,
+	).Int()
+	if err != nil {
+		return Job{}, err
+	}
+	if requeued == 0 {
+		return Job{}, ErrNotFound
+	}
+
+	return job, nil
+}
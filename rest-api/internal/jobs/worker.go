@@ -0,0 +1,186 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// Backoff is how long a failed job waits before its next attempt
+type Backoff struct {
+	// wait after the first failure, doubled after each one after that
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+var DefaultBackoff = Backoff{
+	BaseDelay: 10 * time.Second,
+	MaxDelay:  time.Hour,
+}
+
+// Delay returns the wait after the given attempt failed, with up to a fifth of jitter so jobs that
+// failed together don't all retry together
+func (b Backoff) Delay(attempts int) time.Duration {
+	delay := b.BaseDelay
+	for i := 1; i < attempts && delay < b.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > b.MaxDelay {
+		delay = b.MaxDelay
+	}
+
+	return delay + rand.N(delay/5+1)
+}
+
+type WorkerOptions struct {
+	// jobs run at the same time
+	Concurrency int
+	// how long an idle worker waits before looking for due jobs again
+	PollInterval time.Duration
+	// how long a reserved job is hidden from other workers. it is also the handler's deadline, so a
+	// job is never run twice at once because it was slow
+	Visibility time.Duration
+	// once Run's context is cancelled, how long running jobs get to finish before theirs is cancelled too
+	ShutdownTimeout time.Duration
+	Backoff         Backoff
+}
+
+type handlerFunc func(ctx context.Context, job *Job) error
+
+// Worker runs handlers for due jobs
+type Worker struct {
+	driver   Driver
+	opts     WorkerOptions
+	handlers map[string]handlerFunc
+}
+
+func NewWorker(driver Driver, opts WorkerOptions) *Worker {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 1
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Second
+	}
+	if opts.Visibility <= 0 {
+		opts.Visibility = 5 * time.Minute
+	}
+	if opts.Backoff == (Backoff{}) {
+		opts.Backoff = DefaultBackoff
+	}
+
+	return &Worker{
+		driver:   driver,
+		opts:     opts,
+		handlers: make(map[string]handlerFunc),
+	}
+}
+
+// Handle registers the handler for a job type, call it before Run. the payload is decoded into T first,
+// a payload that doesn't decode fails the job without retrying
+func Handle[T any](w *Worker, t Type[T], fn func(ctx context.Context, payload T) error) {
+	w.handlers[t.Name] = func(ctx context.Context, job *Job) error {
+		var payload T
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			return Permanent(fmt.Errorf("decoding payload: %w", err))
+		}
+
+		return fn(ctx, payload)
+	}
+}
+
+// Run works through due jobs until ctx is cancelled, then waits for running jobs to finish. jobs still
+// running after ShutdownTimeout have their context cancelled, and whatever they don't settle comes
+// back once its visibility timeout passes
+func (w *Worker) Run(ctx context.Context) {
+	jobCtx, cancelJobs := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelJobs()
+
+	stop := context.AfterFunc(ctx, func() {
+		time.AfterFunc(w.opts.ShutdownTimeout, cancelJobs)
+	})
+	defer stop()
+
+	var wg sync.WaitGroup
+	for range w.opts.Concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.loop(ctx, jobCtx)
+		}()
+	}
+	wg.Wait()
+}
+
+func (w *Worker) loop(ctx, jobCtx context.Context) {
+	for ctx.Err() == nil {
+		job, err := w.driver.Reserve(ctx, w.opts.Visibility)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Error reserving job: %v", err)
+		}
+
+		if job == nil {
+			select {
+			case <-ctx.Done():
+			case <-time.After(w.opts.PollInterval):
+			}
+			continue
+		}
+
+		w.process(jobCtx, job)
+	}
+}
+
+func (w *Worker) process(ctx context.Context, job *Job) {
+	err := w.run(ctx, job)
+	stopped := ctx.Err() != nil
+
+	// settle even if shutdown cancelled the job, redis is still there
+	ctx = context.WithoutCancel(ctx)
+
+	var settleErr error
+	switch {
+	case err == nil:
+		settleErr = w.driver.Ack(ctx, job)
+	case stopped:
+		// cut short by shutdown, due again straight away for another worker
+		job.LastError = err.Error()
+		settleErr = w.driver.Retry(ctx, job, time.Now())
+	case isPermanent(err) || job.Attempts >= job.MaxAttempts:
+		job.LastError = err.Error()
+		log.Printf("Job %s (%s) failed after %d attempts: %v", job.ID, job.Type, job.Attempts, err)
+		settleErr = w.driver.Fail(ctx, job)
+	default:
+		job.LastError = err.Error()
+		settleErr = w.driver.Retry(ctx, job, time.Now().Add(w.opts.Backoff.Delay(job.Attempts)))
+	}
+
+	if errors.Is(settleErr, ErrLeaseLost) {
+		log.Printf("Job %s (%s) ran past its visibility timeout and will run again", job.ID, job.Type)
+	} else if settleErr != nil {
+		log.Printf("Error settling job %s (%s): %v", job.ID, job.Type, settleErr)
+	}
+}
+
+// runs the handler within the visibility timeout, a panic counts as a failed attempt
+func (w *Worker) run(ctx context.Context, job *Job) (err error) {
+	handler, ok := w.handlers[job.Type]
+	if !ok {
+		return Permanent(fmt.Errorf("no handler registered for %q", job.Type))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, w.opts.Visibility)
+	defer cancel()
+
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+
+	return handler(ctx, job)
+}
@@ -0,0 +1,170 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestWorker(m *Memory) *Worker {
+	return NewWorker(m, WorkerOptions{
+		Visibility: time.Minute,
+		Backoff:    Backoff{BaseDelay: time.Hour, MaxDelay: time.Hour},
+	})
+}
+
+// enqueues a job due now on the memory clock and reserves it the way the worker loop would
+func reserveTestJob(t *testing.T, m *Memory, now time.Time) *Job {
+	t.Helper()
+
+	if _, err := testType.EnqueueAt(context.Background(), NewQueue(m), now, testPayload{N: 7}); err != nil {
+		t.Fatal(err)
+	}
+	job, err := m.Reserve(context.Background(), time.Minute)
+	if err != nil || job == nil {
+		t.Fatalf("Reserve = %v, %v", job, err)
+	}
+	return job
+}
+
+func TestWorkerAcksSuccess(t *testing.T) {
+	m, now := newTestMemory()
+	w := newTestWorker(m)
+
+	var got testPayload
+	Handle(w, testType, func(ctx context.Context, p testPayload) error {
+		got = p
+		return nil
+	})
+
+	w.process(context.Background(), reserveTestJob(t, m, *now))
+
+	if got.N != 7 {
+		t.Fatalf("handler got %+v, want the enqueued payload", got)
+	}
+	if stats, _ := m.Stats(context.Background()); stats != (Stats{}) {
+		t.Fatalf("Stats = %+v, want the job gone", stats)
+	}
+}
+
+func TestWorkerRetriesWithBackoff(t *testing.T) {
+	m, now := newTestMemory()
+	w := newTestWorker(m)
+
+	Handle(w, testType, func(ctx context.Context, p testPayload) error {
+		return errors.New("receiver down")
+	})
+
+	job := reserveTestJob(t, m, *now)
+	w.process(context.Background(), job)
+
+	// backing off, so delayed rather than failed
+	stats, _ := m.Stats(context.Background())
+	if stats != (Stats{Delayed: 1}) {
+		t.Fatalf("Stats = %+v, want one delayed job", stats)
+	}
+
+	entry := m.entries[job.ID]
+	if entry.job.LastError != "receiver down" {
+		t.Fatalf("LastError = %q", entry.job.LastError)
+	}
+	if wait := entry.job.RunAt.Sub(time.Now()); wait < 59*time.Minute {
+		t.Fatalf("retry due in %v, want the hour of backoff", wait)
+	}
+}
+
+func TestWorkerFailsJobs(t *testing.T) {
+	tests := []struct {
+		name     string
+		attempts int
+		err      error
+		panics   bool
+	}{
+		{"out of attempts", testType.MaxAttempts, errors.New("still down"), false},
+		{"permanent error", 1, Permanent(errors.New("blog was deleted")), false},
+		// a panic is a failed attempt like any other
+		{"panic on the last attempt", testType.MaxAttempts, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, now := newTestMemory()
+			w := newTestWorker(m)
+
+			Handle(w, testType, func(ctx context.Context, p testPayload) error {
+				if tt.panics {
+					panic("nil map")
+				}
+				return tt.err
+			})
+
+			job := reserveTestJob(t, m, *now)
+			job.Attempts = tt.attempts
+			m.entries[job.ID].job.Attempts = tt.attempts
+			w.process(context.Background(), job)
+
+			failed, _ := m.Failed(context.Background(), 0, 10)
+			if len(failed) != 1 || failed[0].ID != job.ID {
+				t.Fatalf("Failed = %+v, want the job", failed)
+			}
+		})
+	}
+}
+
+func TestWorkerFailsUndecodablePayload(t *testing.T) {
+	m, now := newTestMemory()
+	w := newTestWorker(m)
+	Handle(w, testType, func(ctx context.Context, p testPayload) error {
+		t.Fatal("handler ran with a payload that doesn't decode")
+		return nil
+	})
+
+	job := reserveTestJob(t, m, *now)
+	job.Payload = []byte(`{"n":"seven"}`)
+	w.process(context.Background(), job)
+
+	if failed, _ := m.Failed(context.Background(), 0, 10); len(failed) != 1 {
+		t.Fatalf("Failed = %+v, want the job failed on its first attempt", failed)
+	}
+}
+
+func TestWorkerRun(t *testing.T) {
+	m := NewMemory()
+	q := NewQueue(m)
+	w := NewWorker(m, WorkerOptions{Concurrency: 2, PollInterval: 10 * time.Millisecond})
+
+	var ran atomic.Int32
+	Handle(w, testType, func(ctx context.Context, p testPayload) error {
+		ran.Add(1)
+		return nil
+	})
+
+	for i := range 5 {
+		if _, err := testType.Enqueue(context.Background(), q, testPayload{N: i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		w.Run(ctx)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for ran.Load() < 5 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if ran.Load() != 5 {
+		t.Fatalf("ran %d jobs, want 5", ran.Load())
+	}
+	if stats, _ := q.Stats(context.Background()); stats != (Stats{}) {
+		t.Fatalf("Stats = %+v, want every job acked", stats)
+	}
+}
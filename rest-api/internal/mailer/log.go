@@ -0,0 +1,14 @@
+package mailer
+
+import (
+	"context"
+	"log"
+)
+
+// Log writes messages to the server log instead of sending them, for development
+type Log struct{}
+
+func (Log) Send(ctx context.Context, msg Message) error {
+	log.Printf("Mail to %s: %s\n%s", msg.To, msg.Subject, msg.Text)
+	return nil
+}
@@ -0,0 +1,49 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/exzacter/gorestapi/internal/jobs"
+	"github.com/exzacter/gorestapi/internal/serverconfig"
+	"github.com/redis/go-redis/v9"
+)
+
+// Message is a plain text email
+type Message struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+}
+
+// Mailer delivers email. Send should return once the message is handed over, not once it arrives
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Send delivers a message from a background job, so a slow or failing mail server never holds up a
+// request. the links mail carries expire quickly, so there is little point in retrying for long
+var Send = jobs.Type[Message]{Name: "mail.send", MaxAttempts: 3}
+
+// Register sends the mail.send and mail.send_stored jobs through m, call it before Run
+func Register(w *jobs.Worker, m Mailer, rdb *redis.Client) {
+	jobs.Handle(w, Send, m.Send)
+	jobs.Handle(w, SendStored, sendStored(rdb, m))
+}
+
+// Open builds the mailer picked by MAIL_DRIVER
+func Open(config *serverconfig.Config) (Mailer, error) {
+	switch config.MailDriver {
+	case "log":
+		return Log{}, nil
+	case "smtp":
+		return NewSMTP(SMTPOptions{
+			Addr:     config.SMTPAddr,
+			Username: config.SMTPUsername,
+			Password: config.SMTPPassword,
+			From:     config.MailFrom,
+		})
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", config.MailDriver)
+	}
+}
@@ -0,0 +1,68 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+type SMTPOptions struct {
+	// host:port of the server, such as smtp.example.com:587
+	Addr string
+	// leave both empty for servers that don't need to log in
+	Username string
+	Password string
+	From     string
+}
+
+// SMTP sends mail through an smtp server, with STARTTLS when the server offers it
+type SMTP struct {
+	opts SMTPOptions
+	host string
+}
+
+func NewSMTP(opts SMTPOptions) (*SMTP, error) {
+	if opts.Addr == "" || opts.From == "" {
+		return nil, errors.New("smtp needs SMTP_ADDR and MAIL_FROM")
+	}
+
+	host, _, err := net.SplitHostPort(opts.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SMTP_ADDR %q: %w", opts.Addr, err)
+	}
+
+	return &SMTP{opts: opts, host: host}, nil
+}
+
+func (s *SMTP) Send(ctx context.Context, msg Message) error {
+	// addresses come from users, a line break in one would let them add headers
+	if strings.ContainsAny(msg.To, "\r\n") || strings.ContainsAny(msg.Subject, "\r\n") {
+		return errors.New("mail headers must not contain line breaks")
+	}
+
+	var auth smtp.Auth
+	if s.opts.Username != "" {
+		auth = smtp.PlainAuth("", s.opts.Username, s.opts.Password, s.host)
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "From: %s\r\n", s.opts.From)
+	fmt.Fprintf(&body, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&body, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&body, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	body.WriteString("MIME-Version: 1.0\r\n")
+	body.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	body.WriteString(strings.ReplaceAll(msg.Text, "\n", "\r\n"))
+
+	// net/smtp takes no context, a cancelled job is only noticed before sending
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return smtp.SendMail(s.opts.Addr, auth, s.opts.From, []string{msg.To}, []byte(body.String()))
+}
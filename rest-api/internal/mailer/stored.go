@@ -0,0 +1,77 @@
+package mailer
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/exzacter/gorestapi/internal/jobs"
+	"github.com/redis/go-redis/v9"
+)
+
+// Stored is what a SendStored job carries, the id of a message kept in redis
+type Stored struct {
+	ID string `json:"id"`
+}
+
+// SendStored delivers a message kept in redis instead of in the job, for mail carrying a login link or
+// anything else that shouldn't sit in the queue or show up with the failed jobs
+var SendStored = jobs.Type[Stored]{Name: "mail.send_stored", MaxAttempts: 3}
+
+func storedKey(id string) string {
+	return "mail:" + id
+}
+
+// EnqueueStored keeps msg in redis for ttl and queues a SendStored job for it. give it the lifetime of
+// what the mail carries, once that is gone there is no point sending it
+func EnqueueStored(ctx context.Context, q *jobs.Queue, rdb *redis.Client, msg Message, ttl time.Duration) error {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return err
+	}
+	id := hex.EncodeToString(raw)
+
+	encoded, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	if err := rdb.Set(ctx, storedKey(id), encoded, ttl).Err(); err != nil {
+		return err
+	}
+
+	if _, err := SendStored.Enqueue(ctx, q, Stored{ID: id}); err != nil {
+		rdb.Del(context.WithoutCancel(ctx), storedKey(id))
+		return err
+	}
+
+	return nil
+}
+
+func sendStored(rdb *redis.Client, m Mailer) func(ctx context.Context, p Stored) error {
+	return func(ctx context.Context, p Stored) error {
+		key := storedKey(p.ID)
+
+		raw, err := rdb.Get(ctx, key).Bytes()
+		if err == redis.Nil {
+			return jobs.Permanent(errors.New("message expired before it was sent"))
+		} else if err != nil {
+			return err
+		}
+
+		var msg Message
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			return jobs.Permanent(err)
+		}
+
+		if err := m.Send(ctx, msg); err != nil {
+			return err
+		}
+
+		// kept until sent so a retry still has it
+		return rdb.Del(ctx, key).Err()
+	}
+}
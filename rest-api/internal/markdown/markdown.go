@@ -0,0 +1,52 @@
+package markdown
+
+import (
+	"bytes"
+	"html"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// GitHub flavoured markdown. raw html in posts is dropped by goldmark unless WithUnsafe is set,
+// and whatever does make it through is still run past the sanitiser
+var renderer = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+// allowlist for user content, drops script/style/iframe, on* event handlers and javascript: urls
+var sanitiser = bluemonday.UGCPolicy()
+
+// strips every tag, used to turn rendered html back into plain text
+var stripper = bluemonday.StrictPolicy()
+
+// ToHTML renders markdown into sanitised html that is safe to embed in a page
+func ToHTML(source string) (string, error) {
+	var buf bytes.Buffer
+	if err := renderer.Convert([]byte(source), &buf); err != nil {
+		return "", err
+	}
+
+	return sanitiser.Sanitize(buf.String()), nil
+}
+
+// Summary returns the first maxLen characters of the post as plain text, cut on a word boundary
+func Summary(source string, maxLen int) string {
+	rendered, err := ToHTML(source)
+	if err != nil {
+		rendered = source
+	}
+
+	// strict policy leaves entities escaped, undo that and squash the whitespace left by removed tags
+	text := strings.Join(strings.Fields(html.UnescapeString(stripper.Sanitize(rendered))), " ")
+	if utf8.RuneCountInString(text) <= maxLen {
+		return text
+	}
+
+	runes := []rune(text)[:maxLen]
+	if cut := strings.LastIndex(string(runes), " "); cut > 0 {
+		return string(runes)[:cut] + "…"
+	}
+	return string(runes) + "…"
+}
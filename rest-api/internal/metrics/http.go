@@ -0,0 +1,110 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type contextKey string
+
+const routeKey contextKey = "metricsRoute"
+
+// filled in by Routed as the request travels through the muxes
+type route struct {
+	path    string
+	pattern string
+}
+
+// Instrument records the count and latency of every request. The route label comes from the mux
+// pattern that matched, recorded by Routed, so /blogs/12 and /blogs/13 both count as /v1/blogs/{id}
+func Instrument(mux *http.ServeMux) http.Handler {
+	routed := Routed(mux)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		r = TrackRoute(r)
+
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		routed.ServeHTTP(sw, r)
+
+		pattern := RouteOf(r)
+		status := strconv.Itoa(sw.status)
+
+		requestsTotal.WithLabelValues(pattern, r.Method, status).Inc()
+		requestDuration.WithLabelValues(pattern, r.Method, status).Observe(time.Since(start).Seconds())
+	})
+}
+
+// TrackRoute gives the request somewhere for Routed to write the matched pattern. Middleware that runs
+// before Instrument, such as tracing, calls it too so it can read the pattern back with RouteOf
+func TrackRoute(r *http.Request) *http.Request {
+	if _, ok := r.Context().Value(routeKey).(*route); ok {
+		return r
+	}
+
+	return r.WithContext(context.WithValue(r.Context(), routeKey, &route{path: r.URL.Path}))
+}
+
+// RouteOf returns the pattern the request matched, "unmatched" when no mux matched it
+func RouteOf(r *http.Request) string {
+	if matched, ok := r.Context().Value(routeKey).(*route); ok && matched.pattern != "" {
+		return matched.pattern
+	}
+
+	return "unmatched"
+}
+
+// Routed wraps a mux, sub muxes mounted with http.StripPrefix included, and notes the pattern it matched.
+// the innermost mux runs last so it wins, and the prefix stripped on the way is put back in front
+func Routed(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if matched, ok := r.Context().Value(routeKey).(*route); ok {
+			if _, pattern := mux.Handler(r); pattern != "" {
+				// patterns can start with a method, "POST /login", the method is its own label
+				if _, path, found := strings.Cut(pattern, " "); found {
+					pattern = path
+				}
+
+				stripped := len(matched.path) - len(r.URL.Path)
+				if stripped >= 0 && stripped <= len(matched.path) {
+					matched.pattern = matched.path[:stripped] + pattern
+				}
+			}
+		}
+
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// keeps the status code for the labels
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *statusWriter) Flush() {
+	w.wroteHeader = true
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// lets http.ResponseController reach the real writer
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
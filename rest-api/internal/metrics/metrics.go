@@ -0,0 +1,149 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// set at build time with -ldflags "-X github.com/exzacter/gorestapi/internal/metrics.Version=1.2.3"
+var Version = "dev"
+
+const namespace = "gorestapi"
+
+// our own registry so only the metrics below are exposed, plus the usual go and process ones
+var registry = prometheus.NewRegistry()
+
+var (
+	requestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by route pattern, method and status code.",
+	}, []string{"route", "method", "status"})
+
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route pattern, method and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	redisDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "redis_command_duration_seconds",
+		Help:      "Redis command latency by command and result.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"command", "result"})
+
+	cacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_lookups_total",
+		Help:      "Redis cache lookups by cache and result (hit or miss). Hit ratio is hits / all lookups.",
+	}, []string{"cache", "result"})
+
+	loginAttempts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "login_attempts_total",
+		Help:      "Login attempts by result (success or failure).",
+	}, []string{"result"})
+
+	redisCircuit = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "redis_circuit_state",
+		Help:      "1 for the state the redis circuit breaker is in (closed, half_open or open), 0 for the others.",
+	}, []string{"state"})
+
+	degradedAuth = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "auth_degraded_total",
+		Help:      "JWT checks made without the redis revocation lists, by whether the request was let through (fail_open) or refused (fail_closed).",
+	}, []string{"mode"})
+
+	buildInfo = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "build_info",
+		Help:      "Always 1, labelled with the version, vcs revision and go version the binary was built with.",
+	}, []string{"version", "revision", "goversion"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		requestsTotal,
+		requestDuration,
+		redisDuration,
+		cacheLookups,
+		loginAttempts,
+		redisCircuit,
+		degradedAuth,
+		buildInfo,
+	)
+
+	buildInfo.WithLabelValues(Version, revision(), runtime.Version()).Set(1)
+	RedisCircuit("closed")
+}
+
+// Handler serves every registered metric in the prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// RegisterDB exposes the sql.DB pool stats (open, in use, idle, waits) as gauges
+func RegisterDB(db *sql.DB, name string) {
+	registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// Login counts a login attempt, failures are wrong usernames or passwords
+func Login(success bool) {
+	loginAttempts.WithLabelValues(result(success, "success", "failure")).Inc()
+}
+
+// CacheLookup counts a read from one of the redis caches
+func CacheLookup(cache string, hit bool) {
+	cacheLookups.WithLabelValues(cache, result(hit, "hit", "miss")).Inc()
+}
+
+// RedisCircuit records the state the redis circuit breaker moved to
+func RedisCircuit(state string) {
+	for _, s := range []string{"closed", "half_open", "open"} {
+		value := 0.0
+		if s == state {
+			value = 1
+		}
+		redisCircuit.WithLabelValues(s).Set(value)
+	}
+}
+
+// DegradedAuth counts a jwt checked while redis couldn't say whether it was revoked
+func DegradedAuth(failOpen bool) {
+	degradedAuth.WithLabelValues(result(failOpen, "fail_open", "fail_closed")).Inc()
+}
+
+func result(ok bool, yes, no string) string {
+	if ok {
+		return yes
+	}
+	return no
+}
+
+// the commit the binary was built from, go fills this in when building inside a git checkout
+func revision() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" {
+			return setting.Value
+		}
+	}
+
+	return "unknown"
+}
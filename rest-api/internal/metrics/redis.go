@@ -0,0 +1,40 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisHook times every redis command, add it with rdb.AddHook(metrics.RedisHook{})
+type RedisHook struct{}
+
+func (RedisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+func (RedisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmd)
+		observeRedis(cmd.Name(), err, time.Since(start))
+		return err
+	}
+}
+
+func (RedisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmds)
+		observeRedis("pipeline", err, time.Since(start))
+		return err
+	}
+}
+
+// a missing key (redis.Nil) is a normal answer, not a failure
+func observeRedis(command string, err error, took time.Duration) {
+	redisDuration.WithLabelValues(command, result(err == nil || err == redis.Nil, "ok", "error")).Observe(took.Seconds())
+}
+
+var _ redis.Hook = RedisHook{}
@@ -0,0 +1,33 @@
+package middlewares
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/exzacter/gorestapi/internal/utils"
+)
+
+// AdaptRequest lets a newer api version accept its own request body and still reuse an older handler.
+// The body is decoded as From, converted to the older DTO with convert and re-encoded, so next runs
+// unchanged. For example a v2 route can turn a v2 DTO into dtos.CreateBlogRequest and reuse the v1 handler
+func AdaptRequest[From, To any](convert func(From) To, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var in From
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+			return
+		}
+
+		body, err := json.Marshal(convert(in))
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, "Internal error")
+			return
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		r.ContentLength = int64(len(body))
+		next.ServeHTTP(w, r)
+	})
+}
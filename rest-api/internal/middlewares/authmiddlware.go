@@ -2,12 +2,19 @@ package middlewares
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"log"
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/exzacter/gorestapi/internal/auth"
+	"github.com/exzacter/gorestapi/internal/breaker"
 	"github.com/exzacter/gorestapi/internal/dbconfig"
+	"github.com/exzacter/gorestapi/internal/metrics"
+	"github.com/exzacter/gorestapi/internal/store"
 	"github.com/exzacter/gorestapi/internal/utils"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/redis/go-redis/v9"
@@ -16,12 +23,28 @@ import (
 // creates custom type for context key to avoid collision
 type contextKey string
 
-// constant used in storing the user claims
-const UserClaimsKey contextKey = "claims"
+// constant used in storing the *auth.Principal, set for both jwt and api key requests
+const PrincipalKey contextKey = "principal"
 
+// set in main.go, used to look up api keys
+var Queries *store.Queries
+
+// AuthMiddle accepts either a bearer jwt or an X-API-Key header and stores an *auth.Principal in the context
 func AuthMiddle(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
+		// machine clients send an api key instead of logging in
+		if apiKey := r.Header.Get("X-API-Key"); apiKey != "" {
+			principal, status, message := authenticateAPIKey(r.Context(), apiKey)
+			if principal == nil {
+				utils.RespondWithError(w, status, message)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), PrincipalKey, principal)))
+			return
+		}
+
 		// retrieves the authorization header from the request (postman/web/mobile)
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
@@ -33,16 +56,6 @@ func AuthMiddle(next http.Handler) http.Handler {
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 		claims := &auth.Claims{}
 
-		// check redis for blacklisted token
-		blacklisted, err := dbconfig.RedisClient.Get(r.Context(), tokenString).Result()
-		if err == nil && blacklisted == "blacklisted" {
-			utils.RespondWithError(w, http.StatusUnauthorized, "Token revoked")
-			return
-		} else if err != nil && err != redis.Nil {
-			utils.RespondWithError(w, http.StatusInternalServerError, "Internal error")
-			return
-		}
-
 		// Parse the token and validating it
 		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
 			// provided our key from the environment variable and validate it against the token from the request
@@ -62,13 +75,160 @@ func AuthMiddle(next http.Handler) http.Handler {
 			return
 		}
 
-		// if token is valid, store the claim in the request
-		if token.Valid {
-			ctx := context.WithValue(r.Context(), UserClaimsKey, claims)
-			r = r.WithContext(ctx) // replace request context with the new request
-			next.ServeHTTP(w, r)   // calls the enxt handler, with the updated request
-		} else {
+		if !token.Valid {
 			utils.RespondWithError(w, http.StatusUnauthorized, "Invalid Token")
+			return
 		}
+
+		// only signed tokens get this far, so forged ones never cost a redis round trip
+		if status, message := checkRevoked(r.Context(), tokenString, claims); status != 0 {
+			if status == http.StatusServiceUnavailable {
+				w.Header().Set("Retry-After", "10")
+			}
+			utils.RespondWithError(w, status, message)
+			return
+		}
+
+		// if token is valid, store the principal in the request
+		principal := &auth.Principal{
+			UserID:   claims.UserID,
+			Username: claims.Username,
+			Method:   auth.MethodJWT,
+			Claims:   claims,
+		}
+		ctx := context.WithValue(r.Context(), PrincipalKey, principal)
+		r = r.WithContext(ctx) // replace request context with the new request
+		next.ServeHTTP(w, r)   // calls the enxt handler, with the updated request
+	})
+}
+
+// RedisFailOpen decides what happens to a jwt when redis can't say whether it was revoked. true lets it
+// through with only this instance's own revocations checked, false refuses it with a 503. set in main.go
+var RedisFailOpen = true
+
+// returns the status and message to refuse a revoked token with, 0 when it may go through
+func checkRevoked(ctx context.Context, tokenString string, claims *auth.Claims) (int, string) {
+	if localRevocations.revoked(tokenString, claims.UserID, claims.IssuedBefore) {
+		return http.StatusUnauthorized, "Token revoked"
+	}
+
+	// check redis for blacklisted token
+	blacklisted, err := dbconfig.RedisClient.Get(ctx, tokenString).Result()
+	if err == nil && blacklisted == "blacklisted" {
+		RememberRevokedToken(tokenString, claims.ExpiresAt.Time)
+		return http.StatusUnauthorized, "Token revoked"
+	} else if err != nil && err != redis.Nil {
+		return revocationUnknown(ctx, err, claims.UserID)
+	}
+
+	// every token the user had was revoked at once, such as when the account was erased
+	revokedAt, err := dbconfig.RedisClient.Get(ctx, auth.TokensRevokedKey(claims.UserID)).Int64()
+	if err == nil {
+		RememberRevokedUser(claims.UserID, time.Unix(revokedAt, 0))
+		if claims.IssuedBefore(time.Unix(revokedAt, 0)) {
+			return http.StatusUnauthorized, "Token revoked"
+		}
+	} else if err != redis.Nil {
+		return revocationUnknown(ctx, err, claims.UserID)
+	}
+
+	return 0, ""
+}
+
+func revocationUnknown(ctx context.Context, err error, userID int64) (int, string) {
+	// the breaker logs when it opens, so only the failures that led up to it are logged here
+	if !errors.Is(err, breaker.ErrOpen) {
+		log.Printf("Revocation check failed: %v", err)
+	}
+
+	metrics.DegradedAuth(RedisFailOpen)
+	if !RedisFailOpen {
+		return http.StatusServiceUnavailable, "Authentication is temporarily unavailable"
+	}
+
+	// disabling an account revokes its tokens in redis, which can't be read now, so the account is checked
+	// in the database the way an api key's is
+	user, err := Queries.GetUser(ctx, int32(userID))
+	if err == sql.ErrNoRows {
+		return http.StatusUnauthorized, "Invalid Token"
+	} else if err != nil {
+		return http.StatusInternalServerError, "Internal error"
+	}
+	if user.DisabledAt.Valid {
+		return http.StatusForbidden, "Account disabled"
+	}
+
+	return 0, ""
+}
+
+// returns the principal for a valid key, otherwise the status and message to respond with
+func authenticateAPIKey(ctx context.Context, apiKey string) (*auth.Principal, int, string) {
+	prefix, secret, err := auth.ParseAPIKey(apiKey)
+	if err != nil {
+		return nil, http.StatusUnauthorized, "Invalid API key"
+	}
+
+	key, err := Queries.GetApiKeyByPrefix(ctx, prefix)
+	if err == sql.ErrNoRows {
+		return nil, http.StatusUnauthorized, "Invalid API key"
+	} else if err != nil {
+		return nil, http.StatusInternalServerError, "Internal error"
+	}
+
+	if !auth.APIKeySecretMatches(secret, key.SecretHash) {
+		return nil, http.StatusUnauthorized, "Invalid API key"
+	}
+	if key.RevokedAt.Valid {
+		return nil, http.StatusUnauthorized, "API key revoked"
+	}
+	if key.ExpiresAt.Valid && key.ExpiresAt.Time.Before(time.Now()) {
+		return nil, http.StatusUnauthorized, "API key expired"
+	}
+	if key.DisabledAt.Valid {
+		return nil, http.StatusForbidden, "Account disabled"
+	}
+
+	// last used is only informational, a failed write shouldn't fail the request
+	if err := Queries.TouchApiKey(ctx, key.ID); err != nil {
+		log.Printf("Failed to update last used for api key %d: %v", key.ID, err)
+	}
+
+	return &auth.Principal{
+		UserID:   int64(key.UserID),
+		Username: key.Username,
+		Method:   auth.MethodAPIKey,
+		Scopes:   key.Scopes,
+		APIKeyID: key.ID,
+	}, 0, ""
+}
+
+// RequireScope goes inside AuthMiddle. api keys without the scope get a 403, jwt logins always pass
+func RequireScope(scope string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := r.Context().Value(PrincipalKey).(*auth.Principal)
+		if !ok {
+			utils.RespondWithError(w, http.StatusUnauthorized, "Please login to continue")
+			return
+		}
+
+		if !principal.HasScope(scope) {
+			utils.RespondWithError(w, http.StatusForbidden, "API key is missing the "+scope+" scope")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireJWT goes inside AuthMiddle, for routes an api key must never reach such as managing api keys
+func RequireJWT(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := r.Context().Value(PrincipalKey).(*auth.Principal)
+		if !ok || principal.Method != auth.MethodJWT {
+			utils.RespondWithError(w, http.StatusForbidden, "This endpoint needs a logged in user, not an API key")
+			return
+		}
+
+		next.ServeHTTP(w, r)
 	})
 }
@@ -0,0 +1,321 @@
+package middlewares
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// content types that are already compressed, or streamed, and are sent as they are. an entry ending
+// in / matches every subtype
+var DefaultCompressExcludedTypes = []string{
+	"image/png", "image/jpeg", "image/gif", "image/webp", "image/avif",
+	"video/", "audio/",
+	"font/woff", "font/woff2",
+	"application/zip", "application/gzip", "application/x-gzip", "application/zstd",
+	"application/x-7z-compressed", "application/x-rar-compressed", "application/pdf",
+	"application/octet-stream",
+	"text/event-stream",
+}
+
+type CompressOptions struct {
+	// bodies smaller than this are sent uncompressed, the headers would outweigh the saving
+	MinSize int
+	// flate level from 1 (fastest) to 9 (smallest), 0 uses the default
+	Level int
+	// nil uses DefaultCompressExcludedTypes
+	ExcludedTypes []string
+}
+
+// Compress gzip or deflate encodes responses for clients that send a matching Accept-Encoding. headers
+// are held back until MinSize bytes have been written, so handlers that call WriteHeader before
+// encoding, like utils.RespondWithSucess, are still compressed. strong ETags get the encoding appended,
+// and the suffix is taken off If-None-Match and If-Match again, so conditional requests keep working
+func Compress(opts CompressOptions) func(http.Handler) http.Handler {
+	if opts.Level == 0 {
+		opts.Level = flate.DefaultCompression
+	}
+	if opts.ExcludedTypes == nil {
+		opts.ExcludedTypes = DefaultCompressExcludedTypes
+	}
+
+	// writers are reused, each holds several hundred kilobytes of state
+	pools := map[string]*sync.Pool{
+		"gzip": {New: func() any {
+			zw, _ := gzip.NewWriterLevel(io.Discard, opts.Level)
+			return zw
+		}},
+		"deflate": {New: func() any {
+			zw, _ := flate.NewWriter(io.Discard, opts.Level)
+			return zw
+		}},
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, name := range []string{"If-None-Match", "If-Match"} {
+				if header := r.Header.Get(name); header != "" {
+					r.Header.Set(name, stripEncodingSuffix(header))
+				}
+			}
+
+			encoding := ""
+			// HEAD and range responses have to match the identity body byte for byte
+			if r.Method != http.MethodHead && r.Header.Get("Range") == "" {
+				encoding = negotiateEncoding(r.Header.Get("Accept-Encoding"))
+			}
+
+			cw := &compressWriter{
+				ResponseWriter: w,
+				opts:           &opts,
+				pools:          pools,
+				encoding:       encoding,
+				status:         http.StatusOK,
+			}
+			defer cw.close()
+
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// picks gzip or deflate from Accept-Encoding, preferring gzip when both are equally acceptable
+func negotiateEncoding(header string) string {
+	best, bestQ := "", 0.0
+	wildcard := -1.0
+	explicit := map[string]bool{}
+
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		q := 1.0
+		if value, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+
+		switch name {
+		case "*":
+			wildcard = q
+		case "gzip", "x-gzip", "deflate":
+			if name == "x-gzip" {
+				name = "gzip"
+			}
+			explicit[name] = true
+			if q > bestQ || (q == bestQ && name == "gzip") {
+				best, bestQ = name, q
+			}
+		}
+	}
+
+	// * covers whichever encoding wasn't named
+	for _, name := range []string{"gzip", "deflate"} {
+		if wildcard > bestQ && !explicit[name] {
+			best, bestQ = name, wildcard
+		}
+	}
+	if bestQ <= 0 {
+		return ""
+	}
+
+	return best
+}
+
+// removes the encoding compressWriter adds to etags, "abc.gzip" back to "abc"
+func stripEncodingSuffix(header string) string {
+	for _, suffix := range []string{`.gzip"`, `.deflate"`} {
+		header = strings.ReplaceAll(header, suffix, `"`)
+	}
+
+	return header
+}
+
+type compressWriter struct {
+	http.ResponseWriter
+	opts     *CompressOptions
+	pools    map[string]*sync.Pool
+	encoding string
+
+	status int
+	// WriteHeader was called, the headers may still be held back
+	wroteHeader bool
+	// headers have gone out, either compressed or not
+	decided bool
+	buf     []byte
+	zw      compressor
+}
+
+// what *gzip.Writer and *flate.Writer have in common
+type compressor interface {
+	io.WriteCloser
+	Flush() error
+	Reset(io.Writer)
+}
+
+func (w *compressWriter) WriteHeader(status int) {
+	if w.wroteHeader || w.decided {
+		return
+	}
+
+	// informational responses can be sent more than once and don't count as the final status
+	if status >= 100 && status < 200 {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+
+	w.status = status
+	w.wroteHeader = true
+
+	// nothing to compress, send the headers now
+	if status == http.StatusNoContent || status == http.StatusNotModified || !w.compressible() {
+		w.passthrough()
+	}
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		// the type decides whether to compress, so work it out the way net/http would
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if w.decided {
+		if w.zw != nil {
+			return w.zw.Write(b)
+		}
+		return w.ResponseWriter.Write(b)
+	}
+
+	w.buf = append(w.buf, b...)
+	if len(w.buf) >= w.opts.MinSize {
+		if err := w.start(); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(b), nil
+}
+
+// Flush sends what is buffered now, compressing it if it could be compressed at all
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		if !w.wroteHeader {
+			w.WriteHeader(http.StatusOK)
+		}
+		if !w.decided {
+			w.start()
+		}
+	}
+
+	if w.zw != nil {
+		w.zw.Flush()
+	}
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// lets http.ResponseController reach the real writer
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// whether the response can be compressed at all, false once the handler encoded it itself
+func (w *compressWriter) compressible() bool {
+	if w.Header().Get("Content-Encoding") != "" {
+		return false
+	}
+
+	mediaType, _, err := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	if err != nil {
+		return true
+	}
+
+	return !slices.ContainsFunc(w.opts.ExcludedTypes, func(excluded string) bool {
+		if strings.HasSuffix(excluded, "/") {
+			return strings.HasPrefix(mediaType, excluded)
+		}
+		return mediaType == excluded
+	})
+}
+
+// the same url returns a different body for another Accept-Encoding, caches need to know. the etag
+// changes with the encoding so a strong etag always means the same bytes
+func (w *compressWriter) setVariantHeaders() {
+	if w.status != http.StatusNotModified && !w.compressible() {
+		return
+	}
+
+	if !slices.ContainsFunc(w.Header().Values("Vary"), func(v string) bool {
+		return strings.Contains(strings.ToLower(v), "accept-encoding")
+	}) {
+		w.Header().Add("Vary", "Accept-Encoding")
+	}
+
+	if etag := w.Header().Get("ETag"); w.encoding != "" && strings.HasPrefix(etag, `"`) {
+		w.Header().Set("ETag", strings.TrimSuffix(etag, `"`)+"."+w.encoding+`"`)
+	}
+}
+
+// sends the headers and anything buffered without compressing
+func (w *compressWriter) passthrough() {
+	w.decided = true
+	w.setVariantHeaders()
+	w.ResponseWriter.WriteHeader(w.status)
+
+	if len(w.buf) > 0 {
+		w.ResponseWriter.Write(w.buf)
+		w.buf = nil
+	}
+}
+
+// compresses from here on when the client and the content allow it, otherwise passes through
+func (w *compressWriter) start() error {
+	if w.encoding == "" || !w.compressible() {
+		w.passthrough()
+		return nil
+	}
+
+	w.decided = true
+	w.setVariantHeaders()
+	w.Header().Set("Content-Encoding", w.encoding)
+	// the handler's length was for the uncompressed body
+	w.Header().Del("Content-Length")
+	w.ResponseWriter.WriteHeader(w.status)
+
+	w.zw = w.pools[w.encoding].Get().(compressor)
+	w.zw.Reset(w.ResponseWriter)
+
+	_, err := w.zw.Write(w.buf)
+	w.buf = nil
+	return err
+}
+
+// finishes the body, small responses that never reached MinSize go out uncompressed here
+func (w *compressWriter) close() {
+	if !w.decided {
+		// the handler wrote nothing at all, leave it to net/http
+		if !w.wroteHeader {
+			return
+		}
+		w.passthrough()
+		return
+	}
+
+	if w.zw != nil {
+		w.zw.Close()
+		w.zw.Reset(io.Discard)
+		w.pools[w.encoding].Put(w.zw)
+		w.zw = nil
+	}
+}
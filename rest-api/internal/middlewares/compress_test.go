@@ -0,0 +1,150 @@
+package middlewares
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/exzacter/gorestapi/internal/utils"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", ""},
+		{"gzip", "gzip"},
+		{"deflate", "deflate"},
+		{"GZIP", "gzip"},
+		{"x-gzip", "gzip"},
+		{"gzip, deflate", "gzip"},
+		{"deflate, gzip", "gzip"},
+		{"gzip;q=0.8, deflate;q=0.8", "gzip"},
+		{"deflate, gzip;q=0.5", "deflate"},
+		{"gzip;q=0, deflate", "deflate"},
+		{"gzip;q=0", ""},
+		{"identity", ""},
+		{"br", ""},
+		// a q value that doesn't parse drops the entry
+		{"gzip;q=high", ""},
+		{"*", "gzip"},
+		{"*;q=0", ""},
+		// * only covers the encodings that weren't named
+		{"gzip;q=0, *", "deflate"},
+		{"deflate;q=0.5, *", "gzip"},
+		{"deflate, *;q=0.5", "deflate"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if got := negotiateEncoding(tt.header); got != tt.want {
+				t.Fatalf("negotiateEncoding(%q) = %q, want %q", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStripEncodingSuffix(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{`"blog-1-5.gzip"`, `"blog-1-5"`},
+		{`"blog-1-5.html.deflate"`, `"blog-1-5.html"`},
+		{`W/"blog-1-5.gzip"`, `W/"blog-1-5"`},
+		{`"blog-1-5.gzip", "blog-1-6.deflate"`, `"blog-1-5", "blog-1-6"`},
+		{`"blog-1-5.html"`, `"blog-1-5.html"`},
+		{`*`, `*`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if got := stripEncodingSuffix(tt.header); got != tt.want {
+				t.Fatalf("stripEncodingSuffix(%q) = %q, want %q", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompressConditionalRequests(t *testing.T) {
+	etag := utils.ETag("blog-1-5", "")
+	body := strings.Repeat("hello compression ", 100)
+
+	handler := Compress(CompressOptions{MinSize: 64})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if utils.NotModified(w, r, etag) {
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		io.WriteString(w, body)
+	}))
+
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/blogs/1", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get("")
+	if rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", rec.Header().Get("Content-Encoding"))
+	}
+	// the gzip body is a different representation, so it gets its own etag
+	encodedETag := rec.Header().Get("ETag")
+	if encodedETag != `"blog-1-5.gzip"` {
+		t.Fatalf("ETag = %q, want the encoding appended", encodedETag)
+	}
+
+	zr, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, _ := io.ReadAll(zr)
+	if string(decoded) != body {
+		t.Fatalf("decoded body differs from what the handler wrote")
+	}
+
+	// the handler only knows the unencoded etag, the suffix comes off on the way in
+	if rec := get(encodedETag); rec.Code != http.StatusNotModified {
+		t.Fatalf("If-None-Match with the gzip etag = %d, want 304", rec.Code)
+	}
+	if rec := get(`"blog-1-4.gzip"`); rec.Code != http.StatusOK {
+		t.Fatalf("If-None-Match with an old etag = %d, want 200", rec.Code)
+	}
+}
+
+func TestUncompressedHeader(t *testing.T) {
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set("Content-Encoding", "gzip")
+	header.Set("Content-Length", "42")
+	header.Set("Vary", "Origin, Accept-Encoding")
+	header.Set("ETag", `"blog-1-5.gzip"`)
+
+	got := uncompressedHeader(header)
+
+	if got.Get("Content-Encoding") != "" || got.Get("Content-Length") != "" {
+		t.Fatalf("encoding headers kept: %v", got)
+	}
+	if got.Get("Vary") != "Origin" {
+		t.Fatalf("Vary = %q, want Origin", got.Get("Vary"))
+	}
+	if got.Get("ETag") != `"blog-1-5"` {
+		t.Fatalf("ETag = %q, want the suffix stripped", got.Get("ETag"))
+	}
+	if got.Get("Content-Type") != "application/json" {
+		t.Fatalf("Content-Type = %q", got.Get("Content-Type"))
+	}
+	// the recorded response still goes out as it was
+	if header.Get("Content-Encoding") != "gzip" {
+		t.Fatal("the original header was changed")
+	}
+}
@@ -0,0 +1,153 @@
+package middlewares
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type CORSOptions struct {
+	// exact origins such as https://app.example.com, https://*.example.com for any subdomain, or * for everyone.
+	// * is ignored with AllowCredentials, every site could make logged in requests otherwise
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	// how long browsers may cache a preflight response
+	MaxAge time.Duration
+}
+
+// CORS wraps the whole router rather than single routes. Preflight requests are answered here before
+// routing, because method qualified patterns like "POST /login" never match an OPTIONS request
+func CORS(opts CORSOptions) func(http.Handler) http.Handler {
+	allowedMethods := make(map[string]bool, len(opts.AllowedMethods))
+	for _, m := range opts.AllowedMethods {
+		allowedMethods[strings.ToUpper(m)] = true
+	}
+
+	allowedHeaders := make(map[string]bool, len(opts.AllowedHeaders))
+	for _, h := range opts.AllowedHeaders {
+		allowedHeaders[http.CanonicalHeaderKey(h)] = true
+	}
+
+	methods := strings.Join(opts.AllowedMethods, ", ")
+	exposed := strings.Join(opts.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(opts.MaxAge.Seconds()))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+			// responses differ per origin, caches must not hand one origins answer to another
+			w.Header().Add("Vary", "Origin")
+			if preflight {
+				w.Header().Add("Vary", "Access-Control-Request-Method")
+				w.Header().Add("Vary", "Access-Control-Request-Headers")
+			}
+
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !originAllowed(opts.AllowedOrigins, origin, opts.AllowCredentials) {
+				// no cors headers means the browser blocks it, the request itself is left alone
+				if preflight {
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// with credentials the origin is echoed back, a literal * can't be combined with them
+			if !opts.AllowCredentials && len(opts.AllowedOrigins) == 1 && opts.AllowedOrigins[0] == "*" {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			} else {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+			}
+
+			if opts.AllowCredentials {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			if !preflight {
+				if exposed != "" {
+					w.Header().Set("Access-Control-Expose-Headers", exposed)
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !allowedMethods[strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))] {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			requested := splitHeaderList(r.Header.Get("Access-Control-Request-Headers"))
+			for _, h := range requested {
+				if !allowedHeaders[http.CanonicalHeaderKey(h)] {
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+			}
+
+			w.Header().Set("Access-Control-Allow-Methods", methods)
+			if len(requested) > 0 {
+				w.Header().Set("Access-Control-Allow-Headers", strings.Join(requested, ", "))
+			}
+			if opts.MaxAge > 0 {
+				w.Header().Set("Access-Control-Max-Age", maxAge)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
+
+// matches exact origins, * and wildcard subdomains. https://*.example.com matches https://api.example.com
+// but not https://example.com or http://api.example.com. * matches nothing when credentials are allowed
+func originAllowed(allowed []string, origin string, credentials bool) bool {
+	origin = strings.ToLower(origin)
+
+	for _, pattern := range allowed {
+		pattern = strings.ToLower(pattern)
+
+		if pattern == "*" {
+			if !credentials {
+				return true
+			}
+			continue
+		}
+		if pattern == origin {
+			return true
+		}
+
+		prefix, suffix, found := strings.Cut(pattern, "*")
+		if !found {
+			continue
+		}
+
+		if len(origin) > len(prefix)+len(suffix) && strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) {
+			// the wildcard covers subdomain labels only, never a scheme, port or path
+			sub := origin[len(prefix) : len(origin)-len(suffix)]
+			if !strings.ContainsAny(sub, "/:") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func splitHeaderList(value string) []string {
+	var out []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
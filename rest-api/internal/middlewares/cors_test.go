@@ -0,0 +1,165 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestOriginAllowed(t *testing.T) {
+	tests := []struct {
+		name        string
+		allowed     []string
+		origin      string
+		credentials bool
+		want        bool
+	}{
+		{"exact", []string{"https://app.example.com"}, "https://app.example.com", false, true},
+		{"exact ignores case", []string{"https://App.example.com"}, "https://app.EXAMPLE.com", false, true},
+		{"other origin", []string{"https://app.example.com"}, "https://evil.com", false, false},
+		{"other scheme", []string{"https://app.example.com"}, "http://app.example.com", false, false},
+		{"nothing configured", nil, "https://app.example.com", false, false},
+
+		{"subdomain", []string{"https://*.example.com"}, "https://api.example.com", false, true},
+		{"nested subdomain", []string{"https://*.example.com"}, "https://a.b.example.com", false, true},
+		{"bare domain isn't a subdomain", []string{"https://*.example.com"}, "https://example.com", false, false},
+		{"empty label", []string{"https://*.example.com"}, "https://.example.com", false, false},
+		{"wildcard keeps the scheme", []string{"https://*.example.com"}, "http://api.example.com", false, false},
+		{"lookalike domain", []string{"https://*.example.com"}, "https://api.evilexample.com", false, false},
+		{"domain as a prefix", []string{"https://*.example.com"}, "https://api.example.com.evil.com", false, false},
+		{"wildcard doesn't cover a port", []string{"https://*.example.com"}, "https://evil.com:443.example.com", false, false},
+		{"wildcard doesn't cover a path", []string{"https://*.example.com"}, "https://evil.com/.example.com", false, false},
+		{"port after the wildcard", []string{"http://*.localhost:3000"}, "http://app.localhost:3000", false, true},
+		{"wildcard with credentials", []string{"https://*.example.com"}, "https://api.example.com", true, true},
+
+		{"star", []string{"*"}, "https://anything.test", false, true},
+		// every site could make logged in requests otherwise
+		{"star with credentials", []string{"*"}, "https://anything.test", true, false},
+		{"star with credentials falls through to the list", []string{"*", "https://app.example.com"}, "https://app.example.com", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := originAllowed(tt.allowed, tt.origin, tt.credentials); got != tt.want {
+				t.Fatalf("originAllowed(%v, %q, %v) = %v, want %v", tt.allowed, tt.origin, tt.credentials, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCORS(t *testing.T) {
+	opts := CORSOptions{
+		AllowedOrigins: []string{"https://*.example.com"},
+		AllowedMethods: []string{"GET", "POST"},
+		AllowedHeaders: []string{"Authorization", "Content-Type"},
+		ExposedHeaders: []string{"ETag"},
+		MaxAge:         10 * time.Minute,
+	}
+
+	tests := []struct {
+		name           string
+		opts           CORSOptions
+		method         string
+		origin         string
+		requestMethod  string
+		requestHeaders string
+		// expected Access-Control-Allow-Origin, empty when the browser should block it
+		allowOrigin  string
+		allowMethods string
+		credentials  string
+		nextRan      bool
+	}{
+		{
+			name:   "no origin",
+			opts:   opts,
+			method: http.MethodGet, nextRan: true,
+		},
+		{
+			name:   "allowed origin",
+			opts:   opts,
+			method: http.MethodGet, origin: "https://app.example.com",
+			allowOrigin: "https://app.example.com", nextRan: true,
+		},
+		{
+			// the request still goes through, only the browser hides the response
+			name:   "refused origin",
+			opts:   opts,
+			method: http.MethodGet, origin: "https://evil.com",
+			nextRan: true,
+		},
+		{
+			name:   "preflight",
+			opts:   opts,
+			method: http.MethodOptions, origin: "https://app.example.com", requestMethod: "POST", requestHeaders: "content-type, authorization",
+			allowOrigin: "https://app.example.com", allowMethods: "GET, POST",
+		},
+		{
+			name:   "preflight for a method that isn't allowed",
+			opts:   opts,
+			method: http.MethodOptions, origin: "https://app.example.com", requestMethod: "DELETE",
+			allowOrigin: "https://app.example.com",
+		},
+		{
+			name:   "preflight for a header that isn't allowed",
+			opts:   opts,
+			method: http.MethodOptions, origin: "https://app.example.com", requestMethod: "POST", requestHeaders: "X-Secret",
+			allowOrigin: "https://app.example.com",
+		},
+		{
+			name:   "preflight from a refused origin",
+			opts:   opts,
+			method: http.MethodOptions, origin: "https://evil.com", requestMethod: "POST",
+		},
+		{
+			name:   "star",
+			opts:   CORSOptions{AllowedOrigins: []string{"*"}},
+			method: http.MethodGet, origin: "https://anything.test",
+			allowOrigin: "*", nextRan: true,
+		},
+		{
+			name:   "credentials echo the origin",
+			opts:   CORSOptions{AllowedOrigins: []string{"https://app.example.com"}, AllowCredentials: true},
+			method: http.MethodGet, origin: "https://app.example.com",
+			allowOrigin: "https://app.example.com", credentials: "true", nextRan: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ran := false
+			handler := CORS(tt.opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ran = true
+			}))
+
+			req := httptest.NewRequest(tt.method, "/blogs", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.requestMethod != "" {
+				req.Header.Set("Access-Control-Request-Method", tt.requestMethod)
+			}
+			if tt.requestHeaders != "" {
+				req.Header.Set("Access-Control-Request-Headers", tt.requestHeaders)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.allowOrigin {
+				t.Fatalf("Access-Control-Allow-Origin = %q, want %q", got, tt.allowOrigin)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Methods"); got != tt.allowMethods {
+				t.Fatalf("Access-Control-Allow-Methods = %q, want %q", got, tt.allowMethods)
+			}
+			if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != tt.credentials {
+				t.Fatalf("Access-Control-Allow-Credentials = %q, want %q", got, tt.credentials)
+			}
+			if ran != tt.nextRan {
+				t.Fatalf("next ran = %v, want %v", ran, tt.nextRan)
+			}
+			if rec.Header().Values("Vary")[0] != "Origin" {
+				t.Fatalf("Vary = %v, want Origin", rec.Header().Values("Vary"))
+			}
+		})
+	}
+}
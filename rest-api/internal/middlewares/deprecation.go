@@ -0,0 +1,37 @@
+package middlewares
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/exzacter/gorestapi/internal/utils"
+)
+
+// Deprecation describes an endpoint that is on its way out
+type Deprecation struct {
+	// when the endpoint was deprecated
+	Since time.Time
+	// when it stops working, requests after this get 410 Gone
+	Sunset time.Time
+	// prefix of the replacement, e.g. /v1, added in front of the request path for the Link header
+	Successor string
+}
+
+// Deprecated adds Deprecation (RFC 9745), Sunset (RFC 8594) and a successor Link header to every response
+func Deprecated(d Deprecation) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Deprecation", fmt.Sprintf("@%d", d.Since.Unix()))
+			w.Header().Set("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+			w.Header().Set("Link", fmt.Sprintf(`<%s%s>; rel="successor-version"`, d.Successor, r.URL.Path))
+
+			if time.Now().After(d.Sunset) {
+				utils.RespondWithError(w, http.StatusGone, fmt.Sprintf("This endpoint has been removed, use %s%s", d.Successor, r.URL.Path))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,284 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/exzacter/gorestapi/internal/auth"
+	"github.com/exzacter/gorestapi/internal/dbconfig"
+	"github.com/exzacter/gorestapi/internal/utils"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// how long a response is replayed for
+	idempotencyTTL = 24 * time.Hour
+	// held while the first request with a key runs. longer than any handler should take, short enough
+	// that a crashed instance doesn't block the key for long
+	idempotencyLockTTL = 30 * time.Second
+	// how long a duplicate waits for the first request before giving up with a 409
+	idempotencyWait      = 10 * time.Second
+	idempotencyPoll      = 50 * time.Millisecond
+	maxIdempotencyKeyLen = 255
+)
+
+// only deletes the lock if it is still ours, it may have expired and been taken by another request
+var unlockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// what is kept in redis for a key
+type storedResponse struct {
+	Fingerprint string      `json:"fingerprint"`
+	Status      int         `json:"status"`
+	Header      http.Header `json:"header"`
+	Body        []byte      `json:"body"`
+}
+
+// where responses and locks are kept, redisIdempotencyStore in the api
+type idempotencyStore interface {
+	// the stored response for a key, nil if there isn't one yet
+	Load(ctx context.Context, key string) ([]byte, error)
+	Save(ctx context.Context, key string, raw []byte, ttl time.Duration) error
+	Lock(ctx context.Context, key, token string, ttl time.Duration) (bool, error)
+	Unlock(ctx context.Context, key, token string) error
+}
+
+type redisIdempotencyStore struct{}
+
+func (redisIdempotencyStore) Load(ctx context.Context, key string) ([]byte, error) {
+	raw, err := dbconfig.RedisClient.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	return raw, err
+}
+
+func (redisIdempotencyStore) Save(ctx context.Context, key string, raw []byte, ttl time.Duration) error {
+	return dbconfig.RedisClient.Set(ctx, key, raw, ttl).Err()
+}
+
+func (redisIdempotencyStore) Lock(ctx context.Context, key, token string, ttl time.Duration) (bool, error) {
+	return dbconfig.RedisClient.SetNX(ctx, key, token, ttl).Result()
+}
+
+func (redisIdempotencyStore) Unlock(ctx context.Context, key, token string) error {
+	return unlockScript.Run(ctx, dbconfig.RedisClient, []string{key}, token).Err()
+}
+
+// Idempotent honours an Idempotency-Key header on POST routes so a client can safely retry. the first
+// response for a key is stored for 24 hours and replayed for repeats with an Idempotent-Replayed header.
+// the same key with a different body gets a 422, and a repeat that arrives while the first is still
+// running waits for it. keys are per user when it runs inside AuthMiddle. anonymous requests, such as
+// register, share one namespace, so their keys are scoped to the route and body as well and a key only
+// ever replays the request it was sent with. 5xx responses aren't stored, the retry runs the handler again
+func Idempotent(next http.Handler) http.Handler {
+	return idempotent(redisIdempotencyStore{}, next)
+}
+
+func idempotent(responses idempotencyStore, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" || r.Method != http.MethodPost {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLen {
+			utils.RespondWithError(w, http.StatusBadRequest, fmt.Sprintf("Idempotency-Key can be at most %d characters", maxIdempotencyKeyLen))
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		sum := sha256.Sum256([]byte(r.Method + " " + r.URL.Path + "\n" + string(body)))
+		fingerprint := hex.EncodeToString(sum[:])
+
+		// the key is hashed so clients can't pick what ends up in the redis key name
+		var responseKey string
+		if principal, ok := r.Context().Value(PrincipalKey).(*auth.Principal); ok {
+			keyHash := sha256.Sum256([]byte(key))
+			responseKey = fmt.Sprintf("idempotency:%d:%s", principal.UserID, hex.EncodeToString(keyHash[:]))
+		} else {
+			// two clients that happen to pick the same key get separate entries unless they sent the same request
+			keyHash := sha256.Sum256([]byte(key + "\n" + fingerprint))
+			responseKey = "idempotency:anonymous:" + hex.EncodeToString(keyHash[:])
+		}
+		lockKey := responseKey + ":lock"
+
+		ctx := r.Context()
+		deadline := time.Now().Add(idempotencyWait)
+		for {
+			stored, err := loadResponse(ctx, responses, responseKey)
+			if err != nil {
+				utils.RespondWithError(w, http.StatusInternalServerError, "Internal error")
+				return
+			}
+			if stored != nil {
+				replay(w, stored, fingerprint)
+				return
+			}
+
+			token, err := lockToken()
+			if err != nil {
+				utils.RespondWithError(w, http.StatusInternalServerError, "Internal error")
+				return
+			}
+
+			locked, err := responses.Lock(ctx, lockKey, token, idempotencyLockTTL)
+			if err != nil {
+				utils.RespondWithError(w, http.StatusInternalServerError, "Internal error")
+				return
+			}
+			if locked {
+				defer responses.Unlock(context.WithoutCancel(ctx), lockKey, token)
+				break
+			}
+
+			// another request with the key is running, wait for its response
+			if time.Now().After(deadline) {
+				utils.RespondWithError(w, http.StatusConflict, "A request with this Idempotency-Key is still being processed")
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(idempotencyPoll):
+			}
+		}
+
+		rec := &recordingWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		if rec.status >= 500 {
+			return
+		}
+
+		raw, err := json.Marshal(storedResponse{
+			Fingerprint: fingerprint,
+			Status:      rec.status,
+			Header:      uncompressedHeader(rec.Header()),
+			Body:        rec.body.Bytes(),
+		})
+		if err == nil {
+			err = responses.Save(context.WithoutCancel(ctx), responseKey, raw, idempotencyTTL)
+		}
+		if err != nil {
+			// the request itself succeeded, a retry will just run it again
+			log.Printf("Failed to store idempotent response: %v", err)
+		}
+	})
+}
+
+// the stored response for a key, nil if there isn't one yet
+func loadResponse(ctx context.Context, responses idempotencyStore, key string) (*storedResponse, error) {
+	raw, err := responses.Load(ctx, key)
+	if raw == nil || err != nil {
+		return nil, err
+	}
+
+	var stored storedResponse
+	if err := json.Unmarshal(raw, &stored); err != nil {
+		return nil, err
+	}
+
+	return &stored, nil
+}
+
+func replay(w http.ResponseWriter, stored *storedResponse, fingerprint string) {
+	if stored.Fingerprint != fingerprint {
+		utils.RespondWithError(w, http.StatusUnprocessableEntity, "Idempotency-Key was already used for a different request")
+		return
+	}
+
+	for name, values := range stored.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(stored.Status)
+	w.Write(stored.Body)
+}
+
+// the recorded body is what the handler wrote, before Compress encoded it, but the headers are Compress's
+// by then. stored as they were for the uncompressed body, a replay goes through Compress again and gets
+// encoded for whatever the retry accepts
+func uncompressedHeader(header http.Header) http.Header {
+	clone := header.Clone()
+	if clone.Get("Content-Encoding") != "" {
+		clone.Del("Content-Encoding")
+		clone.Del("Content-Length")
+	}
+
+	var vary []string
+	for _, value := range clone.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" && !strings.EqualFold(name, "Accept-Encoding") {
+				vary = append(vary, name)
+			}
+		}
+	}
+	clone.Del("Vary")
+	if len(vary) > 0 {
+		clone.Set("Vary", strings.Join(vary, ", "))
+	}
+
+	if etag := clone.Get("ETag"); etag != "" {
+		clone.Set("ETag", stripEncodingSuffix(etag))
+	}
+
+	return clone
+}
+
+func lockToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// passes the response through while keeping a copy to store
+type recordingWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (w *recordingWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
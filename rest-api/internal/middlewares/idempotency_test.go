@@ -0,0 +1,250 @@
+package middlewares
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/exzacter/gorestapi/internal/auth"
+)
+
+// keeps responses and locks in memory, without their ttls
+type fakeIdempotencyStore struct {
+	mu        sync.Mutex
+	responses map[string][]byte
+	locks     map[string]string
+}
+
+func newFakeIdempotencyStore() *fakeIdempotencyStore {
+	return &fakeIdempotencyStore{responses: map[string][]byte{}, locks: map[string]string{}}
+}
+
+func (f *fakeIdempotencyStore) Load(ctx context.Context, key string) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.responses[key], nil
+}
+
+func (f *fakeIdempotencyStore) Save(ctx context.Context, key string, raw []byte, ttl time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.responses[key] = raw
+	return nil
+}
+
+func (f *fakeIdempotencyStore) Lock(ctx context.Context, key, token string, ttl time.Duration) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, held := f.locks[key]; held {
+		return false, nil
+	}
+	f.locks[key] = token
+	return true, nil
+}
+
+func (f *fakeIdempotencyStore) Unlock(ctx context.Context, key, token string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.locks[key] == token {
+		delete(f.locks, key)
+	}
+	return nil
+}
+
+// answers with the status it is given and counts how often it ran
+type countingHandler struct {
+	calls  atomic.Int32
+	status int
+}
+
+func (h *countingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n := h.calls.Add(1)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(h.status)
+	fmt.Fprintf(w, `{"call":%d}`, n)
+}
+
+func idempotentRequest(path, key, body string, userID int64) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	if key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
+	if userID != 0 {
+		req = req.WithContext(context.WithValue(req.Context(), PrincipalKey, &auth.Principal{UserID: userID}))
+	}
+	return req
+}
+
+func TestIdempotent(t *testing.T) {
+	type request struct {
+		path, key, body string
+		userID          int64
+	}
+	tests := []struct {
+		name     string
+		status   int
+		requests []request
+		// expected status of every response, and how many ran the handler
+		want  []int
+		calls int32
+	}{
+		{
+			name:     "repeat is replayed",
+			status:   http.StatusCreated,
+			requests: []request{{"/blogs", "k1", `{"a":1}`, 1}, {"/blogs", "k1", `{"a":1}`, 1}},
+			want:     []int{http.StatusCreated, http.StatusCreated},
+			calls:    1,
+		},
+		{
+			name:     "different body is refused",
+			status:   http.StatusCreated,
+			requests: []request{{"/blogs", "k1", `{"a":1}`, 1}, {"/blogs", "k1", `{"a":2}`, 1}},
+			want:     []int{http.StatusCreated, http.StatusUnprocessableEntity},
+			calls:    1,
+		},
+		{
+			name:     "different path is refused",
+			status:   http.StatusCreated,
+			requests: []request{{"/blogs", "k1", `{}`, 1}, {"/webhooks", "k1", `{}`, 1}},
+			want:     []int{http.StatusCreated, http.StatusUnprocessableEntity},
+			calls:    1,
+		},
+		{
+			name:     "keys are per user",
+			status:   http.StatusCreated,
+			requests: []request{{"/blogs", "k1", `{}`, 1}, {"/blogs", "k1", `{}`, 2}},
+			want:     []int{http.StatusCreated, http.StatusCreated},
+			calls:    2,
+		},
+		{
+			name:     "anonymous repeat is replayed",
+			status:   http.StatusCreated,
+			requests: []request{{"/register", "k1", `{"u":"a"}`, 0}, {"/register", "k1", `{"u":"a"}`, 0}},
+			want:     []int{http.StatusCreated, http.StatusCreated},
+			calls:    1,
+		},
+		{
+			// two clients picking the same key mustn't see each other's response or a 422
+			name:     "anonymous key with another body runs again",
+			status:   http.StatusCreated,
+			requests: []request{{"/register", "k1", `{"u":"a"}`, 0}, {"/register", "k1", `{"u":"b"}`, 0}},
+			want:     []int{http.StatusCreated, http.StatusCreated},
+			calls:    2,
+		},
+		{
+			name:     "without a key nothing is stored",
+			status:   http.StatusCreated,
+			requests: []request{{"/blogs", "", `{}`, 1}, {"/blogs", "", `{}`, 1}},
+			want:     []int{http.StatusCreated, http.StatusCreated},
+			calls:    2,
+		},
+		{
+			name:     "server errors aren't stored",
+			status:   http.StatusInternalServerError,
+			requests: []request{{"/blogs", "k1", `{}`, 1}, {"/blogs", "k1", `{}`, 1}},
+			want:     []int{http.StatusInternalServerError, http.StatusInternalServerError},
+			calls:    2,
+		},
+		{
+			name:     "key too long",
+			status:   http.StatusCreated,
+			requests: []request{{"/blogs", strings.Repeat("k", maxIdempotencyKeyLen+1), `{}`, 1}},
+			want:     []int{http.StatusBadRequest},
+			calls:    0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &countingHandler{status: tt.status}
+			handler := idempotent(newFakeIdempotencyStore(), next)
+
+			var first string
+			for i, req := range tt.requests {
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, idempotentRequest(req.path, req.key, req.body, req.userID))
+
+				if rec.Code != tt.want[i] {
+					t.Fatalf("request %d got %d, want %d", i, rec.Code, tt.want[i])
+				}
+				if i == 0 {
+					first = rec.Body.String()
+				} else if rec.Header().Get("Idempotent-Replayed") == "true" && rec.Body.String() != first {
+					t.Fatalf("replayed %q, want the first response %q", rec.Body.String(), first)
+				}
+			}
+
+			if calls := next.calls.Load(); calls != tt.calls {
+				t.Fatalf("handler ran %d times, want %d", calls, tt.calls)
+			}
+		})
+	}
+}
+
+func TestIdempotentReplayHeader(t *testing.T) {
+	handler := idempotent(newFakeIdempotencyStore(), &countingHandler{status: http.StatusCreated})
+
+	for i, want := range []string{"", "true"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, idempotentRequest("/blogs", "k1", `{}`, 1))
+		if got := rec.Header().Get("Idempotent-Replayed"); got != want {
+			t.Fatalf("request %d Idempotent-Replayed = %q, want %q", i, got, want)
+		}
+		if got := rec.Header().Get("Content-Type"); got != "application/json" {
+			t.Fatalf("request %d Content-Type = %q", i, got)
+		}
+	}
+}
+
+func TestIdempotentWaitsForTheFirstRequest(t *testing.T) {
+	responses := newFakeIdempotencyStore()
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	var calls atomic.Int32
+	handler := idempotent(responses, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			close(started)
+			<-release
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":1}`))
+	}))
+
+	first := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		handler.ServeHTTP(first, idempotentRequest("/blogs", "k1", `{}`, 1))
+		close(done)
+	}()
+	<-started
+
+	// the repeat polls until the first request stores its response
+	go func() {
+		time.Sleep(3 * idempotencyPoll)
+		close(release)
+	}()
+	repeat := httptest.NewRecorder()
+	handler.ServeHTTP(repeat, idempotentRequest("/blogs", "k1", `{}`, 1))
+	<-done
+
+	if calls.Load() != 1 {
+		t.Fatalf("handler ran %d times, want once", calls.Load())
+	}
+	if repeat.Code != http.StatusCreated || repeat.Header().Get("Idempotent-Replayed") != "true" || repeat.Body.String() != first.Body.String() {
+		t.Fatalf("repeat got %d %q, want the first response replayed", repeat.Code, repeat.Body.String())
+	}
+	if len(responses.locks) != 0 {
+		t.Fatalf("locks left behind: %v", responses.locks)
+	}
+}
@@ -0,0 +1,91 @@
+package middlewares
+
+import (
+	"sync"
+	"time"
+
+	"github.com/exzacter/gorestapi/internal/auth"
+)
+
+// how many revocations each instance remembers, past this the ones expiring soonest are dropped first
+const maxLocalRevocations = 10000
+
+// revocations this instance has made or seen in redis, kept in memory so revoked tokens stay revoked
+// while redis is unreachable. another instance only knows of a revocation once it has read it from redis
+type revocations struct {
+	mu sync.Mutex
+	// token -> when it expires anyway
+	tokens map[string]time.Time
+	// user id -> every token issued at or before this is revoked
+	users map[int64]time.Time
+}
+
+var localRevocations = &revocations{
+	tokens: map[string]time.Time{},
+	users:  map[int64]time.Time{},
+}
+
+// RememberRevokedToken keeps a logged out token revoked on this instance until it expires
+func RememberRevokedToken(token string, expires time.Time) {
+	localRevocations.mu.Lock()
+	defer localRevocations.mu.Unlock()
+
+	localRevocations.tokens[token] = expires
+	localRevocations.prune()
+}
+
+// RememberRevokedUser keeps every token of the user issued at or before revokedAt revoked on this instance
+func RememberRevokedUser(userID int64, revokedAt time.Time) {
+	localRevocations.mu.Lock()
+	defer localRevocations.mu.Unlock()
+
+	if revokedAt.After(localRevocations.users[userID]) {
+		localRevocations.users[userID] = revokedAt
+	}
+	localRevocations.prune()
+}
+
+func (l *revocations) revoked(token string, userID int64, issuedBefore func(time.Time) bool) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if expires, ok := l.tokens[token]; ok && time.Now().Before(expires) {
+		return true
+	}
+	if revokedAt, ok := l.users[userID]; ok && issuedBefore(revokedAt) {
+		return true
+	}
+
+	return false
+}
+
+// drops what no longer matters, and the soonest to expire when there is still too much. the caller
+// holds the lock
+func (l *revocations) prune() {
+	if len(l.tokens)+len(l.users) <= maxLocalRevocations {
+		return
+	}
+
+	now := time.Now()
+	for token, expires := range l.tokens {
+		if !now.Before(expires) {
+			delete(l.tokens, token)
+		}
+	}
+	// every token issued before a revocation has expired once TokenLifetime has passed
+	for userID, revokedAt := range l.users {
+		if now.Sub(revokedAt) > auth.TokenLifetime {
+			delete(l.users, userID)
+		}
+	}
+
+	for len(l.tokens) > 0 && len(l.tokens)+len(l.users) > maxLocalRevocations {
+		var soonest string
+		for token, expires := range l.tokens {
+			if soonest == "" || expires.Before(l.tokens[soonest]) {
+				soonest = token
+			}
+		}
+		delete(l.tokens, soonest)
+	}
+}
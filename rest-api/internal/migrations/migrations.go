@@ -0,0 +1,30 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	_ "embed"
+)
+
+// Schema creates every table and index and adds columns newer than their table with ALTER TABLE. tables,
+// indexes and columns are only created if they don't exist, and the one column type change only runs
+// while the column has the old type, so it runs against a database at any earlier version and against an
+// up to date one
+//
+//go:embed schema.sql
+var Schema string
+
+// Migrate brings the database up to Schema in one transaction, a failed statement leaves it as it was
+func Migrate(ctx context.Context, db *sql.DB) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, Schema); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
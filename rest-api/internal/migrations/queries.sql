@@ -1,10 +1,12 @@
 -- name: CreateUser :one
+-- no row when the email is already taken, ignoring case
 INSERT INTO users(username, email, password, created, updated)
 VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT DO NOTHING
 	RETURNING id, username, email, created, updated;
 
 -- name: GetUser :one
-SELECT id, username, email, password, created, updated
+SELECT id, username, email, password, created, updated, role, avatar_key, disabled_at
 FROM users
 WHERE id = $1;
 
@@ -14,6 +16,594 @@ FROM users
 ORDER BY id;
 
 -- name: GetUserByUsernameOrEmail :one
-SELECT id, username, email, created, updated, password
+SELECT id, username, email, created, updated, password, disabled_at
 FROM users
 WHERE username = $1 OR email = $1;
+
+-- name: ListUsersByEmail :many
+-- emails are matched ignoring case, disabled accounts are left out. a database from before the unique
+-- index can have more than one account for an email, two rows are enough to tell
+SELECT id, username, email
+FROM users
+WHERE lower(email) = lower(@email) AND disabled_at IS NULL
+ORDER BY id
+LIMIT 2;
+
+-- name: CreateBlog :one
+INSERT INTO blogs(title, content, summary, user_id, status, publish_at, organization_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+	RETURNING id, title, content, summary, user_id, status, publish_at, created, updated, organization_id;
+
+-- name: GetBlog :one
+SELECT b.id, b.title, b.content, b.summary, b.user_id, b.status, b.publish_at, b.created, b.updated, b.organization_id,
+	(SELECT COUNT(*) FROM blog_comments c WHERE c.blog_id = b.id)::bigint AS comment_count,
+	(SELECT COALESCE(jsonb_object_agg(r.kind, r.total), '{}')
+		FROM (SELECT kind, COUNT(*) AS total FROM blog_reactions WHERE blog_id = b.id GROUP BY kind) r
+	)::jsonb AS reaction_counts,
+	(SELECT COALESCE(array_agg(t.slug ORDER BY t.slug), '{}')
+		FROM blog_tags bt JOIN tags t ON t.id = bt.tag_id WHERE bt.blog_id = b.id
+	)::text[] AS tags
+FROM blogs b
+WHERE b.id = $1;
+
+-- name: ListBlogs :many
+-- with match_all a blog needs every requested tag, otherwise any one of them is enough
+SELECT b.id, b.title, b.content, b.summary, b.user_id, b.status, b.publish_at, b.created, b.updated, b.organization_id,
+	(SELECT COUNT(*) FROM blog_comments c WHERE c.blog_id = b.id)::bigint AS comment_count,
+	(SELECT COALESCE(jsonb_object_agg(r.kind, r.total), '{}')
+		FROM (SELECT kind, COUNT(*) AS total FROM blog_reactions WHERE blog_id = b.id GROUP BY kind) r
+	)::jsonb AS reaction_counts,
+	(SELECT COALESCE(array_agg(t.slug ORDER BY t.slug), '{}')
+		FROM blog_tags bt JOIN tags t ON t.id = bt.tag_id WHERE bt.blog_id = b.id
+	)::text[] AS tags
+FROM blogs b
+WHERE b.status = 'published'
+	AND (cardinality(@tags::text[]) = 0
+		OR (
+			SELECT COUNT(*) FROM blog_tags bt JOIN tags t ON t.id = bt.tag_id
+			WHERE bt.blog_id = b.id AND t.slug = ANY(@tags::text[])
+		) >= CASE WHEN @match_all::boolean THEN cardinality(@tags::text[]) ELSE 1 END)
+ORDER BY COALESCE(b.publish_at, b.created) DESC, b.id DESC
+LIMIT @row_limit OFFSET @row_offset;
+
+-- name: SearchBlogs :many
+SELECT id, title, user_id, created, updated,
+	ts_rank(search_vector, websearch_to_tsquery('english', @query))::real AS rank,
+	ts_headline('english', content, websearch_to_tsquery('english', @query),
+		'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=30, MinWords=10')::text AS snippet,
+	COUNT(*) OVER() AS total
+FROM blogs
+WHERE status = 'published'
+	AND search_vector @@ websearch_to_tsquery('english', @query)
+	AND (sqlc.narg('author_id')::int IS NULL OR user_id = sqlc.narg('author_id'))
+ORDER BY rank DESC, id DESC
+LIMIT @row_limit OFFSET @row_offset;
+
+-- name: GetUserRole :one
+SELECT role
+FROM users
+WHERE id = $1;
+
+-- name: CreateBlogComment :one
+INSERT INTO blog_comments(blog_id, user_id, parent_id, content)
+VALUES ($1, $2, $3, $4)
+	RETURNING id, blog_id, user_id, parent_id, content, created, updated;
+
+-- name: GetBlogComment :one
+SELECT id, blog_id, user_id, parent_id, content, created, updated
+FROM blog_comments
+WHERE id = $1;
+
+-- name: ListBlogComments :many
+SELECT id, blog_id, user_id, parent_id, content, created, updated
+FROM blog_comments
+WHERE blog_id = $1
+ORDER BY created, id;
+
+-- name: UpdateBlogComment :one
+UPDATE blog_comments
+SET content = $2, updated = CURRENT_TIMESTAMP
+WHERE id = $1 AND user_id = $3
+	RETURNING id, blog_id, user_id, parent_id, content, created, updated;
+
+-- name: DeleteBlogComment :execrows
+DELETE FROM blog_comments
+WHERE id = $1;
+
+-- name: AddBlogReaction :exec
+INSERT INTO blog_reactions(user_id, blog_id, kind)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, blog_id, kind) DO NOTHING;
+
+-- name: RemoveBlogReaction :execrows
+DELETE FROM blog_reactions
+WHERE user_id = $1 AND blog_id = $2 AND kind = $3;
+
+-- name: UpdateBlog :one
+-- only updates the version the editor last saw, no row means someone else saved first
+UPDATE blogs
+SET title = $2, content = $3, summary = $4, status = $5, publish_at = $6, updated = CURRENT_TIMESTAMP
+WHERE id = $1 AND updated = $7
+	RETURNING id, title, content, summary, user_id, status, publish_at, created, updated, organization_id;
+
+-- name: UpsertTag :one
+INSERT INTO tags(name, slug)
+VALUES ($1, $2)
+ON CONFLICT (slug) DO UPDATE SET slug = EXCLUDED.slug
+	RETURNING id, name, slug, created;
+
+-- name: AddBlogTag :exec
+INSERT INTO blog_tags(blog_id, tag_id)
+VALUES ($1, $2)
+ON CONFLICT (blog_id, tag_id) DO NOTHING;
+
+-- name: DeleteBlogTags :exec
+DELETE FROM blog_tags
+WHERE blog_id = $1;
+
+-- name: ListTags :many
+SELECT t.id, t.name, t.slug, COUNT(b.id) FILTER (WHERE b.status = 'published') AS usage_count
+FROM tags t
+LEFT JOIN blog_tags bt ON bt.tag_id = t.id
+LEFT JOIN blogs b ON b.id = bt.blog_id
+GROUP BY t.id
+ORDER BY usage_count DESC, t.slug;
+
+-- name: ListBlogsByAuthor :many
+SELECT id, title, content, summary, user_id, status, publish_at, created, updated
+FROM blogs
+WHERE user_id = $1
+ORDER BY created DESC, id DESC
+LIMIT $2 OFFSET $3;
+
+-- name: PublishDueBlogs :many
+-- SKIP LOCKED lets several instances run this at once, each row is claimed by exactly one of them
+UPDATE blogs
+SET status = 'published', updated = CURRENT_TIMESTAMP
+WHERE id IN (
+	SELECT id FROM blogs
+	WHERE status = 'scheduled' AND publish_at <= CURRENT_TIMESTAMP
+	ORDER BY publish_at
+	LIMIT $1
+	FOR UPDATE SKIP LOCKED
+)
+	RETURNING id, title, user_id, publish_at;
+
+-- name: UpdateUserAvatar :execrows
+-- zero rows means the profile changed since the client fetched it. users registered before updated was
+-- set on create have it NULL, which has to match too
+UPDATE users
+SET avatar_key = $2, updated = CURRENT_TIMESTAMP
+WHERE id = $1 AND updated IS NOT DISTINCT FROM $3;
+
+-- name: CreateApiKey :one
+INSERT INTO api_keys (user_id, name, prefix, secret_hash, scopes, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, user_id, name, prefix, scopes, expires_at, last_used_at, revoked_at, created;
+
+-- name: GetApiKeyByPrefix :one
+SELECT k.id, k.user_id, k.secret_hash, k.scopes, k.expires_at, k.revoked_at, u.username, u.disabled_at
+FROM api_keys k
+JOIN users u ON u.id = k.user_id
+WHERE k.prefix = $1;
+
+-- name: ListApiKeys :many
+SELECT id, user_id, name, prefix, scopes, expires_at, last_used_at, revoked_at, created
+FROM api_keys
+WHERE user_id = $1
+ORDER BY created DESC, id DESC;
+
+-- name: RevokeApiKey :execrows
+UPDATE api_keys
+SET revoked_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL;
+
+-- name: TouchApiKey :exec
+-- written at most once a minute per key so busy clients don't turn every request into a write
+UPDATE api_keys
+SET last_used_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < CURRENT_TIMESTAMP - INTERVAL '1 minute');
+
+-- name: UpsertUserTotp :execrows
+-- a confirmed secret is never replaced, zero rows means 2fa is already on
+INSERT INTO user_totp (user_id, secret)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE
+SET secret = EXCLUDED.secret, created = CURRENT_TIMESTAMP
+WHERE user_totp.confirmed_at IS NULL;
+
+-- name: GetUserTotp :one
+SELECT user_id, secret, confirmed_at, created
+FROM user_totp
+WHERE user_id = $1;
+
+-- name: ConfirmUserTotp :execrows
+UPDATE user_totp
+SET confirmed_at = CURRENT_TIMESTAMP
+WHERE user_id = $1 AND confirmed_at IS NULL;
+
+-- name: DeleteRecoveryCodes :exec
+DELETE FROM user_recovery_codes
+WHERE user_id = $1;
+
+-- name: CreateRecoveryCodes :exec
+INSERT INTO user_recovery_codes (user_id, code_hash)
+SELECT @user_id::int, unnest(@code_hashes::text[]);
+
+-- name: UseRecoveryCode :execrows
+-- the used_at check makes each code single use even with concurrent logins
+UPDATE user_recovery_codes
+SET used_at = CURRENT_TIMESTAMP
+WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL;
+
+-- name: CreateWebhook :one
+INSERT INTO webhooks (user_id, url, secret, events)
+VALUES ($1, $2, $3, $4)
+RETURNING id, user_id, url, secret, events, active, created, updated;
+
+-- name: ListWebhooks :many
+SELECT id, user_id, url, events, active, created, updated
+FROM webhooks
+ORDER BY id;
+
+-- name: DeleteWebhook :execrows
+DELETE FROM webhooks
+WHERE id = $1;
+
+-- name: EnqueueWebhookDeliveries :execrows
+-- one delivery per active webhook subscribed to the event, run it in the same transaction as the change
+INSERT INTO webhook_deliveries (webhook_id, event, payload)
+SELECT id, @event::text, @payload::jsonb
+FROM webhooks
+WHERE active AND @event::text = ANY(events);
+
+-- name: ClaimDueWebhookDeliveries :many
+-- next_attempt_at is pushed a minute ahead while sending, so rows held by a crashed worker come back on their own
+UPDATE webhook_deliveries d
+SET attempts = d.attempts + 1, next_attempt_at = CURRENT_TIMESTAMP + INTERVAL '1 minute', updated = CURRENT_TIMESTAMP
+FROM webhooks w
+WHERE w.id = d.webhook_id AND d.id IN (
+	SELECT id FROM webhook_deliveries
+	WHERE status = 'pending' AND next_attempt_at <= CURRENT_TIMESTAMP
+	ORDER BY next_attempt_at
+	LIMIT $1
+	FOR UPDATE SKIP LOCKED
+)
+RETURNING d.id, d.webhook_id, d.event, d.payload, d.attempts, w.url, w.secret;
+
+-- name: MarkWebhookDeliverySucceeded :exec
+UPDATE webhook_deliveries
+SET status = 'succeeded', last_status_code = $2, last_error = NULL, delivered_at = CURRENT_TIMESTAMP, updated = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: MarkWebhookDeliveryFailed :exec
+-- status stays pending with a later next_attempt_at for a retry, or becomes dead once attempts run out
+UPDATE webhook_deliveries
+SET status = $2, last_status_code = $3, last_error = $4, next_attempt_at = $5, updated = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: ListWebhookDeliveries :many
+SELECT id, webhook_id, event, payload, status, attempts, next_attempt_at, last_status_code, last_error, delivered_at, created, updated
+FROM webhook_deliveries
+WHERE webhook_id = $1
+ORDER BY id DESC
+LIMIT $2 OFFSET $3;
+
+-- name: RedeliverWebhookDelivery :one
+-- queues a delivery again with a fresh set of attempts, whatever state it ended in
+UPDATE webhook_deliveries
+SET status = 'pending', attempts = 0, next_attempt_at = CURRENT_TIMESTAMP, updated = CURRENT_TIMESTAMP
+WHERE id = $1 AND webhook_id = $2
+RETURNING id, webhook_id, event, payload, status, attempts, next_attempt_at, last_status_code, last_error, delivered_at, created, updated;
+
+-- name: CreateOrganization :one
+-- no row when the slug is taken
+INSERT INTO organizations (name, slug)
+VALUES ($1, $2)
+ON CONFLICT (slug) DO NOTHING
+RETURNING id, name, slug, created, updated;
+
+-- name: GetOrganization :one
+SELECT id, name, slug, created, updated
+FROM organizations
+WHERE id = $1;
+
+-- name: LockOrganization :exec
+-- held while memberships change so two owners can't demote each other and leave nobody in charge
+SELECT id
+FROM organizations
+WHERE id = $1
+FOR UPDATE;
+
+-- name: ListUserOrganizations :many
+SELECT o.id, o.name, o.slug, m.role, o.created
+FROM organizations o
+JOIN memberships m ON m.organization_id = o.id
+WHERE m.user_id = $1
+ORDER BY o.name, o.id;
+
+-- name: GetMembershipRole :one
+SELECT role
+FROM memberships
+WHERE organization_id = $1 AND user_id = $2;
+
+-- name: CreateMembership :execrows
+-- zero rows when the user is already a member
+INSERT INTO memberships (organization_id, user_id, role)
+VALUES ($1, $2, $3)
+ON CONFLICT (organization_id, user_id) DO NOTHING;
+
+-- name: ListMemberships :many
+SELECT m.user_id, u.username, m.role, m.created
+FROM memberships m
+JOIN users u ON u.id = m.user_id
+WHERE m.organization_id = $1
+ORDER BY m.created, m.user_id;
+
+-- name: UpdateMembershipRole :exec
+UPDATE memberships
+SET role = $3, updated = CURRENT_TIMESTAMP
+WHERE organization_id = $1 AND user_id = $2;
+
+-- name: DeleteMembership :exec
+DELETE FROM memberships
+WHERE organization_id = $1 AND user_id = $2;
+
+-- name: CountOrganizationOwners :one
+SELECT COUNT(*)
+FROM memberships
+WHERE organization_id = $1 AND role = 'owner';
+
+-- name: CreateOrganizationInvitation :one
+INSERT INTO organization_invitations (organization_id, email, role, token_hash, invited_by, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, organization_id, email, role, invited_by, expires_at, created;
+
+-- name: ListOrganizationInvitations :many
+-- invitations that can still be accepted
+SELECT id, organization_id, email, role, invited_by, expires_at, created
+FROM organization_invitations
+WHERE organization_id = $1 AND accepted_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+ORDER BY id DESC;
+
+-- name: DeleteOrganizationInvitation :one
+DELETE FROM organization_invitations
+WHERE id = $1 AND organization_id = $2 AND accepted_at IS NULL
+RETURNING email, role;
+
+-- name: AcceptOrganizationInvitation :one
+-- an invitation works once, before it expires and only for the address it was sent to
+UPDATE organization_invitations
+SET accepted_at = CURRENT_TIMESTAMP
+WHERE token_hash = @token_hash
+	AND lower(email) = lower(@email)
+	AND accepted_at IS NULL
+	AND expires_at > CURRENT_TIMESTAMP
+RETURNING id, organization_id, email, role;
+
+-- name: CreateMembershipEvent :exec
+INSERT INTO membership_events (organization_id, actor_id, user_id, action, role, previous_role, email)
+VALUES ($1, $2, $3, $4, $5, $6, $7);
+
+-- name: ListMembershipEvents :many
+SELECT id, organization_id, actor_id, user_id, action, role, previous_role, email, created
+FROM membership_events
+WHERE organization_id = $1
+ORDER BY id DESC
+LIMIT $2 OFFSET $3;
+
+-- name: ListOrganizationBlogs :many
+-- every status, for members
+SELECT id, title, summary, user_id, status, publish_at, created, updated
+FROM blogs
+WHERE organization_id = $1
+ORDER BY created DESC, id DESC
+LIMIT $2 OFFSET $3;
+
+-- name: ExportUserBlogs :many
+SELECT id, title, content, summary, status, publish_at, organization_id, created, updated
+FROM blogs
+WHERE user_id = $1
+ORDER BY id;
+
+-- name: ExportUserComments :many
+SELECT id, blog_id, parent_id, content, created, updated
+FROM blog_comments
+WHERE user_id = $1
+ORDER BY id;
+
+-- name: ExportUserMembershipEvents :many
+-- changes the user made, and changes made to their memberships
+SELECT id, organization_id, actor_id, user_id, action, role, previous_role, email, created
+FROM membership_events
+WHERE actor_id = @user_id::int OR user_id = @user_id::int
+ORDER BY id;
+
+-- name: CountSoleOwnedOrganizations :one
+-- organisations that would be left without an owner while other people are still members
+SELECT COUNT(*)
+FROM memberships m
+WHERE m.user_id = $1
+	AND m.role = 'owner'
+	AND NOT EXISTS (
+		SELECT 1 FROM memberships o
+		WHERE o.organization_id = m.organization_id AND o.user_id <> m.user_id AND o.role = 'owner'
+	)
+	AND EXISTS (
+		SELECT 1 FROM memberships o
+		WHERE o.organization_id = m.organization_id AND o.user_id <> m.user_id
+	);
+
+-- name: DeleteSoleMemberOrganizations :exec
+DELETE FROM organizations o
+WHERE EXISTS (SELECT 1 FROM memberships m WHERE m.organization_id = o.id AND m.user_id = $1)
+	AND NOT EXISTS (SELECT 1 FROM memberships m WHERE m.organization_id = o.id AND m.user_id <> $1);
+
+-- name: DeleteUserBlogs :many
+-- organisation blogs stay with the organisation
+DELETE FROM blogs
+WHERE user_id = $1 AND organization_id IS NULL
+RETURNING id, updated;
+
+-- name: AnonymiseUserComments :exec
+-- replies hang off comments, so the comments stay with their content removed
+UPDATE blog_comments
+SET content = '[deleted]', updated = CURRENT_TIMESTAMP
+WHERE user_id = $1;
+
+-- name: DeleteUserReactions :exec
+DELETE FROM blog_reactions
+WHERE user_id = $1;
+
+-- name: DeleteUserApiKeys :exec
+DELETE FROM api_keys
+WHERE user_id = $1;
+
+-- name: DeleteUserTotp :exec
+DELETE FROM user_totp
+WHERE user_id = $1;
+
+-- name: DeleteUserMemberships :exec
+DELETE FROM memberships
+WHERE user_id = $1;
+
+-- name: DeleteInvitationsForEmail :exec
+DELETE FROM organization_invitations
+WHERE lower(email) = lower(@email);
+
+-- name: AnonymiseMembershipEvents :exec
+-- the history stays, without who it was about
+UPDATE membership_events
+SET actor_id = NULLIF(actor_id, @user_id::int),
+	user_id = NULLIF(user_id, @user_id::int),
+	email = CASE WHEN lower(email) = lower(@email) THEN NULL ELSE email END
+WHERE actor_id = @user_id::int OR user_id = @user_id::int OR lower(email) = lower(@email);
+
+-- name: EraseUser :exec
+-- the row stays so organisation blogs and comments keep an author, with nothing left that identifies anyone.
+-- '!' is not a hash of any kind, so no password ever matches it. disabled as well, so its tokens are refused
+-- even when redis can't say they were revoked
+UPDATE users
+SET username = 'deleted-' || id,
+	email = 'deleted-' || id || '@invalid',
+	password = '!',
+	role = 'user',
+	avatar_key = NULL,
+	disabled_at = COALESCE(disabled_at, CURRENT_TIMESTAMP),
+	updated = CURRENT_TIMESTAMP
+WHERE id = $1;
+
+-- name: CountAvatarUsers :one
+-- avatars are stored by content hash, so two users can share one
+SELECT COUNT(*)
+FROM users
+WHERE avatar_key = $1;
+
+-- name: UpgradePasswordHash :exec
+-- only replaces the hash it was computed from, a password change in between wins
+UPDATE users
+SET password = @new_hash
+WHERE id = @id AND password = @old_hash;
+
+-- name: AdminListUsers :many
+SELECT id, username, email, role, disabled_at, created
+FROM users
+ORDER BY id
+LIMIT $1 OFFSET $2;
+
+-- name: SetUserRole :execrows
+UPDATE users
+SET role = @role, updated = CURRENT_TIMESTAMP
+WHERE id = @id;
+
+-- name: DisableUser :execrows
+UPDATE users
+SET disabled_at = CURRENT_TIMESTAMP, updated = CURRENT_TIMESTAMP
+WHERE id = $1 AND disabled_at IS NULL;
+
+-- name: EnableUser :execrows
+UPDATE users
+SET disabled_at = NULL, updated = CURRENT_TIMESTAMP
+WHERE id = $1 AND disabled_at IS NOT NULL;
+
+-- name: RevokeUserApiKeys :execrows
+UPDATE api_keys
+SET revoked_at = CURRENT_TIMESTAMP
+WHERE user_id = $1 AND revoked_at IS NULL;
+
+-- name: FollowUser :execrows
+-- zero rows when already following
+INSERT INTO follows (follower_id, followee_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: UnfollowUser :execrows
+DELETE FROM follows
+WHERE follower_id = $1 AND followee_id = $2;
+
+-- name: GetFollowCounts :one
+SELECT
+	(SELECT COUNT(*) FROM follows WHERE followee_id = $1)::bigint AS followers,
+	(SELECT COUNT(*) FROM follows WHERE follower_id = $1)::bigint AS following;
+
+-- name: ListFollowers :many
+SELECT u.id, u.username, f.created AS followed_at
+FROM follows f
+JOIN users u ON u.id = f.follower_id
+WHERE f.followee_id = $1
+ORDER BY f.created DESC, u.id DESC
+LIMIT $2 OFFSET $3;
+
+-- name: ListFollowing :many
+SELECT u.id, u.username, f.created AS followed_at
+FROM follows f
+JOIN users u ON u.id = f.followee_id
+WHERE f.follower_id = $1
+ORDER BY f.created DESC, u.id DESC
+LIMIT $2 OFFSET $3;
+
+-- name: ListFeedEntries :many
+-- published blogs of everyone the user follows, newest first. blogs published before publish_at existed
+-- don't have one, so they go by created. keyset paginated: pass the published_at and id of the last entry
+-- seen, or NULL for the first page
+SELECT b.id, COALESCE(b.publish_at, b.created) AS published_at
+FROM blogs b
+JOIN follows f ON f.followee_id = b.user_id
+WHERE f.follower_id = @follower_id
+	AND b.status = 'published'
+	AND (sqlc.narg('before_published_at')::timestamp IS NULL
+		OR (COALESCE(b.publish_at, b.created), b.id) < (sqlc.narg('before_published_at')::timestamp, sqlc.narg('before_id')::int))
+ORDER BY COALESCE(b.publish_at, b.created) DESC, b.id DESC
+LIMIT @row_limit;
+
+-- name: ListFeedBlogs :many
+-- blogs that stopped being published since their id was read are left out
+SELECT b.id, b.title, b.summary, b.user_id, u.username, b.publish_at, b.organization_id,
+	(SELECT COUNT(*) FROM blog_comments c WHERE c.blog_id = b.id)::bigint AS comment_count,
+	(SELECT COALESCE(jsonb_object_agg(r.kind, r.total), '{}')
+		FROM (SELECT kind, COUNT(*) AS total FROM blog_reactions WHERE blog_id = b.id GROUP BY kind) r
+	)::jsonb AS reaction_counts,
+	(SELECT COALESCE(array_agg(t.slug ORDER BY t.slug), '{}')
+		FROM blog_tags bt JOIN tags t ON t.id = bt.tag_id WHERE bt.blog_id = b.id
+	)::text[] AS tags
+FROM blogs b
+JOIN users u ON u.id = b.user_id
+WHERE b.id = ANY(@ids::int[]) AND b.status = 'published'
+ORDER BY COALESCE(b.publish_at, b.created) DESC, b.id DESC;
+
+-- name: ListHeavyFollowers :many
+-- followers who follow at least min_following users, the ones whose feeds are cached
+SELECT f.follower_id
+FROM follows f
+WHERE f.followee_id = @followee_id
+	AND (SELECT COUNT(*) FROM follows mine WHERE mine.follower_id = f.follower_id) >= @min_following::bigint;
+
+-- name: ExportUserFollows :many
+SELECT follower_id, followee_id, created
+FROM follows
+WHERE follower_id = $1 OR followee_id = $1
+ORDER BY created;
+
+-- name: DeleteUserFollows :exec
+DELETE FROM follows
+WHERE follower_id = $1 OR followee_id = $1;
@@ -7,6 +7,10 @@ CREATE TABLE IF NOT EXISTS users (
 	updated TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(50) NOT NULL DEFAULT 'user';
+-- storage key of the current avatar, e.g. avatars/<sha256>.png
+ALTER TABLE users ADD COLUMN IF NOT EXISTS avatar_key VARCHAR(255);
+
 CREATE TABLE IF NOT EXISTS blogs (
 	id SERIAL PRIMARY KEY,
 	title VARCHAR(255) NOT NULL,
@@ -16,3 +20,250 @@ CREATE TABLE IF NOT EXISTS blogs (
 	updated TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
 	FOREIGN KEY (user_id) REFERENCES users(id)
 );
+
+-- blog content outgrew VARCHAR(255). the type of a column a generated column reads can't be changed, even
+-- to the type it already has, so it is only changed while it is still the old one
+DO $$
+BEGIN
+	IF (SELECT data_type FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = 'blogs' AND column_name = 'content') <> 'text' THEN
+		ALTER TABLE blogs ALTER COLUMN content TYPE TEXT;
+	END IF;
+END $$;
+
+-- weighted so title matches rank above content matches
+ALTER TABLE blogs ADD COLUMN IF NOT EXISTS search_vector TSVECTOR GENERATED ALWAYS AS (
+	setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+	setweight(to_tsvector('english', coalesce(content, '')), 'B')
+) STORED;
+
+-- blogs from before publish states were all public, so they are added as published. new blogs start as
+-- drafts. publish_at stays NULL for them, readers fall back to created
+ALTER TABLE blogs ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'published' CHECK (status IN ('draft', 'scheduled', 'published', 'archived'));
+ALTER TABLE blogs ALTER COLUMN status SET DEFAULT 'draft';
+-- when a scheduled blog goes live, and when a published blog went live
+ALTER TABLE blogs ADD COLUMN IF NOT EXISTS publish_at TIMESTAMP;
+
+-- plain text taken from the start of the markdown content
+ALTER TABLE blogs ADD COLUMN IF NOT EXISTS summary TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS blogs_search_vector_idx ON blogs USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS blogs_status_publish_at_idx ON blogs (status, publish_at);
+
+CREATE TABLE IF NOT EXISTS blog_comments (
+	id SERIAL PRIMARY KEY,
+	blog_id INT NOT NULL,
+	user_id INT NOT NULL,
+	-- NULL for top level comments, otherwise the comment being replied to
+	parent_id INT,
+	content TEXT NOT NULL,
+	created TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (blog_id) REFERENCES blogs(id) ON DELETE CASCADE,
+	FOREIGN KEY (user_id) REFERENCES users(id),
+	FOREIGN KEY (parent_id) REFERENCES blog_comments(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS blog_comments_blog_id_idx ON blog_comments (blog_id);
+
+CREATE TABLE IF NOT EXISTS blog_reactions (
+	id SERIAL PRIMARY KEY,
+	user_id INT NOT NULL,
+	blog_id INT NOT NULL,
+	kind VARCHAR(20) NOT NULL,
+	created TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (user_id) REFERENCES users(id),
+	FOREIGN KEY (blog_id) REFERENCES blogs(id) ON DELETE CASCADE,
+	UNIQUE (user_id, blog_id, kind)
+);
+
+CREATE INDEX IF NOT EXISTS blog_reactions_blog_id_idx ON blog_reactions (blog_id);
+
+CREATE TABLE IF NOT EXISTS tags (
+	id SERIAL PRIMARY KEY,
+	name VARCHAR(100) NOT NULL,
+	slug VARCHAR(100) NOT NULL UNIQUE,
+	created TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS blog_tags (
+	blog_id INT NOT NULL,
+	tag_id INT NOT NULL,
+	PRIMARY KEY (blog_id, tag_id),
+	FOREIGN KEY (blog_id) REFERENCES blogs(id) ON DELETE CASCADE,
+	FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS blog_tags_tag_id_idx ON blog_tags (tag_id);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+	id SERIAL PRIMARY KEY,
+	user_id INT NOT NULL,
+	name VARCHAR(100) NOT NULL,
+	-- public part of the key, used to find the row. the secret part is only stored as a sha256 hash
+	prefix VARCHAR(16) NOT NULL UNIQUE,
+	secret_hash VARCHAR(64) NOT NULL,
+	scopes TEXT[] NOT NULL DEFAULT '{}',
+	expires_at TIMESTAMP,
+	last_used_at TIMESTAMP,
+	revoked_at TIMESTAMP,
+	created TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS api_keys_user_id_idx ON api_keys (user_id);
+
+CREATE TABLE IF NOT EXISTS user_totp (
+	user_id INT PRIMARY KEY,
+	-- base32 TOTP secret, the same value the authenticator app holds
+	secret VARCHAR(64) NOT NULL,
+	-- NULL until the first code is confirmed, 2fa is only enforced after that
+	confirmed_at TIMESTAMP,
+	created TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS user_recovery_codes (
+	id SERIAL PRIMARY KEY,
+	user_id INT NOT NULL,
+	-- sha256 of the normalised code, the code itself is only shown once
+	code_hash VARCHAR(64) NOT NULL,
+	used_at TIMESTAMP,
+	created TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS user_recovery_codes_user_id_idx ON user_recovery_codes (user_id);
+
+CREATE TABLE IF NOT EXISTS webhooks (
+	id SERIAL PRIMARY KEY,
+	-- the admin who registered it
+	user_id INT NOT NULL,
+	url TEXT NOT NULL,
+	-- shared secret for the X-Webhook-Signature hmac
+	secret VARCHAR(64) NOT NULL,
+	-- event names such as blog.published
+	events TEXT[] NOT NULL,
+	active BOOLEAN NOT NULL DEFAULT TRUE,
+	created TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+	id SERIAL PRIMARY KEY,
+	webhook_id INT NOT NULL,
+	event VARCHAR(50) NOT NULL,
+	payload JSONB NOT NULL,
+	-- pending covers both the first attempt and retries, dead means every attempt failed
+	status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'succeeded', 'dead')),
+	attempts INT NOT NULL DEFAULT 0,
+	next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	last_status_code INT,
+	last_error TEXT,
+	delivered_at TIMESTAMP,
+	created TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (webhook_id) REFERENCES webhooks(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_status_next_attempt_at_idx ON webhook_deliveries (status, next_attempt_at);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook_id_idx ON webhook_deliveries (webhook_id);
+
+CREATE TABLE IF NOT EXISTS organizations (
+	id SERIAL PRIMARY KEY,
+	name VARCHAR(100) NOT NULL,
+	slug VARCHAR(100) NOT NULL UNIQUE,
+	created TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS memberships (
+	organization_id INT NOT NULL,
+	user_id INT NOT NULL,
+	-- owners manage members, editors write the organisation's blogs, viewers read its drafts
+	role VARCHAR(20) NOT NULL CHECK (role IN ('owner', 'editor', 'viewer')),
+	created TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (organization_id, user_id),
+	FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS memberships_user_id_idx ON memberships (user_id);
+
+CREATE TABLE IF NOT EXISTS organization_invitations (
+	id SERIAL PRIMARY KEY,
+	organization_id INT NOT NULL,
+	-- only the user with this email can accept
+	email VARCHAR(255) NOT NULL,
+	role VARCHAR(20) NOT NULL CHECK (role IN ('owner', 'editor', 'viewer')),
+	-- sha256 of the token, the token itself is only shown when the invitation is created
+	token_hash VARCHAR(64) NOT NULL UNIQUE,
+	invited_by INT,
+	expires_at TIMESTAMP NOT NULL,
+	accepted_at TIMESTAMP,
+	created TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE,
+	FOREIGN KEY (invited_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS organization_invitations_organization_id_idx ON organization_invitations (organization_id);
+
+-- every change to who is in an organisation and with which role
+CREATE TABLE IF NOT EXISTS membership_events (
+	id SERIAL PRIMARY KEY,
+	organization_id INT NOT NULL,
+	-- who made the change
+	actor_id INT,
+	-- whose membership changed, NULL for invitations nobody has accepted yet
+	user_id INT,
+	action VARCHAR(30) NOT NULL CHECK (action IN ('created', 'invited', 'invitation_revoked', 'joined', 'role_changed', 'removed', 'left')),
+	role VARCHAR(20),
+	previous_role VARCHAR(20),
+	-- the invited address for invitation events
+	email VARCHAR(255),
+	created TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE,
+	FOREIGN KEY (actor_id) REFERENCES users(id) ON DELETE SET NULL,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS membership_events_organization_id_idx ON membership_events (organization_id, id);
+
+-- set for blogs published by an organisation, user_id stays the author. if the organisation is deleted
+-- its blogs go back to their authors
+ALTER TABLE blogs ADD COLUMN IF NOT EXISTS organization_id INT REFERENCES organizations(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS blogs_organization_id_idx ON blogs (organization_id);
+
+-- set when an operator disables the account with the admin cli. it can't log in or use its api keys
+-- until it is enabled again
+ALTER TABLE users ADD COLUMN IF NOT EXISTS disabled_at TIMESTAMP;
+
+-- who follows whom, for GET /feed
+CREATE TABLE IF NOT EXISTS follows (
+	follower_id INT NOT NULL,
+	followee_id INT NOT NULL,
+	created TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (follower_id, followee_id),
+	CHECK (follower_id <> followee_id),
+	FOREIGN KEY (follower_id) REFERENCES users(id) ON DELETE CASCADE,
+	FOREIGN KEY (followee_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS follows_followee_id_idx ON follows (followee_id, created);
+
+-- the feed reads each followed author's published blogs newest first, by created for blogs from before
+-- publish_at
+CREATE INDEX IF NOT EXISTS blogs_user_id_published_idx ON blogs (user_id, COALESCE(publish_at, created) DESC, id DESC) WHERE status = 'published';
+
+-- emails are matched ignoring case, so they must be unique that way too. a database that already has emails
+-- differing only in case goes without the index until they are merged, magic links refuse those emails
+DO $$
+BEGIN
+	IF NOT EXISTS (SELECT 1 FROM users GROUP BY lower(email) HAVING COUNT(*) > 1) THEN
+		CREATE UNIQUE INDEX IF NOT EXISTS users_email_lower_idx ON users (lower(email));
+	ELSE
+		RAISE WARNING 'users_email_lower_idx not created, some emails are used by more than one account';
+	END IF;
+END $$;
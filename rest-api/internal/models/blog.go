@@ -1,10 +1,20 @@
 package models
 
 type Blog struct {
-	ID      int    `json:"id"`
-	Title   string `json:"title"`
-	Content string `json:"content"`
-	UserID  int    `json:"user_id`
-	Created string `json:"created"`
-	Updated string `json:"updated"`
+	ID        int    `json:"id"`
+	Title     string `json:"title"`
+	Content   string `json:"content"`
+	UserID    int    `json:"user_id"`
+	Status    string `json:"status"`
+	PublishAt string `json:"publish_at"`
+	Created   string `json:"created"`
+	Updated   string `json:"updated"`
 }
+
+// blog statuses, only published blogs are shown publicly
+const (
+	BlogStatusDraft     = "draft"
+	BlogStatusScheduled = "scheduled"
+	BlogStatusPublished = "published"
+	BlogStatusArchived  = "archived"
+)
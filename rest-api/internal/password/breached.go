@@ -0,0 +1,74 @@
+package password
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// BreachedSource answers with the suffixes of breached password sha1 hashes that start with a five
+// character prefix. it is the range query of the Have I Been Pwned api, so a remote source only ever
+// sees the prefix, never the password or its full hash
+type BreachedSource interface {
+	Range(ctx context.Context, prefix string) ([]string, error)
+}
+
+// Breached reports whether the password appears in the source
+func Breached(ctx context.Context, source BreachedSource, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	suffixes, err := source.Range(ctx, hash[:5])
+	if err != nil {
+		return false, err
+	}
+
+	return slices.Contains(suffixes, hash[5:]), nil
+}
+
+// LocalBreached is a breached list held in memory, grouped by prefix like the remote api
+type LocalBreached struct {
+	ranges map[string][]string
+}
+
+// LoadBreached reads a file of sha1 hashes, one per line. lines in the Have I Been Pwned download
+// format, HASH:COUNT, work as they are. blank lines and lines starting with # are skipped
+func LoadBreached(path string) (*LocalBreached, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening breached password list: %w", err)
+	}
+	defer f.Close()
+
+	list := &LocalBreached{ranges: map[string][]string{}}
+
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		hash, _, _ := strings.Cut(text, ":")
+		hash = strings.ToUpper(hash)
+		if _, err := hex.DecodeString(hash); err != nil || len(hash) != 2*sha1.Size {
+			return nil, fmt.Errorf("breached password list line %d is not a sha1 hash", line)
+		}
+
+		list.ranges[hash[:5]] = append(list.ranges[hash[:5]], hash[5:])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading breached password list: %w", err)
+	}
+
+	return list, nil
+}
+
+func (l *LocalBreached) Range(ctx context.Context, prefix string) ([]string, error) {
+	return l.ranges[strings.ToUpper(prefix)], nil
+}
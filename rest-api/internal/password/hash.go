@@ -0,0 +1,146 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// algorithms new hashes can be made with
+const (
+	Argon2id = "argon2id"
+	Bcrypt   = "bcrypt"
+)
+
+var ErrUnknownHash = errors.New("unknown password hash format")
+
+// Params decide how new hashes are made. a stored hash made with anything else still verifies, and
+// Verify reports that it should be replaced
+type Params struct {
+	// Argon2id or Bcrypt
+	Algorithm string
+	// argon2id memory in KiB, passes over it and lanes
+	Memory  uint32
+	Time    uint32
+	Threads uint8
+	// bcrypt work factor
+	BcryptCost int
+}
+
+// DefaultParams are the OWASP argon2id recommendation with some headroom
+var DefaultParams = Params{
+	Algorithm:  Argon2id,
+	Memory:     64 * 1024,
+	Time:       3,
+	Threads:    2,
+	BcryptCost: 12,
+}
+
+const (
+	argon2SaltLen = 16
+	argon2KeyLen  = 32
+)
+
+// Hash returns a self describing hash: $argon2id$v=19$m=65536,t=3,p=2$<salt>$<key> in the PHC string
+// format, or a $2a$ bcrypt hash. both carry their parameters, so they can be verified after the params
+// change
+func (p Params) Hash(password string) (string, error) {
+	switch p.Algorithm {
+	case Argon2id:
+		salt := make([]byte, argon2SaltLen)
+		if _, err := rand.Read(salt); err != nil {
+			return "", err
+		}
+
+		key := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, argon2KeyLen)
+		return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+			argon2.Version, p.Memory, p.Time, p.Threads,
+			base64.RawStdEncoding.EncodeToString(salt),
+			base64.RawStdEncoding.EncodeToString(key),
+		), nil
+	case Bcrypt:
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), p.BcryptCost)
+		return string(hash), err
+	default:
+		return "", fmt.Errorf("unknown password hash algorithm %q", p.Algorithm)
+	}
+}
+
+// Verify checks a password against a stored hash. rehash is true when the password matched but the hash
+// was made with other params, hash it again with Hash and store the result
+func (p Params) Verify(encoded, password string) (ok, rehash bool, err error) {
+	switch {
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		hash, err := decodeArgon2(encoded)
+		if err != nil {
+			return false, false, err
+		}
+
+		key := argon2.IDKey([]byte(password), hash.salt, hash.time, hash.memory, hash.threads, uint32(len(hash.key)))
+		if subtle.ConstantTimeCompare(key, hash.key) != 1 {
+			return false, false, nil
+		}
+
+		current := p.Algorithm == Argon2id && hash.memory == p.Memory && hash.time == p.Time &&
+			hash.threads == p.Threads && len(hash.key) == argon2KeyLen && len(hash.salt) == argon2SaltLen
+		return true, !current, nil
+	case strings.HasPrefix(encoded, "$2"):
+		if err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password)); err != nil {
+			if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+				return false, false, nil
+			}
+			return false, false, err
+		}
+
+		cost, err := bcrypt.Cost([]byte(encoded))
+		if err != nil {
+			return false, false, err
+		}
+
+		return true, p.Algorithm != Bcrypt || cost != p.BcryptCost, nil
+	default:
+		return false, false, ErrUnknownHash
+	}
+}
+
+type argon2Hash struct {
+	memory  uint32
+	time    uint32
+	threads uint8
+	salt    []byte
+	key     []byte
+}
+
+func decodeArgon2(encoded string) (*argon2Hash, error) {
+	// "", "argon2id", "v=19", "m=65536,t=3,p=2", salt, key
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return nil, ErrUnknownHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, ErrUnknownHash
+	}
+
+	var hash argon2Hash
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &hash.memory, &hash.time, &hash.threads); err != nil {
+		return nil, ErrUnknownHash
+	}
+
+	var err error
+	if hash.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return nil, ErrUnknownHash
+	}
+	if hash.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(hash.key) == 0 {
+		return nil, ErrUnknownHash
+	}
+
+	return &hash, nil
+}
@@ -0,0 +1,121 @@
+package password
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// cheap enough to run in tests, the real params take tens of milliseconds per hash
+var testArgon2 = Params{Algorithm: Argon2id, Memory: 1024, Time: 1, Threads: 1, BcryptCost: 4}
+
+func TestHashVerify(t *testing.T) {
+	testBcrypt := testArgon2
+	testBcrypt.Algorithm = Bcrypt
+
+	for _, params := range []Params{testArgon2, testBcrypt} {
+		t.Run(params.Algorithm, func(t *testing.T) {
+			encoded, err := params.Hash("Correct-Horse-9")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ok, rehash, err := params.Verify(encoded, "Correct-Horse-9")
+			if err != nil || !ok || rehash {
+				t.Fatalf("Verify(right password) = %v, %v, %v, want true, false, nil", ok, rehash, err)
+			}
+
+			ok, rehash, err = params.Verify(encoded, "Correct-Horse-8")
+			if err != nil || ok || rehash {
+				t.Fatalf("Verify(wrong password) = %v, %v, %v, want false, false, nil", ok, rehash, err)
+			}
+
+			// salted, the same password never hashes the same way twice
+			again, err := params.Hash("Correct-Horse-9")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if again == encoded {
+				t.Fatal("two hashes of one password are the same")
+			}
+		})
+	}
+}
+
+func TestVerifyRehash(t *testing.T) {
+	moreMemory := testArgon2
+	moreMemory.Memory = 2048
+	moreTime := testArgon2
+	moreTime.Time = 2
+	moreThreads := testArgon2
+	moreThreads.Threads = 2
+	bcryptParams := testArgon2
+	bcryptParams.Algorithm = Bcrypt
+	higherCost := bcryptParams
+	higherCost.BcryptCost = 5
+
+	tests := []struct {
+		name    string
+		made    Params
+		current Params
+		rehash  bool
+	}{
+		{"same argon2id params", testArgon2, testArgon2, false},
+		{"argon2id memory raised", testArgon2, moreMemory, true},
+		{"argon2id time raised", testArgon2, moreTime, true},
+		{"argon2id threads raised", testArgon2, moreThreads, true},
+		{"argon2id lowered again", moreMemory, testArgon2, true},
+		{"same bcrypt cost", bcryptParams, bcryptParams, false},
+		{"bcrypt cost raised", bcryptParams, higherCost, true},
+		{"bcrypt to argon2id", bcryptParams, testArgon2, true},
+		{"argon2id to bcrypt", testArgon2, bcryptParams, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := tt.made.Hash("Correct-Horse-9")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ok, rehash, err := tt.current.Verify(encoded, "Correct-Horse-9")
+			if err != nil || !ok {
+				t.Fatalf("Verify = %v, %v, want a match", ok, err)
+			}
+			if rehash != tt.rehash {
+				t.Fatalf("rehash = %v, want %v", rehash, tt.rehash)
+			}
+		})
+	}
+}
+
+func TestVerifyMalformed(t *testing.T) {
+	valid, err := testArgon2.Hash("Correct-Horse-9")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(valid, "$")
+
+	tests := []struct {
+		name    string
+		encoded string
+	}{
+		{"empty", ""},
+		{"plain text", "Correct-Horse-9"},
+		{"other scheme", "$1$abc$def"},
+		{"missing key", strings.Join(parts[:5], "$")},
+		{"other argon2 version", strings.Replace(valid, "v=19", "v=16", 1)},
+		{"unreadable params", strings.Replace(valid, parts[3], "m=x,t=1,p=1", 1)},
+		{"salt not base64", strings.Replace(valid, parts[4], "!!!", 1)},
+		{"empty key", strings.Join(append(parts[:5:5], ""), "$")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, _, err := testArgon2.Verify(tt.encoded, "Correct-Horse-9")
+			if ok || !errors.Is(err, ErrUnknownHash) {
+				t.Fatalf("Verify(%q) = %v, %v, want ErrUnknownHash", tt.encoded, ok, err)
+			}
+		})
+	}
+}
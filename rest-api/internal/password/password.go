@@ -0,0 +1,56 @@
+package password
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/exzacter/gorestapi/internal/serverconfig"
+)
+
+// Manager hashes and checks passwords the way the config says
+type Manager struct {
+	Params Params
+	Policy Policy
+}
+
+// FromConfig builds a Manager from the PASSWORD_* settings, loading the breached list when one is set
+func FromConfig(config *serverconfig.Config) (*Manager, error) {
+	params := Params{
+		Algorithm:  config.PasswordHash,
+		Memory:     uint32(config.PasswordArgon2Memory),
+		Time:       uint32(config.PasswordArgon2Time),
+		Threads:    uint8(config.PasswordArgon2Threads),
+		BcryptCost: int(config.PasswordBcryptCost),
+	}
+	if params.Algorithm != Argon2id && params.Algorithm != Bcrypt {
+		return nil, fmt.Errorf("unknown PASSWORD_HASH %q", params.Algorithm)
+	}
+
+	policy := Policy{
+		MinLength:      int(config.PasswordMinLength),
+		MaxLength:      int(config.PasswordMaxLength),
+		MinCharClasses: int(config.PasswordMinCharClasses),
+		ForbidPersonal: true,
+	}
+	if config.PasswordBreachedFile != "" {
+		breached, err := LoadBreached(config.PasswordBreachedFile)
+		if err != nil {
+			return nil, err
+		}
+		policy.Breached = breached
+	}
+
+	return &Manager{Params: params, Policy: policy}, nil
+}
+
+func (m *Manager) Hash(password string) (string, error) {
+	return m.Params.Hash(password)
+}
+
+func (m *Manager) Verify(encoded, password string) (ok, rehash bool, err error) {
+	return m.Params.Verify(encoded, password)
+}
+
+func (m *Manager) Check(ctx context.Context, password, username, email string) error {
+	return m.Policy.Check(ctx, password, username, email)
+}
@@ -0,0 +1,111 @@
+package password
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Policy is what a new password has to satisfy
+type Policy struct {
+	MinLength int
+	MaxLength int
+	// how many of lower case, upper case, digits and symbols it needs
+	MinCharClasses int
+	// rejects passwords containing the username or the part of the email before the @
+	ForbidPersonal bool
+	// nil skips the breached check
+	Breached BreachedSource
+}
+
+var DefaultPolicy = Policy{
+	MinLength:      10,
+	MaxLength:      128,
+	MinCharClasses: 3,
+	ForbidPersonal: true,
+}
+
+// Violations lists every rule a password broke, so the user can fix them all at once
+type Violations []string
+
+func (v Violations) Error() string {
+	return strings.Join(v, ", ")
+}
+
+// personal values shorter than this are too common to forbid, "al" would rule out "totally"
+const minPersonalLength = 3
+
+// Check returns Violations when the password breaks the policy. any other error comes from the breached
+// source
+func (p Policy) Check(ctx context.Context, password, username, email string) error {
+	var violations Violations
+
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		violations = append(violations, fmt.Sprintf("password must be at least %d characters", p.MinLength))
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		violations = append(violations, fmt.Sprintf("password must be at most %d characters", p.MaxLength))
+	}
+
+	if classes := charClasses(password); classes < p.MinCharClasses {
+		violations = append(violations, fmt.Sprintf("password must mix at least %d of lower case, upper case, digits and symbols", p.MinCharClasses))
+	}
+
+	if p.ForbidPersonal {
+		lowered := strings.ToLower(password)
+		local, _, _ := strings.Cut(email, "@")
+		for _, personal := range []string{username, local} {
+			personal = strings.ToLower(strings.TrimSpace(personal))
+			if utf8.RuneCountInString(personal) >= minPersonalLength && strings.Contains(lowered, personal) {
+				violations = append(violations, "password must not contain your username or email")
+				break
+			}
+		}
+	}
+
+	// only worth asking when everything else passed
+	if len(violations) == 0 && p.Breached != nil {
+		breached, err := Breached(ctx, p.Breached, password)
+		if err != nil {
+			return err
+		}
+		if breached {
+			violations = append(violations, "password has appeared in a data breach, choose another")
+		}
+	}
+
+	if len(violations) > 0 {
+		return violations
+	}
+
+	return nil
+}
+
+func charClasses(password string) int {
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r), unicode.IsLetter(r):
+			// letters without case, such as cjk, count as lower case
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+
+	count := 0
+	for _, has := range []bool{lower, upper, digit, symbol} {
+		if has {
+			count++
+		}
+	}
+
+	return count
+}
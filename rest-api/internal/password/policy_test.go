@@ -0,0 +1,144 @@
+package password
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPolicyCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		password string
+		username string
+		email    string
+		// the violations expected, nil when the password is fine
+		want Violations
+	}{
+		{"fine", "Correct-Horse-9", "ada", "ada@example.com", nil},
+		{"too short", "Ab1!", "ada", "ada@example.com", Violations{"password must be at least 10 characters"}},
+		{"too long", "Aa1!" + strings.Repeat("x", 125), "ada", "ada@example.com", Violations{"password must be at most 128 characters"}},
+		// length is counted in characters, not bytes
+		{"multibyte length", "Ünïcödé-1", "ada", "ada@example.com", Violations{"password must be at least 10 characters"}},
+		{"two classes", "alllowercase1", "ada", "ada@example.com", Violations{"password must mix at least 3 of lower case, upper case, digits and symbols"}},
+		{"contains the username", "Lovelace-1843", "lovelace", "ada@example.com", Violations{"password must not contain your username or email"}},
+		{"contains the username in another case", "LOVELACE-1843x", "Lovelace", "ada@example.com", Violations{"password must not contain your username or email"}},
+		{"contains the email", "Countess-ada.k1", "lovelace", "ada.k@example.com", Violations{"password must not contain your username or email"}},
+		{"short personal values are ignored", "Totally-Fine-1", "al", "al@example.com", nil},
+		{
+			name:     "every violation at once",
+			password: "ada",
+			username: "ada",
+			email:    "ada@example.com",
+			want: Violations{
+				"password must be at least 10 characters",
+				"password must mix at least 3 of lower case, upper case, digits and symbols",
+				"password must not contain your username or email",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := DefaultPolicy.Check(context.Background(), tt.password, tt.username, tt.email)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("Check = %v, want nil", err)
+				}
+				return
+			}
+
+			var violations Violations
+			if !errors.As(err, &violations) {
+				t.Fatalf("Check = %v, want Violations", err)
+			}
+			if !slices.Equal(violations, tt.want) {
+				t.Fatalf("Check = %q, want %q", violations, tt.want)
+			}
+		})
+	}
+}
+
+func TestCharClasses(t *testing.T) {
+	tests := []struct {
+		password string
+		want     int
+	}{
+		{"", 0},
+		{"abc", 1},
+		{"abcABC", 2},
+		{"abcABC123", 3},
+		{"abcABC123!", 4},
+		{"ab cd", 2},
+		// letters without case count as lower case
+		{"密码密码", 1},
+		{"密码ABC", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			if got := charClasses(tt.password); got != tt.want {
+				t.Fatalf("charClasses(%q) = %d, want %d", tt.password, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyBreached(t *testing.T) {
+	breached := "Password-123"
+	sum := sha1.Sum([]byte(breached))
+
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	contents := "# pwned passwords\n\n" + strings.ToUpper(hex.EncodeToString(sum[:])) + ":42\n"
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := LoadBreached(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy := DefaultPolicy
+	policy.Breached = list
+
+	tests := []struct {
+		name     string
+		password string
+		want     string
+	}{
+		{"breached", breached, "password has appeared in a data breach, choose another"},
+		{"not breached", "Password-124", ""},
+		// the list is only asked once everything else passed
+		{"other violations first", "short", "password must be at least 10 characters, password must mix at least 3 of lower case, upper case, digits and symbols"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Check(context.Background(), tt.password, "ada", "ada@example.com")
+			got := ""
+			if err != nil {
+				got = err.Error()
+			}
+			if got != tt.want {
+				t.Fatalf("Check = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadBreachedRejectsGarbage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	if err := os.WriteFile(path, []byte("not-a-hash\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadBreached(path); err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Fatalf("LoadBreached = %v, want an error for line 1", err)
+	}
+}
@@ -0,0 +1,32 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/exzacter/gorestapi/internal/auth"
+	"github.com/exzacter/gorestapi/internal/handlers"
+	"github.com/exzacter/gorestapi/internal/metrics"
+	"github.com/exzacter/gorestapi/internal/middlewares"
+)
+
+func SetupBlogRoute(mux *http.ServeMux, handler *handlers.Handler) {
+	blogMux := http.NewServeMux()
+
+	blogMux.HandleFunc("GET /{$}", handler.ListBlogsHandler())
+	blogMux.HandleFunc("GET /search", handler.SearchBlogsHandler())
+	blogMux.HandleFunc("GET /stream", handler.BlogStreamHandler())
+	blogMux.Handle("GET /mine", middlewares.AuthMiddle(middlewares.RequireScope(auth.ScopeBlogsRead, http.HandlerFunc(handler.MyBlogsHandler()))))
+	blogMux.HandleFunc("GET /{id}", handler.GetBlogHandler())
+	blogMux.Handle("POST /{$}", middlewares.AuthMiddle(middlewares.RequireScope(auth.ScopeBlogsWrite, middlewares.Idempotent(http.HandlerFunc(handler.CreateBlogHandler())))))
+	blogMux.Handle("PUT /{id}", middlewares.AuthMiddle(middlewares.RequireScope(auth.ScopeBlogsWrite, http.HandlerFunc(handler.UpdateBlogHandler()))))
+
+	blogMux.HandleFunc("GET /{id}/comments", handler.ListCommentsHandler())
+	blogMux.Handle("POST /{id}/comments", middlewares.AuthMiddle(middlewares.RequireScope(auth.ScopeCommentsWrite, middlewares.Idempotent(http.HandlerFunc(handler.CreateCommentHandler())))))
+	blogMux.Handle("PUT /{id}/comments/{commentID}", middlewares.AuthMiddle(middlewares.RequireScope(auth.ScopeCommentsWrite, http.HandlerFunc(handler.UpdateCommentHandler()))))
+	blogMux.Handle("DELETE /{id}/comments/{commentID}", middlewares.AuthMiddle(middlewares.RequireScope(auth.ScopeCommentsWrite, http.HandlerFunc(handler.DeleteCommentHandler()))))
+
+	blogMux.Handle("POST /{id}/reactions", middlewares.AuthMiddle(middlewares.RequireScope(auth.ScopeReactionsWrite, http.HandlerFunc(handler.AddReactionHandler()))))
+	blogMux.Handle("DELETE /{id}/reactions/{kind}", middlewares.AuthMiddle(middlewares.RequireScope(auth.ScopeReactionsWrite, http.HandlerFunc(handler.RemoveReactionHandler()))))
+
+	mux.Handle("/blogs/", http.StripPrefix("/blogs", metrics.Routed(blogMux)))
+}
@@ -0,0 +1,11 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/exzacter/gorestapi/internal/handlers"
+)
+
+func SetupFileRoute(mux *http.ServeMux, handler *handlers.Handler) {
+	mux.HandleFunc("GET /files/{key...}", handler.ServeFileHandler())
+}
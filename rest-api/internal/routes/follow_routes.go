@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/exzacter/gorestapi/internal/auth"
+	"github.com/exzacter/gorestapi/internal/handlers"
+	"github.com/exzacter/gorestapi/internal/middlewares"
+)
+
+// follows live under /users/{id}, but on the v1 mux itself. on the users mux "/{id}/followers" would clash
+// with "/export/{id}", neither being more specific. here they are more specific than the "/users/" prefix
+func SetupFollowRoute(mux *http.ServeMux, handler *handlers.Handler) {
+	mux.Handle("POST /users/{id}/follow", middlewares.AuthMiddle(middlewares.RequireScope(auth.ScopeFollowsWrite, http.HandlerFunc(handler.FollowUserHandler()))))
+	mux.Handle("DELETE /users/{id}/follow", middlewares.AuthMiddle(middlewares.RequireScope(auth.ScopeFollowsWrite, http.HandlerFunc(handler.UnfollowUserHandler()))))
+	mux.HandleFunc("GET /users/{id}/followers", handler.ListFollowersHandler())
+	mux.HandleFunc("GET /users/{id}/following", handler.ListFollowingHandler())
+
+	mux.Handle("GET /feed", middlewares.AuthMiddle(middlewares.RequireScope(auth.ScopeBlogsRead, http.HandlerFunc(handler.FeedHandler()))))
+}
@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/exzacter/gorestapi/internal/auth"
+	"github.com/exzacter/gorestapi/internal/handlers"
+	"github.com/exzacter/gorestapi/internal/middlewares"
+)
+
+// background job admin, the handlers also check the user is an admin
+func SetupJobRoute(mux *http.ServeMux, handler *handlers.Handler) {
+	manage := func(h http.HandlerFunc) http.Handler {
+		return middlewares.AuthMiddle(middlewares.RequireScope(auth.ScopeJobsManage, h))
+	}
+
+	mux.Handle("GET /admin/jobs", manage(handler.ListJobsHandler()))
+	mux.Handle("POST /admin/jobs/{id}/retry", manage(handler.RetryJobHandler()))
+}
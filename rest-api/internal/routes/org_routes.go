@@ -0,0 +1,39 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/exzacter/gorestapi/internal/auth"
+	"github.com/exzacter/gorestapi/internal/handlers"
+	"github.com/exzacter/gorestapi/internal/metrics"
+	"github.com/exzacter/gorestapi/internal/middlewares"
+)
+
+// organisations and their members, the handlers check the caller's role in the organisation
+func SetupOrgRoute(mux *http.ServeMux, handler *handlers.Handler) {
+	orgMux := http.NewServeMux()
+
+	read := func(h http.Handler) http.Handler {
+		return middlewares.AuthMiddle(middlewares.RequireScope(auth.ScopeOrgsRead, h))
+	}
+	write := func(h http.Handler) http.Handler {
+		return middlewares.AuthMiddle(middlewares.RequireScope(auth.ScopeOrgsWrite, h))
+	}
+
+	orgMux.Handle("POST /{$}", write(middlewares.Idempotent(handler.CreateOrganizationHandler())))
+	orgMux.Handle("GET /{$}", read(handler.ListMyOrganizationsHandler()))
+	orgMux.Handle("POST /invitations/accept", write(handler.AcceptInvitationHandler()))
+	orgMux.Handle("GET /{id}", read(handler.GetOrganizationHandler()))
+	orgMux.Handle("GET /{id}/blogs", read(handler.ListOrganizationBlogsHandler()))
+	orgMux.Handle("GET /{id}/audit", read(handler.ListMembershipEventsHandler()))
+
+	orgMux.Handle("GET /{id}/members", read(handler.ListMembersHandler()))
+	orgMux.Handle("PUT /{id}/members/{userID}", write(handler.UpdateMemberHandler()))
+	orgMux.Handle("DELETE /{id}/members/{userID}", write(handler.RemoveMemberHandler()))
+
+	orgMux.Handle("POST /{id}/invitations", write(middlewares.Idempotent(handler.InviteMemberHandler())))
+	orgMux.Handle("GET /{id}/invitations", read(handler.ListInvitationsHandler()))
+	orgMux.Handle("DELETE /{id}/invitations/{invitationID}", write(handler.RevokeInvitationHandler()))
+
+	mux.Handle("/orgs/", http.StripPrefix("/orgs", metrics.Routed(orgMux)))
+}
@@ -2,22 +2,44 @@ package routes
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/exzacter/gorestapi/internal/handlers"
+	"github.com/exzacter/gorestapi/internal/metrics"
+	"github.com/exzacter/gorestapi/internal/middlewares"
 	"github.com/exzacter/gorestapi/internal/utils"
 )
 
+// unversioned paths from before /v1 existed. they are served by v1 with deprecation headers until the sunset date
+var legacyPrefixes = []string{"/health", "/test", "/users/", "/blogs/", "/tags", "/files/"}
+
+var legacyDeprecation = middlewares.Deprecation{
+	Since:     time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC),
+	Sunset:    time.Date(2027, time.April, 18, 0, 0, 0, 0, time.UTC),
+	Successor: "/v1",
+}
+
 // passingin the routes that CAN be called via the API so if the client requests them they can be sent to the appropriate handler
 func SetupRoutes(mux *http.ServeMux, handler *handlers.Handler) {
-	SetupHealthRoute(mux, handler)
-	SetupTestRoute(mux, handler)
-	SetupUserRoute(mux, handler)
-
-	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
-		utils.RespondWithNotFound(w)
-	})
+	// each version gets its own mux mounted under its prefix, a v2 would be added the same way
+	v1 := http.NewServeMux()
+	SetupV1Routes(v1, handler)
+	// metrics.Routed lets request metrics see which pattern a sub mux matched
+	v1Routed := metrics.Routed(v1)
+	mux.Handle("/v1/", http.StripPrefix("/v1", v1Routed))
+
+	legacy := middlewares.Deprecated(legacyDeprecation)(v1Routed)
+	for _, prefix := range legacyPrefixes {
+		mux.Handle(prefix, legacy)
+	}
+
+	mux.HandleFunc("/", notFoundHandler)
+}
+
+func notFoundHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	utils.RespondWithNotFound(w)
 }
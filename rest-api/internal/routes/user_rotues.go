@@ -3,17 +3,37 @@ package routes
 import (
 	"net/http"
 
+	"github.com/exzacter/gorestapi/internal/auth"
 	"github.com/exzacter/gorestapi/internal/handlers"
+	"github.com/exzacter/gorestapi/internal/metrics"
 	"github.com/exzacter/gorestapi/internal/middlewares"
 )
 
 func SetupUserRoute(mux *http.ServeMux, handler *handlers.Handler) {
 	userMux := http.NewServeMux()
 
-	userMux.HandleFunc("POST /register", handler.CreateUserHandler())
+	userMux.Handle("POST /register", middlewares.Idempotent(http.HandlerFunc(handler.CreateUserHandler())))
 	userMux.HandleFunc("POST /login", handler.LoginUserHandler())
-	userMux.Handle("GET /profile", middlewares.AuthMiddle(http.HandlerFunc(handler.UserProfile())))
+	userMux.HandleFunc("POST /login/2fa", handler.LoginTwoFactorHandler())
+	userMux.HandleFunc("POST /login/magic", handler.RequestMagicLinkHandler())
+	userMux.HandleFunc("POST /login/magic/verify", handler.VerifyMagicLinkHandler())
+	userMux.Handle("GET /profile", middlewares.AuthMiddle(middlewares.RequireScope(auth.ScopeProfileRead, http.HandlerFunc(handler.UserProfile()))))
+	userMux.Handle("PUT /profile/avatar", middlewares.AuthMiddle(middlewares.RequireScope(auth.ScopeProfileWrite, http.HandlerFunc(handler.UploadAvatarHandler()))))
 
-	userMux.Handle("POST /session/logout", middlewares.AuthMiddle(http.HandlerFunc(handler.LogoutHandler())))
-	mux.Handle("/users/", http.StripPrefix("/users", userMux))
+	userMux.Handle("POST /session/logout", middlewares.AuthMiddle(middlewares.RequireJWT(http.HandlerFunc(handler.LogoutHandler()))))
+
+	userMux.Handle("POST /2fa/enroll", middlewares.AuthMiddle(middlewares.RequireJWT(http.HandlerFunc(handler.EnrollTwoFactorHandler()))))
+	userMux.Handle("POST /2fa/confirm", middlewares.AuthMiddle(middlewares.RequireJWT(http.HandlerFunc(handler.ConfirmTwoFactorHandler()))))
+
+	// api keys can only be managed by a logged in user, never by another api key
+	userMux.Handle("POST /api-keys", middlewares.AuthMiddle(middlewares.RequireJWT(middlewares.Idempotent(http.HandlerFunc(handler.CreateApiKeyHandler())))))
+	userMux.Handle("GET /api-keys", middlewares.AuthMiddle(middlewares.RequireJWT(http.HandlerFunc(handler.ListApiKeysHandler()))))
+	userMux.Handle("DELETE /api-keys/{id}", middlewares.AuthMiddle(middlewares.RequireJWT(http.HandlerFunc(handler.RevokeApiKeyHandler()))))
+
+	// personal data. the download link carries its own credential so it can be opened in a browser
+	userMux.Handle("POST /export", middlewares.AuthMiddle(middlewares.RequireJWT(http.HandlerFunc(handler.StartExportHandler()))))
+	userMux.Handle("GET /export/{id}", middlewares.AuthMiddle(middlewares.RequireJWT(http.HandlerFunc(handler.GetExportHandler()))))
+	userMux.HandleFunc("GET /export/{id}/download", handler.DownloadExportHandler())
+	userMux.Handle("POST /erase", middlewares.AuthMiddle(middlewares.RequireJWT(http.HandlerFunc(handler.EraseAccountHandler()))))
+	mux.Handle("/users/", http.StripPrefix("/users", metrics.Routed(userMux)))
 }
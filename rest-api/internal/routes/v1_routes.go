@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/exzacter/gorestapi/internal/handlers"
+)
+
+// SetupV1Routes registers every v1 endpoint on a mux that is mounted at /v1
+func SetupV1Routes(mux *http.ServeMux, handler *handlers.Handler) {
+	SetupHealthRoute(mux, handler)
+	SetupTestRoute(mux, handler)
+	SetupUserRoute(mux, handler)
+	SetupFollowRoute(mux, handler)
+	SetupBlogRoute(mux, handler)
+	SetupTagRoute(mux, handler)
+	SetupFileRoute(mux, handler)
+	SetupWebhookRoute(mux, handler)
+	SetupJobRoute(mux, handler)
+	SetupOrgRoute(mux, handler)
+
+	mux.HandleFunc("/", notFoundHandler)
+}
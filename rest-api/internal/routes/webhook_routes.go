@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/exzacter/gorestapi/internal/auth"
+	"github.com/exzacter/gorestapi/internal/handlers"
+	"github.com/exzacter/gorestapi/internal/middlewares"
+)
+
+// webhook management, the handlers also check the user is an admin
+func SetupWebhookRoute(mux *http.ServeMux, handler *handlers.Handler) {
+	manage := func(h http.Handler) http.Handler {
+		return middlewares.AuthMiddle(middlewares.RequireScope(auth.ScopeWebhooksManage, h))
+	}
+
+	mux.Handle("POST /webhooks", manage(middlewares.Idempotent(handler.CreateWebhookHandler())))
+	mux.Handle("GET /webhooks", manage(handler.ListWebhooksHandler()))
+	mux.Handle("DELETE /webhooks/{id}", manage(handler.DeleteWebhookHandler()))
+	mux.Handle("GET /webhooks/{id}/deliveries", manage(handler.ListWebhookDeliveriesHandler()))
+	mux.Handle("POST /webhooks/{id}/deliveries/{deliveryID}/redeliver", manage(handler.RedeliverWebhookHandler()))
+}
@@ -3,6 +3,10 @@ package serverconfig
 import (
 	"fmt"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -12,6 +16,99 @@ type Config struct {
 	DatabaseURL string
 	Environment string
 	LogLevel    string
+	// how often scheduled blogs are checked for publishing
+	PublishInterval time.Duration
+
+	// where uploads are kept, "local" or "s3"
+	StorageDriver   string
+	StorageLocalDir string
+	S3Endpoint      string
+	S3AccessKey     string
+	S3SecretKey     string
+	S3Bucket        string
+	S3Region        string
+	S3UseSSL        bool
+	// largest avatar upload accepted, in bytes
+	AvatarMaxBytes int64
+
+	// browser origins allowed to call the api, https://*.example.com allows any subdomain
+	CORSAllowedOrigins   []string
+	CORSAllowedMethods   []string
+	CORSAllowedHeaders   []string
+	CORSExposedHeaders   []string
+	CORSAllowCredentials bool
+	CORSMaxAge           time.Duration
+
+	// serve /metrics on its own address such as :9090 so it isn't public, empty serves it on the api port
+	MetricsAddr string
+
+	// where spans go, "none", "stdout" or "otlp". otlp reads the standard OTEL_EXPORTER_OTLP_* env vars
+	TracingExporter    string
+	TracingServiceName string
+
+	// how often queued webhook deliveries are sent, how long a receiver gets to answer and how many
+	// attempts a delivery gets before it is marked dead
+	WebhookInterval    time.Duration
+	WebhookTimeout     time.Duration
+	WebhookMaxAttempts int64
+
+	// background job workers: how many jobs run at once, how often idle workers look for due jobs, how long
+	// a job may run before it is handed to another worker and how long running jobs get to finish on shutdown
+	JobsConcurrency       int64
+	JobsPollInterval      time.Duration
+	JobsVisibilityTimeout time.Duration
+	JobsShutdownTimeout   time.Duration
+
+	// responses smaller than this many bytes are sent uncompressed
+	CompressMinSize int64
+
+	// how long a personal data export can be downloaded for before it is deleted
+	ExportLinkTTL time.Duration
+
+	// what new passwords must look like. the breached list is a file of sha1 hashes, empty skips the check
+	PasswordMinLength      int64
+	PasswordMaxLength      int64
+	PasswordMinCharClasses int64
+	PasswordBreachedFile   string
+
+	// how new password hashes are made, older hashes are upgraded on the next login
+	PasswordHash          string
+	PasswordArgon2Memory  int64
+	PasswordArgon2Time    int64
+	PasswordArgon2Threads int64
+	PasswordBcryptCost    int64
+
+	// how mail is sent, "log" writes it to the server log and "smtp" sends it through SMTP_ADDR
+	MailDriver   string
+	MailFrom     string
+	SMTPAddr     string
+	SMTPUsername string
+	SMTPPassword string
+
+	// passwordless login: the page the emailed link opens, which posts the token to the verify endpoint,
+	// how long a link works and how many links one address can be sent per hour
+	MagicLinkURL       string
+	MagicLinkTTL       time.Duration
+	MagicLinkRateLimit int64
+
+	// redis failures in a row that open the circuit breaker, and how long it stays open before redis is
+	// tried again. while it is open "open" lets jwts through on this instance's own revocations and
+	// "closed" refuses them with a 503
+	RedisBreakerThreshold int64
+	RedisBreakerCooldown  time.Duration
+	RedisFailMode         string
+
+	// GET /blogs/stream: how often an idle stream gets a heartbeat comment, and about how many events the
+	// redis stream keeps for clients resuming with Last-Event-ID
+	SSEHeartbeatInterval time.Duration
+	SSEStreamMaxLen      int64
+
+	// GET /feed is built on read. with FeedCache on, users following at least FeedCacheMinFollowing
+	// users get the newest FeedCacheSize entries of theirs kept in redis for FeedCacheTTL instead
+	FeedCache             bool
+	FeedCacheMinFollowing int64
+	FeedCacheSize         int64
+	FeedCacheTTL          time.Duration
 }
 
 func LoadConfig() (*Config, error) {
@@ -19,11 +116,240 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("Error loading file: %v", err)
 	}
 
+	publishInterval, err := getEnvDuration("PUBLISH_INTERVAL", "30s")
+	if err != nil {
+		return nil, err
+	}
+
+	s3UseSSL, err := getEnvBool("S3_USE_SSL", "true")
+	if err != nil {
+		return nil, err
+	}
+
+	avatarMaxBytes, err := getEnvInt64("AVATAR_MAX_BYTES", "2097152")
+	if err != nil {
+		return nil, err
+	}
+
+	corsAllowCredentials, err := getEnvBool("CORS_ALLOW_CREDENTIALS", "false")
+	if err != nil {
+		return nil, err
+	}
+
+	// credentials for every origin would let any site make logged in requests and read the answers
+	corsAllowedOrigins := getEnvList("CORS_ALLOWED_ORIGINS", "")
+	if corsAllowCredentials && slices.Contains(corsAllowedOrigins, "*") {
+		return nil, fmt.Errorf("Invalid CORS_ALLOWED_ORIGINS, * can't be used with CORS_ALLOW_CREDENTIALS, list the origins instead")
+	}
+
+	corsMaxAge, err := getEnvDuration("CORS_MAX_AGE", "10m")
+	if err != nil {
+		return nil, err
+	}
+
+	webhookInterval, err := getEnvDuration("WEBHOOK_INTERVAL", "5s")
+	if err != nil {
+		return nil, err
+	}
+
+	webhookTimeout, err := getEnvDuration("WEBHOOK_TIMEOUT", "10s")
+	if err != nil {
+		return nil, err
+	}
+
+	webhookMaxAttempts, err := getEnvInt64("WEBHOOK_MAX_ATTEMPTS", "8")
+	if err != nil {
+		return nil, err
+	}
+
+	jobsConcurrency, err := getEnvInt64("JOBS_CONCURRENCY", "4")
+	if err != nil {
+		return nil, err
+	}
+
+	jobsPollInterval, err := getEnvDuration("JOBS_POLL_INTERVAL", "1s")
+	if err != nil {
+		return nil, err
+	}
+
+	jobsVisibilityTimeout, err := getEnvDuration("JOBS_VISIBILITY_TIMEOUT", "5m")
+	if err != nil {
+		return nil, err
+	}
+
+	jobsShutdownTimeout, err := getEnvDuration("JOBS_SHUTDOWN_TIMEOUT", "30s")
+	if err != nil {
+		return nil, err
+	}
+
+	compressMinSize, err := getEnvInt64("COMPRESS_MIN_SIZE", "1024")
+	if err != nil {
+		return nil, err
+	}
+
+	exportLinkTTL, err := getEnvDuration("EXPORT_LINK_TTL", "24h")
+	if err != nil {
+		return nil, err
+	}
+
+	passwordMinLength, err := getEnvInt64("PASSWORD_MIN_LENGTH", "10")
+	if err != nil {
+		return nil, err
+	}
+
+	passwordMaxLength, err := getEnvInt64("PASSWORD_MAX_LENGTH", "128")
+	if err != nil {
+		return nil, err
+	}
+
+	passwordMinCharClasses, err := getEnvInt64("PASSWORD_MIN_CHAR_CLASSES", "3")
+	if err != nil {
+		return nil, err
+	}
+
+	passwordArgon2Memory, err := getEnvInt64("PASSWORD_ARGON2_MEMORY", "65536")
+	if err != nil {
+		return nil, err
+	}
+
+	passwordArgon2Time, err := getEnvInt64("PASSWORD_ARGON2_TIME", "3")
+	if err != nil {
+		return nil, err
+	}
+
+	passwordArgon2Threads, err := getEnvInt64("PASSWORD_ARGON2_THREADS", "2")
+	if err != nil {
+		return nil, err
+	}
+
+	passwordBcryptCost, err := getEnvInt64("PASSWORD_BCRYPT_COST", "12")
+	if err != nil {
+		return nil, err
+	}
+
+	magicLinkTTL, err := getEnvDuration("MAGIC_LINK_TTL", "15m")
+	if err != nil {
+		return nil, err
+	}
+
+	magicLinkRateLimit, err := getEnvInt64("MAGIC_LINK_RATE_LIMIT", "5")
+	if err != nil {
+		return nil, err
+	}
+
+	redisBreakerThreshold, err := getEnvInt64("REDIS_BREAKER_THRESHOLD", "5")
+	if err != nil {
+		return nil, err
+	}
+
+	redisBreakerCooldown, err := getEnvDuration("REDIS_BREAKER_COOLDOWN", "10s")
+	if err != nil {
+		return nil, err
+	}
+
+	sseHeartbeatInterval, err := getEnvDuration("SSE_HEARTBEAT_INTERVAL", "15s")
+	if err != nil {
+		return nil, err
+	}
+
+	sseStreamMaxLen, err := getEnvInt64("SSE_STREAM_MAX_LEN", "1000")
+	if err != nil {
+		return nil, err
+	}
+
+	feedCache, err := getEnvBool("FEED_CACHE", "true")
+	if err != nil {
+		return nil, err
+	}
+
+	feedCacheMinFollowing, err := getEnvInt64("FEED_CACHE_MIN_FOLLOWING", "200")
+	if err != nil {
+		return nil, err
+	}
+
+	feedCacheSize, err := getEnvInt64("FEED_CACHE_SIZE", "500")
+	if err != nil {
+		return nil, err
+	}
+
+	feedCacheTTL, err := getEnvDuration("FEED_CACHE_TTL", "1h")
+	if err != nil {
+		return nil, err
+	}
+
 	return &Config{
-		ServerPort:  GetEnv("SERVER_PORT", "8080"),
-		DatabaseURL: GetEnv("DATABASE_URL", "postgres"),
-		Environment: GetEnv("ENVIRONMENT", "development"),
-		LogLevel:    GetEnv("LOG_LEVEL", "info"),
+		ServerPort:      GetEnv("SERVER_PORT", "8080"),
+		DatabaseURL:     GetEnv("DATABASE_URL", "postgres"),
+		Environment:     GetEnv("ENVIRONMENT", "development"),
+		LogLevel:        GetEnv("LOG_LEVEL", "info"),
+		PublishInterval: publishInterval,
+		StorageDriver:   GetEnv("STORAGE_DRIVER", "local"),
+		StorageLocalDir: GetEnv("STORAGE_LOCAL_DIR", "./uploads"),
+		S3Endpoint:      GetEnv("S3_ENDPOINT", ""),
+		S3AccessKey:     GetEnv("S3_ACCESS_KEY", ""),
+		S3SecretKey:     GetEnv("S3_SECRET_KEY", ""),
+		S3Bucket:        GetEnv("S3_BUCKET", "uploads"),
+		S3Region:        GetEnv("S3_REGION", ""),
+		S3UseSSL:        s3UseSSL,
+		AvatarMaxBytes:  avatarMaxBytes,
+
+		CORSAllowedOrigins:   corsAllowedOrigins,
+		CORSAllowedMethods:   getEnvList("CORS_ALLOWED_METHODS", "GET,POST,PUT,PATCH,DELETE"),
+		CORSAllowedHeaders:   getEnvList("CORS_ALLOWED_HEADERS", "Authorization,Content-Type,X-API-Key,Idempotency-Key,If-Match,If-None-Match"),
+		CORSExposedHeaders:   getEnvList("CORS_EXPOSED_HEADERS", "Deprecation,Sunset,Link,Idempotent-Replayed,ETag"),
+		CORSAllowCredentials: corsAllowCredentials,
+		CORSMaxAge:           corsMaxAge,
+
+		MetricsAddr: GetEnv("METRICS_ADDR", ""),
+
+		TracingExporter:    GetEnv("TRACING_EXPORTER", "none"),
+		TracingServiceName: GetEnv("TRACING_SERVICE_NAME", "gorestapi"),
+
+		WebhookInterval:    webhookInterval,
+		WebhookTimeout:     webhookTimeout,
+		WebhookMaxAttempts: webhookMaxAttempts,
+
+		JobsConcurrency:       jobsConcurrency,
+		JobsPollInterval:      jobsPollInterval,
+		JobsVisibilityTimeout: jobsVisibilityTimeout,
+		JobsShutdownTimeout:   jobsShutdownTimeout,
+
+		CompressMinSize: compressMinSize,
+
+		ExportLinkTTL: exportLinkTTL,
+
+		PasswordMinLength:      passwordMinLength,
+		PasswordMaxLength:      passwordMaxLength,
+		PasswordMinCharClasses: passwordMinCharClasses,
+		PasswordBreachedFile:   GetEnv("PASSWORD_BREACHED_FILE", ""),
+
+		PasswordHash:          GetEnv("PASSWORD_HASH", "argon2id"),
+		PasswordArgon2Memory:  passwordArgon2Memory,
+		PasswordArgon2Time:    passwordArgon2Time,
+		PasswordArgon2Threads: passwordArgon2Threads,
+		PasswordBcryptCost:    passwordBcryptCost,
+
+		MailDriver:   GetEnv("MAIL_DRIVER", "log"),
+		MailFrom:     GetEnv("MAIL_FROM", "no-reply@localhost"),
+		SMTPAddr:     GetEnv("SMTP_ADDR", ""),
+		SMTPUsername: GetEnv("SMTP_USERNAME", ""),
+		SMTPPassword: GetEnv("SMTP_PASSWORD", ""),
+
+		MagicLinkURL:       GetEnv("MAGIC_LINK_URL", "http://localhost:3000/login/magic"),
+		MagicLinkTTL:       magicLinkTTL,
+		MagicLinkRateLimit: magicLinkRateLimit,
+
+		RedisBreakerThreshold: redisBreakerThreshold,
+		RedisBreakerCooldown:  redisBreakerCooldown,
+		RedisFailMode:         GetEnv("REDIS_FAIL_MODE", "open"),
+
+		SSEHeartbeatInterval: sseHeartbeatInterval,
+		SSEStreamMaxLen:      sseStreamMaxLen,
+
+		FeedCache:             feedCache,
+		FeedCacheMinFollowing: feedCacheMinFollowing,
+		FeedCacheSize:         feedCacheSize,
+		FeedCacheTTL:          feedCacheTTL,
 	}, nil
 }
 
@@ -34,3 +360,47 @@ func GetEnv(key, defaultValue string) string {
 
 	return defaultValue
 }
+
+// positive durations such as 30s or 5m
+func getEnvDuration(key, defaultValue string) (time.Duration, error) {
+	raw := GetEnv(key, defaultValue)
+	value, err := time.ParseDuration(raw)
+	if err != nil || value <= 0 {
+		return 0, fmt.Errorf("Invalid %s %q, expected a positive duration like 30s", key, raw)
+	}
+
+	return value, nil
+}
+
+func getEnvBool(key, defaultValue string) (bool, error) {
+	raw := GetEnv(key, defaultValue)
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, fmt.Errorf("Invalid %s %q, expected true or false", key, raw)
+	}
+
+	return value, nil
+}
+
+// positive whole numbers
+func getEnvInt64(key, defaultValue string) (int64, error) {
+	raw := GetEnv(key, defaultValue)
+	value, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || value <= 0 {
+		return 0, fmt.Errorf("Invalid %s %q, expected a positive number", key, raw)
+	}
+
+	return value, nil
+}
+
+// comma separated values, blanks are dropped
+func getEnvList(key, defaultValue string) []string {
+	var values []string
+	for _, part := range strings.Split(GetEnv(key, defaultValue), ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+
+	return values
+}
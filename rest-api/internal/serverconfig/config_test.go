@@ -0,0 +1,47 @@
+package serverconfig
+
+import (
+	"os"
+	"slices"
+	"testing"
+)
+
+func TestLoadConfigCORS(t *testing.T) {
+	tests := []struct {
+		name        string
+		origins     string
+		credentials string
+		want        []string
+		wantErr     bool
+	}{
+		{"nothing allowed by default", "", "false", nil, false},
+		{"star", "*", "false", []string{"*"}, false},
+		{"list with credentials", "https://app.example.com, https://*.example.com", "true", []string{"https://app.example.com", "https://*.example.com"}, false},
+		// any site could make logged in requests and read the answers
+		{"star with credentials", "*", "true", nil, true},
+		{"star in a list with credentials", "https://app.example.com,*", "true", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// LoadConfig wants a .env file in the working directory
+			t.Chdir(t.TempDir())
+			if err := os.WriteFile(".env", nil, 0o600); err != nil {
+				t.Fatal(err)
+			}
+			t.Setenv("CORS_ALLOWED_ORIGINS", tt.origins)
+			t.Setenv("CORS_ALLOW_CREDENTIALS", tt.credentials)
+
+			config, err := LoadConfig()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadConfig error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !slices.Equal(config.CORSAllowedOrigins, tt.want) {
+				t.Fatalf("CORSAllowedOrigins = %q, want %q", config.CORSAllowedOrigins, tt.want)
+			}
+		})
+	}
+}
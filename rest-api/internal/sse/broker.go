@@ -0,0 +1,192 @@
+package sse
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// events sent on GET /v1/blogs/stream
+const (
+	EventBlogPublished  = "blog.published"
+	EventBlogUpdated    = "blog.updated"
+	EventCommentCreated = "comment.created"
+)
+
+// Event is one entry of the stream. ID is the redis stream id, which clients send back as Last-Event-ID
+type Event struct {
+	ID   string          `json:"id"`
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+type Blog struct {
+	ID     int32  `json:"id"`
+	Title  string `json:"title"`
+	UserID int32  `json:"user_id"`
+}
+
+type Comment struct {
+	ID       int32  `json:"id"`
+	BlogID   int32  `json:"blog_id"`
+	UserID   int32  `json:"user_id"`
+	ParentID *int32 `json:"parent_id"`
+	Content  string `json:"content"`
+}
+
+// events a subscriber can fall behind by before it is dropped
+const subscriberBuffer = 64
+
+// Broker publishes events to every instance of the api and hands them to the clients connected to this
+// one. every event is added to a redis stream, trimmed to about maxLen entries, for clients resuming
+// with Last-Event-ID, and published on a pub/sub channel that every instance's Run listens to
+type Broker struct {
+	rdb     *redis.Client
+	stream  string
+	channel string
+	maxLen  int64
+
+	mu          sync.Mutex
+	subscribers map[chan Event]struct{}
+	// Run has returned, nothing more will be delivered
+	closed bool
+}
+
+func NewBroker(rdb *redis.Client, prefix string, maxLen int64) *Broker {
+	return &Broker{
+		rdb:         rdb,
+		stream:      prefix + ":stream",
+		channel:     prefix + ":live",
+		maxLen:      maxLen,
+		subscribers: make(map[chan Event]struct{}),
+	}
+}
+
+// Publish adds the event to the stream and sends it to every instance. call it once the change is
+// committed, a client must never hear of a blog it can't read yet
+func (b *Broker) Publish(ctx context.Context, eventType string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	id, err := b.rdb.XAdd(ctx, &redis.XAddArgs{
+		Stream: b.stream,
+		MaxLen: b.maxLen,
+		Approx: true,
+		Values: map[string]interface{}{
+			"type": eventType,
+			"data": string(payload),
+		},
+	}).Result()
+	if err != nil {
+		return err
+	}
+
+	message, err := json.Marshal(Event{ID: id, Type: eventType, Data: payload})
+	if err != nil {
+		return err
+	}
+
+	return b.rdb.Publish(ctx, b.channel, message).Err()
+}
+
+// Since returns the events after id that are still in the stream, oldest first. events trimmed off the
+// stream are gone, a client that was away for longer than the stream covers misses them
+func (b *Broker) Since(ctx context.Context, id string) ([]Event, error) {
+	messages, err := b.rdb.XRangeN(ctx, b.stream, "("+id, "+", b.maxLen).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]Event, 0, len(messages))
+	for _, message := range messages {
+		eventType, _ := message.Values["type"].(string)
+		data, _ := message.Values["data"].(string)
+		events = append(events, Event{ID: message.ID, Type: eventType, Data: json.RawMessage(data)})
+	}
+
+	return events, nil
+}
+
+// Subscribe returns a channel of the events published from now on and a func to stop. the channel is
+// closed when the subscriber falls subscriberBuffer events behind or Run returns, the client should
+// reconnect with Last-Event-ID and catch up from the stream
+func (b *Broker) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+
+	b.mu.Lock()
+	if b.closed {
+		close(ch)
+	} else {
+		b.subscribers[ch] = struct{}{}
+	}
+	b.mu.Unlock()
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subscribers[ch]; ok {
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Run listens on the pub/sub channel and hands every event to this instance's subscribers until ctx is
+// cancelled, then closes them all so open streams end and the server can shut down. go-redis reconnects
+// the subscription by itself when redis goes away
+func (b *Broker) Run(ctx context.Context) {
+	pubsub := b.rdb.Subscribe(ctx, b.channel)
+	defer pubsub.Close()
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			b.close()
+			return
+		case message, ok := <-messages:
+			if !ok {
+				b.close()
+				return
+			}
+
+			var event Event
+			if err := json.Unmarshal([]byte(message.Payload), &event); err != nil {
+				log.Printf("Ignoring malformed stream event: %v", err)
+				continue
+			}
+			b.broadcast(event)
+		}
+	}
+}
+
+func (b *Broker) broadcast(event Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			// a slow client must not hold up the others
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+func (b *Broker) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for ch := range b.subscribers {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
@@ -0,0 +1,80 @@
+package sse
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestBrokerBroadcast(t *testing.T) {
+	b := NewBroker(nil, "test", 100)
+
+	first, stopFirst := b.Subscribe()
+	defer stopFirst()
+	second, stopSecond := b.Subscribe()
+
+	b.broadcast(Event{ID: "1-0", Type: EventBlogPublished})
+	for i, ch := range []<-chan Event{first, second} {
+		if event := <-ch; event.ID != "1-0" {
+			t.Fatalf("subscriber %d got %q, want 1-0", i, event.ID)
+		}
+	}
+
+	// a stopped subscriber's channel is closed and gets nothing more
+	stopSecond()
+	stopSecond()
+	b.broadcast(Event{ID: "2-0", Type: EventBlogUpdated})
+	if _, ok := <-second; ok {
+		t.Fatal("stopped subscriber still open")
+	}
+	if event := <-first; event.ID != "2-0" {
+		t.Fatalf("got %q, want 2-0", event.ID)
+	}
+}
+
+func TestBrokerDropsSlowSubscribers(t *testing.T) {
+	b := NewBroker(nil, "test", 100)
+
+	slow, stopSlow := b.Subscribe()
+	defer stopSlow()
+	fast, stopFast := b.Subscribe()
+	defer stopFast()
+
+	// one more than the buffer holds, the fast subscriber reads as it goes
+	for i := 0; i <= subscriberBuffer; i++ {
+		b.broadcast(Event{ID: fmt.Sprintf("%d-0", i+1)})
+		<-fast
+	}
+
+	// the slow one gets what was buffered, then a closed channel telling it to resume from the stream
+	got := 0
+	for range slow {
+		got++
+	}
+	if got != subscriberBuffer {
+		t.Fatalf("slow subscriber got %d events before being dropped, want %d", got, subscriberBuffer)
+	}
+
+	b.broadcast(Event{ID: "100-0"})
+	if event, ok := <-fast; !ok || event.ID != "100-0" {
+		t.Fatalf("fast subscriber got %q, %v after the slow one was dropped", event.ID, ok)
+	}
+}
+
+func TestBrokerClose(t *testing.T) {
+	b := NewBroker(nil, "test", 100)
+
+	events, stop := b.Subscribe()
+	defer stop()
+
+	b.close()
+	if _, ok := <-events; ok {
+		t.Fatal("subscriber still open after close")
+	}
+
+	// subscribing after Run returned gets a closed channel rather than one that never delivers
+	late, stopLate := b.Subscribe()
+	defer stopLate()
+	if _, ok := <-late; ok {
+		t.Fatal("late subscriber is open")
+	}
+}
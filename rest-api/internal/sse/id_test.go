@@ -0,0 +1,59 @@
+package sse
+
+import "testing"
+
+func TestValidID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{"1700000000000-0", true},
+		{"1700000000000-12", true},
+		{"0-1", true},
+		{"", false},
+		{"1700000000000", false},
+		{"-0", false},
+		{"1700000000000-", false},
+		{"abc-0", false},
+		{"1700000000000-x", false},
+		{"-1-0", false},
+		{"1700000000000-0-0", false},
+		// what XRANGE would read as special ids, never passed on
+		{"$", false},
+		{"+", false},
+		{"(1700000000000-0", false},
+		{"1700000000000-0 +", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			if got := ValidID(tt.id); got != tt.want {
+				t.Fatalf("ValidID(%q) = %v, want %v", tt.id, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAfter(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"1700000000001-0", "1700000000000-0", true},
+		{"1700000000000-1", "1700000000000-0", true},
+		{"1700000000000-0", "1700000000000-0", false},
+		{"1700000000000-0", "1700000000000-1", false},
+		{"1700000000000-9", "1700000000001-0", false},
+		// compared as numbers, not strings
+		{"1700000000000-10", "1700000000000-9", true},
+		{"10000000000000-0", "9999999999999-0", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" after "+tt.b, func(t *testing.T) {
+			if got := After(tt.a, tt.b); got != tt.want {
+				t.Fatalf("After(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/exzacter/gorestapi/internal/serverconfig"
+)
+
+// returned by Get when nothing is stored under the key
+var ErrNotFound = errors.New("blob not found")
+
+// Info describes a stored blob
+type Info struct {
+	ContentType string
+	Size        int64
+}
+
+// Blob is where uploaded files live. keys are slash separated paths such as avatars/<sha256>.png
+type Blob interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// the caller must close the returned reader
+	Get(ctx context.Context, key string) (io.ReadCloser, Info, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// Open builds the backend picked by STORAGE_DRIVER
+func Open(ctx context.Context, config *serverconfig.Config) (Blob, error) {
+	switch config.StorageDriver {
+	case "local":
+		return NewLocal(config.StorageLocalDir)
+	case "s3":
+		return NewS3(ctx, S3Options{
+			Endpoint:  config.S3Endpoint,
+			AccessKey: config.S3AccessKey,
+			SecretKey: config.S3SecretKey,
+			Bucket:    config.S3Bucket,
+			Region:    config.S3Region,
+			UseSSL:    config.S3UseSSL,
+		})
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", config.StorageDriver)
+	}
+}
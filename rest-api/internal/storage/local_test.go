@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalPutGetDelete(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	local, err := NewLocal(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := "avatars/abc123.png"
+	data := "not really a png"
+	if err := local.Put(ctx, key, strings.NewReader(data), int64(len(data)), "image/png"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	body, info, err := local.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	got, _ := io.ReadAll(body)
+	body.Close()
+
+	if string(got) != data {
+		t.Fatalf("Get returned %q, want %q", got, data)
+	}
+	// the content type comes from the extension, the file system doesn't keep it
+	if info.ContentType != "image/png" || info.Size != int64(len(data)) {
+		t.Fatalf("Info = %+v", info)
+	}
+
+	// the temp file it was written through is gone
+	entries, _ := os.ReadDir(filepath.Join(root, "avatars"))
+	if len(entries) != 1 {
+		t.Fatalf("avatars holds %d files, want just the blob", len(entries))
+	}
+
+	if err := local.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, _, err := local.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get after Delete = %v, want ErrNotFound", err)
+	}
+	// deleting what isn't there is fine
+	if err := local.Delete(ctx, key); err != nil {
+		t.Fatalf("second Delete: %v", err)
+	}
+}
+
+func TestLocalRejectsKeysOutsideRoot(t *testing.T) {
+	ctx := context.Background()
+	local, err := NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"", "/", "../escape.png", "avatars/../../escape.png", "avatars/..%2f.."} {
+		if err := local.Put(ctx, key, strings.NewReader("x"), 1, "image/png"); err == nil {
+			t.Errorf("Put(%q) succeeded, want it refused", key)
+		}
+		if _, _, err := local.Get(ctx, key); err == nil {
+			t.Errorf("Get(%q) succeeded, want an error", key)
+		}
+	}
+}
@@ -24,28 +24,540 @@ func New(db DBTX) *Queries {
 func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	q := Queries{db: db}
 	var err error
+	if q.acceptOrganizationInvitationStmt, err = db.PrepareContext(ctx, acceptOrganizationInvitation); err != nil {
+		return nil, fmt.Errorf("error preparing query AcceptOrganizationInvitation: %w", err)
+	}
+	if q.addBlogReactionStmt, err = db.PrepareContext(ctx, addBlogReaction); err != nil {
+		return nil, fmt.Errorf("error preparing query AddBlogReaction: %w", err)
+	}
+	if q.addBlogTagStmt, err = db.PrepareContext(ctx, addBlogTag); err != nil {
+		return nil, fmt.Errorf("error preparing query AddBlogTag: %w", err)
+	}
+	if q.adminListUsersStmt, err = db.PrepareContext(ctx, adminListUsers); err != nil {
+		return nil, fmt.Errorf("error preparing query AdminListUsers: %w", err)
+	}
+	if q.anonymiseMembershipEventsStmt, err = db.PrepareContext(ctx, anonymiseMembershipEvents); err != nil {
+		return nil, fmt.Errorf("error preparing query AnonymiseMembershipEvents: %w", err)
+	}
+	if q.anonymiseUserCommentsStmt, err = db.PrepareContext(ctx, anonymiseUserComments); err != nil {
+		return nil, fmt.Errorf("error preparing query AnonymiseUserComments: %w", err)
+	}
+	if q.claimDueWebhookDeliveriesStmt, err = db.PrepareContext(ctx, claimDueWebhookDeliveries); err != nil {
+		return nil, fmt.Errorf("error preparing query ClaimDueWebhookDeliveries: %w", err)
+	}
+	if q.confirmUserTotpStmt, err = db.PrepareContext(ctx, confirmUserTotp); err != nil {
+		return nil, fmt.Errorf("error preparing query ConfirmUserTotp: %w", err)
+	}
+	if q.countAvatarUsersStmt, err = db.PrepareContext(ctx, countAvatarUsers); err != nil {
+		return nil, fmt.Errorf("error preparing query CountAvatarUsers: %w", err)
+	}
+	if q.countOrganizationOwnersStmt, err = db.PrepareContext(ctx, countOrganizationOwners); err != nil {
+		return nil, fmt.Errorf("error preparing query CountOrganizationOwners: %w", err)
+	}
+	if q.countSoleOwnedOrganizationsStmt, err = db.PrepareContext(ctx, countSoleOwnedOrganizations); err != nil {
+		return nil, fmt.Errorf("error preparing query CountSoleOwnedOrganizations: %w", err)
+	}
+	if q.createApiKeyStmt, err = db.PrepareContext(ctx, createApiKey); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateApiKey: %w", err)
+	}
+	if q.createBlogStmt, err = db.PrepareContext(ctx, createBlog); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateBlog: %w", err)
+	}
+	if q.createBlogCommentStmt, err = db.PrepareContext(ctx, createBlogComment); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateBlogComment: %w", err)
+	}
+	if q.createMembershipStmt, err = db.PrepareContext(ctx, createMembership); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateMembership: %w", err)
+	}
+	if q.createMembershipEventStmt, err = db.PrepareContext(ctx, createMembershipEvent); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateMembershipEvent: %w", err)
+	}
+	if q.createOrganizationStmt, err = db.PrepareContext(ctx, createOrganization); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateOrganization: %w", err)
+	}
+	if q.createOrganizationInvitationStmt, err = db.PrepareContext(ctx, createOrganizationInvitation); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateOrganizationInvitation: %w", err)
+	}
+	if q.createRecoveryCodesStmt, err = db.PrepareContext(ctx, createRecoveryCodes); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateRecoveryCodes: %w", err)
+	}
 	if q.createUserStmt, err = db.PrepareContext(ctx, createUser); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateUser: %w", err)
 	}
+	if q.createWebhookStmt, err = db.PrepareContext(ctx, createWebhook); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateWebhook: %w", err)
+	}
+	if q.deleteBlogCommentStmt, err = db.PrepareContext(ctx, deleteBlogComment); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteBlogComment: %w", err)
+	}
+	if q.deleteBlogTagsStmt, err = db.PrepareContext(ctx, deleteBlogTags); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteBlogTags: %w", err)
+	}
+	if q.deleteInvitationsForEmailStmt, err = db.PrepareContext(ctx, deleteInvitationsForEmail); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteInvitationsForEmail: %w", err)
+	}
+	if q.deleteMembershipStmt, err = db.PrepareContext(ctx, deleteMembership); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteMembership: %w", err)
+	}
+	if q.deleteOrganizationInvitationStmt, err = db.PrepareContext(ctx, deleteOrganizationInvitation); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteOrganizationInvitation: %w", err)
+	}
+	if q.deleteRecoveryCodesStmt, err = db.PrepareContext(ctx, deleteRecoveryCodes); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteRecoveryCodes: %w", err)
+	}
+	if q.deleteSoleMemberOrganizationsStmt, err = db.PrepareContext(ctx, deleteSoleMemberOrganizations); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteSoleMemberOrganizations: %w", err)
+	}
+	if q.deleteUserApiKeysStmt, err = db.PrepareContext(ctx, deleteUserApiKeys); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteUserApiKeys: %w", err)
+	}
+	if q.deleteUserBlogsStmt, err = db.PrepareContext(ctx, deleteUserBlogs); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteUserBlogs: %w", err)
+	}
+	if q.deleteUserFollowsStmt, err = db.PrepareContext(ctx, deleteUserFollows); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteUserFollows: %w", err)
+	}
+	if q.deleteUserMembershipsStmt, err = db.PrepareContext(ctx, deleteUserMemberships); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteUserMemberships: %w", err)
+	}
+	if q.deleteUserReactionsStmt, err = db.PrepareContext(ctx, deleteUserReactions); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteUserReactions: %w", err)
+	}
+	if q.deleteUserTotpStmt, err = db.PrepareContext(ctx, deleteUserTotp); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteUserTotp: %w", err)
+	}
+	if q.deleteWebhookStmt, err = db.PrepareContext(ctx, deleteWebhook); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteWebhook: %w", err)
+	}
+	if q.disableUserStmt, err = db.PrepareContext(ctx, disableUser); err != nil {
+		return nil, fmt.Errorf("error preparing query DisableUser: %w", err)
+	}
+	if q.enableUserStmt, err = db.PrepareContext(ctx, enableUser); err != nil {
+		return nil, fmt.Errorf("error preparing query EnableUser: %w", err)
+	}
+	if q.enqueueWebhookDeliveriesStmt, err = db.PrepareContext(ctx, enqueueWebhookDeliveries); err != nil {
+		return nil, fmt.Errorf("error preparing query EnqueueWebhookDeliveries: %w", err)
+	}
+	if q.eraseUserStmt, err = db.PrepareContext(ctx, eraseUser); err != nil {
+		return nil, fmt.Errorf("error preparing query EraseUser: %w", err)
+	}
+	if q.exportUserBlogsStmt, err = db.PrepareContext(ctx, exportUserBlogs); err != nil {
+		return nil, fmt.Errorf("error preparing query ExportUserBlogs: %w", err)
+	}
+	if q.exportUserCommentsStmt, err = db.PrepareContext(ctx, exportUserComments); err != nil {
+		return nil, fmt.Errorf("error preparing query ExportUserComments: %w", err)
+	}
+	if q.exportUserFollowsStmt, err = db.PrepareContext(ctx, exportUserFollows); err != nil {
+		return nil, fmt.Errorf("error preparing query ExportUserFollows: %w", err)
+	}
+	if q.exportUserMembershipEventsStmt, err = db.PrepareContext(ctx, exportUserMembershipEvents); err != nil {
+		return nil, fmt.Errorf("error preparing query ExportUserMembershipEvents: %w", err)
+	}
+	if q.followUserStmt, err = db.PrepareContext(ctx, followUser); err != nil {
+		return nil, fmt.Errorf("error preparing query FollowUser: %w", err)
+	}
+	if q.getApiKeyByPrefixStmt, err = db.PrepareContext(ctx, getApiKeyByPrefix); err != nil {
+		return nil, fmt.Errorf("error preparing query GetApiKeyByPrefix: %w", err)
+	}
+	if q.getBlogStmt, err = db.PrepareContext(ctx, getBlog); err != nil {
+		return nil, fmt.Errorf("error preparing query GetBlog: %w", err)
+	}
+	if q.getBlogCommentStmt, err = db.PrepareContext(ctx, getBlogComment); err != nil {
+		return nil, fmt.Errorf("error preparing query GetBlogComment: %w", err)
+	}
+	if q.getFollowCountsStmt, err = db.PrepareContext(ctx, getFollowCounts); err != nil {
+		return nil, fmt.Errorf("error preparing query GetFollowCounts: %w", err)
+	}
+	if q.getMembershipRoleStmt, err = db.PrepareContext(ctx, getMembershipRole); err != nil {
+		return nil, fmt.Errorf("error preparing query GetMembershipRole: %w", err)
+	}
+	if q.getOrganizationStmt, err = db.PrepareContext(ctx, getOrganization); err != nil {
+		return nil, fmt.Errorf("error preparing query GetOrganization: %w", err)
+	}
 	if q.getUserStmt, err = db.PrepareContext(ctx, getUser); err != nil {
 		return nil, fmt.Errorf("error preparing query GetUser: %w", err)
 	}
 	if q.getUserByUsernameOrEmailStmt, err = db.PrepareContext(ctx, getUserByUsernameOrEmail); err != nil {
 		return nil, fmt.Errorf("error preparing query GetUserByUsernameOrEmail: %w", err)
 	}
+	if q.getUserRoleStmt, err = db.PrepareContext(ctx, getUserRole); err != nil {
+		return nil, fmt.Errorf("error preparing query GetUserRole: %w", err)
+	}
+	if q.getUserTotpStmt, err = db.PrepareContext(ctx, getUserTotp); err != nil {
+		return nil, fmt.Errorf("error preparing query GetUserTotp: %w", err)
+	}
+	if q.listApiKeysStmt, err = db.PrepareContext(ctx, listApiKeys); err != nil {
+		return nil, fmt.Errorf("error preparing query ListApiKeys: %w", err)
+	}
+	if q.listBlogCommentsStmt, err = db.PrepareContext(ctx, listBlogComments); err != nil {
+		return nil, fmt.Errorf("error preparing query ListBlogComments: %w", err)
+	}
+	if q.listBlogsStmt, err = db.PrepareContext(ctx, listBlogs); err != nil {
+		return nil, fmt.Errorf("error preparing query ListBlogs: %w", err)
+	}
+	if q.listBlogsByAuthorStmt, err = db.PrepareContext(ctx, listBlogsByAuthor); err != nil {
+		return nil, fmt.Errorf("error preparing query ListBlogsByAuthor: %w", err)
+	}
+	if q.listFeedBlogsStmt, err = db.PrepareContext(ctx, listFeedBlogs); err != nil {
+		return nil, fmt.Errorf("error preparing query ListFeedBlogs: %w", err)
+	}
+	if q.listFeedEntriesStmt, err = db.PrepareContext(ctx, listFeedEntries); err != nil {
+		return nil, fmt.Errorf("error preparing query ListFeedEntries: %w", err)
+	}
+	if q.listFollowersStmt, err = db.PrepareContext(ctx, listFollowers); err != nil {
+		return nil, fmt.Errorf("error preparing query ListFollowers: %w", err)
+	}
+	if q.listFollowingStmt, err = db.PrepareContext(ctx, listFollowing); err != nil {
+		return nil, fmt.Errorf("error preparing query ListFollowing: %w", err)
+	}
+	if q.listHeavyFollowersStmt, err = db.PrepareContext(ctx, listHeavyFollowers); err != nil {
+		return nil, fmt.Errorf("error preparing query ListHeavyFollowers: %w", err)
+	}
+	if q.listMembershipEventsStmt, err = db.PrepareContext(ctx, listMembershipEvents); err != nil {
+		return nil, fmt.Errorf("error preparing query ListMembershipEvents: %w", err)
+	}
+	if q.listMembershipsStmt, err = db.PrepareContext(ctx, listMemberships); err != nil {
+		return nil, fmt.Errorf("error preparing query ListMemberships: %w", err)
+	}
+	if q.listOrganizationBlogsStmt, err = db.PrepareContext(ctx, listOrganizationBlogs); err != nil {
+		return nil, fmt.Errorf("error preparing query ListOrganizationBlogs: %w", err)
+	}
+	if q.listOrganizationInvitationsStmt, err = db.PrepareContext(ctx, listOrganizationInvitations); err != nil {
+		return nil, fmt.Errorf("error preparing query ListOrganizationInvitations: %w", err)
+	}
+	if q.listTagsStmt, err = db.PrepareContext(ctx, listTags); err != nil {
+		return nil, fmt.Errorf("error preparing query ListTags: %w", err)
+	}
+	if q.listUserOrganizationsStmt, err = db.PrepareContext(ctx, listUserOrganizations); err != nil {
+		return nil, fmt.Errorf("error preparing query ListUserOrganizations: %w", err)
+	}
 	if q.listUsersStmt, err = db.PrepareContext(ctx, listUsers); err != nil {
 		return nil, fmt.Errorf("error preparing query ListUsers: %w", err)
 	}
+	if q.listUsersByEmailStmt, err = db.PrepareContext(ctx, listUsersByEmail); err != nil {
+		return nil, fmt.Errorf("error preparing query ListUsersByEmail: %w", err)
+	}
+	if q.listWebhookDeliveriesStmt, err = db.PrepareContext(ctx, listWebhookDeliveries); err != nil {
+		return nil, fmt.Errorf("error preparing query ListWebhookDeliveries: %w", err)
+	}
+	if q.listWebhooksStmt, err = db.PrepareContext(ctx, listWebhooks); err != nil {
+		return nil, fmt.Errorf("error preparing query ListWebhooks: %w", err)
+	}
+	if q.lockOrganizationStmt, err = db.PrepareContext(ctx, lockOrganization); err != nil {
+		return nil, fmt.Errorf("error preparing query LockOrganization: %w", err)
+	}
+	if q.markWebhookDeliveryFailedStmt, err = db.PrepareContext(ctx, markWebhookDeliveryFailed); err != nil {
+		return nil, fmt.Errorf("error preparing query MarkWebhookDeliveryFailed: %w", err)
+	}
+	if q.markWebhookDeliverySucceededStmt, err = db.PrepareContext(ctx, markWebhookDeliverySucceeded); err != nil {
+		return nil, fmt.Errorf("error preparing query MarkWebhookDeliverySucceeded: %w", err)
+	}
+	if q.publishDueBlogsStmt, err = db.PrepareContext(ctx, publishDueBlogs); err != nil {
+		return nil, fmt.Errorf("error preparing query PublishDueBlogs: %w", err)
+	}
+	if q.redeliverWebhookDeliveryStmt, err = db.PrepareContext(ctx, redeliverWebhookDelivery); err != nil {
+		return nil, fmt.Errorf("error preparing query RedeliverWebhookDelivery: %w", err)
+	}
+	if q.removeBlogReactionStmt, err = db.PrepareContext(ctx, removeBlogReaction); err != nil {
+		return nil, fmt.Errorf("error preparing query RemoveBlogReaction: %w", err)
+	}
+	if q.revokeApiKeyStmt, err = db.PrepareContext(ctx, revokeApiKey); err != nil {
+		return nil, fmt.Errorf("error preparing query RevokeApiKey: %w", err)
+	}
+	if q.revokeUserApiKeysStmt, err = db.PrepareContext(ctx, revokeUserApiKeys); err != nil {
+		return nil, fmt.Errorf("error preparing query RevokeUserApiKeys: %w", err)
+	}
+	if q.searchBlogsStmt, err = db.PrepareContext(ctx, searchBlogs); err != nil {
+		return nil, fmt.Errorf("error preparing query SearchBlogs: %w", err)
+	}
+	if q.setUserRoleStmt, err = db.PrepareContext(ctx, setUserRole); err != nil {
+		return nil, fmt.Errorf("error preparing query SetUserRole: %w", err)
+	}
+	if q.touchApiKeyStmt, err = db.PrepareContext(ctx, touchApiKey); err != nil {
+		return nil, fmt.Errorf("error preparing query TouchApiKey: %w", err)
+	}
+	if q.unfollowUserStmt, err = db.PrepareContext(ctx, unfollowUser); err != nil {
+		return nil, fmt.Errorf("error preparing query UnfollowUser: %w", err)
+	}
+	if q.updateBlogStmt, err = db.PrepareContext(ctx, updateBlog); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateBlog: %w", err)
+	}
+	if q.updateBlogCommentStmt, err = db.PrepareContext(ctx, updateBlogComment); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateBlogComment: %w", err)
+	}
+	if q.updateMembershipRoleStmt, err = db.PrepareContext(ctx, updateMembershipRole); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateMembershipRole: %w", err)
+	}
+	if q.updateUserAvatarStmt, err = db.PrepareContext(ctx, updateUserAvatar); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateUserAvatar: %w", err)
+	}
+	if q.upgradePasswordHashStmt, err = db.PrepareContext(ctx, upgradePasswordHash); err != nil {
+		return nil, fmt.Errorf("error preparing query UpgradePasswordHash: %w", err)
+	}
+	if q.upsertTagStmt, err = db.PrepareContext(ctx, upsertTag); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertTag: %w", err)
+	}
+	if q.upsertUserTotpStmt, err = db.PrepareContext(ctx, upsertUserTotp); err != nil {
+		return nil, fmt.Errorf("error preparing query UpsertUserTotp: %w", err)
+	}
+	if q.useRecoveryCodeStmt, err = db.PrepareContext(ctx, useRecoveryCode); err != nil {
+		return nil, fmt.Errorf("error preparing query UseRecoveryCode: %w", err)
+	}
 	return &q, nil
 }
 
 func (q *Queries) Close() error {
 	var err error
+	if q.acceptOrganizationInvitationStmt != nil {
+		if cerr := q.acceptOrganizationInvitationStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing acceptOrganizationInvitationStmt: %w", cerr)
+		}
+	}
+	if q.addBlogReactionStmt != nil {
+		if cerr := q.addBlogReactionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing addBlogReactionStmt: %w", cerr)
+		}
+	}
+	if q.addBlogTagStmt != nil {
+		if cerr := q.addBlogTagStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing addBlogTagStmt: %w", cerr)
+		}
+	}
+	if q.adminListUsersStmt != nil {
+		if cerr := q.adminListUsersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing adminListUsersStmt: %w", cerr)
+		}
+	}
+	if q.anonymiseMembershipEventsStmt != nil {
+		if cerr := q.anonymiseMembershipEventsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing anonymiseMembershipEventsStmt: %w", cerr)
+		}
+	}
+	if q.anonymiseUserCommentsStmt != nil {
+		if cerr := q.anonymiseUserCommentsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing anonymiseUserCommentsStmt: %w", cerr)
+		}
+	}
+	if q.claimDueWebhookDeliveriesStmt != nil {
+		if cerr := q.claimDueWebhookDeliveriesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing claimDueWebhookDeliveriesStmt: %w", cerr)
+		}
+	}
+	if q.confirmUserTotpStmt != nil {
+		if cerr := q.confirmUserTotpStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing confirmUserTotpStmt: %w", cerr)
+		}
+	}
+	if q.countAvatarUsersStmt != nil {
+		if cerr := q.countAvatarUsersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countAvatarUsersStmt: %w", cerr)
+		}
+	}
+	if q.countOrganizationOwnersStmt != nil {
+		if cerr := q.countOrganizationOwnersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countOrganizationOwnersStmt: %w", cerr)
+		}
+	}
+	if q.countSoleOwnedOrganizationsStmt != nil {
+		if cerr := q.countSoleOwnedOrganizationsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing countSoleOwnedOrganizationsStmt: %w", cerr)
+		}
+	}
+	if q.createApiKeyStmt != nil {
+		if cerr := q.createApiKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createApiKeyStmt: %w", cerr)
+		}
+	}
+	if q.createBlogStmt != nil {
+		if cerr := q.createBlogStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createBlogStmt: %w", cerr)
+		}
+	}
+	if q.createBlogCommentStmt != nil {
+		if cerr := q.createBlogCommentStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createBlogCommentStmt: %w", cerr)
+		}
+	}
+	if q.createMembershipStmt != nil {
+		if cerr := q.createMembershipStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createMembershipStmt: %w", cerr)
+		}
+	}
+	if q.createMembershipEventStmt != nil {
+		if cerr := q.createMembershipEventStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createMembershipEventStmt: %w", cerr)
+		}
+	}
+	if q.createOrganizationStmt != nil {
+		if cerr := q.createOrganizationStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createOrganizationStmt: %w", cerr)
+		}
+	}
+	if q.createOrganizationInvitationStmt != nil {
+		if cerr := q.createOrganizationInvitationStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createOrganizationInvitationStmt: %w", cerr)
+		}
+	}
+	if q.createRecoveryCodesStmt != nil {
+		if cerr := q.createRecoveryCodesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createRecoveryCodesStmt: %w", cerr)
+		}
+	}
 	if q.createUserStmt != nil {
 		if cerr := q.createUserStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createUserStmt: %w", cerr)
 		}
 	}
+	if q.createWebhookStmt != nil {
+		if cerr := q.createWebhookStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createWebhookStmt: %w", cerr)
+		}
+	}
+	if q.deleteBlogCommentStmt != nil {
+		if cerr := q.deleteBlogCommentStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteBlogCommentStmt: %w", cerr)
+		}
+	}
+	if q.deleteBlogTagsStmt != nil {
+		if cerr := q.deleteBlogTagsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteBlogTagsStmt: %w", cerr)
+		}
+	}
+	if q.deleteInvitationsForEmailStmt != nil {
+		if cerr := q.deleteInvitationsForEmailStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteInvitationsForEmailStmt: %w", cerr)
+		}
+	}
+	if q.deleteMembershipStmt != nil {
+		if cerr := q.deleteMembershipStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteMembershipStmt: %w", cerr)
+		}
+	}
+	if q.deleteOrganizationInvitationStmt != nil {
+		if cerr := q.deleteOrganizationInvitationStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteOrganizationInvitationStmt: %w", cerr)
+		}
+	}
+	if q.deleteRecoveryCodesStmt != nil {
+		if cerr := q.deleteRecoveryCodesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteRecoveryCodesStmt: %w", cerr)
+		}
+	}
+	if q.deleteSoleMemberOrganizationsStmt != nil {
+		if cerr := q.deleteSoleMemberOrganizationsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteSoleMemberOrganizationsStmt: %w", cerr)
+		}
+	}
+	if q.deleteUserApiKeysStmt != nil {
+		if cerr := q.deleteUserApiKeysStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteUserApiKeysStmt: %w", cerr)
+		}
+	}
+	if q.deleteUserBlogsStmt != nil {
+		if cerr := q.deleteUserBlogsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteUserBlogsStmt: %w", cerr)
+		}
+	}
+	if q.deleteUserFollowsStmt != nil {
+		if cerr := q.deleteUserFollowsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteUserFollowsStmt: %w", cerr)
+		}
+	}
+	if q.deleteUserMembershipsStmt != nil {
+		if cerr := q.deleteUserMembershipsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteUserMembershipsStmt: %w", cerr)
+		}
+	}
+	if q.deleteUserReactionsStmt != nil {
+		if cerr := q.deleteUserReactionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteUserReactionsStmt: %w", cerr)
+		}
+	}
+	if q.deleteUserTotpStmt != nil {
+		if cerr := q.deleteUserTotpStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteUserTotpStmt: %w", cerr)
+		}
+	}
+	if q.deleteWebhookStmt != nil {
+		if cerr := q.deleteWebhookStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteWebhookStmt: %w", cerr)
+		}
+	}
+	if q.disableUserStmt != nil {
+		if cerr := q.disableUserStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing disableUserStmt: %w", cerr)
+		}
+	}
+	if q.enableUserStmt != nil {
+		if cerr := q.enableUserStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing enableUserStmt: %w", cerr)
+		}
+	}
+	if q.enqueueWebhookDeliveriesStmt != nil {
+		if cerr := q.enqueueWebhookDeliveriesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing enqueueWebhookDeliveriesStmt: %w", cerr)
+		}
+	}
+	if q.eraseUserStmt != nil {
+		if cerr := q.eraseUserStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing eraseUserStmt: %w", cerr)
+		}
+	}
+	if q.exportUserBlogsStmt != nil {
+		if cerr := q.exportUserBlogsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing exportUserBlogsStmt: %w", cerr)
+		}
+	}
+	if q.exportUserCommentsStmt != nil {
+		if cerr := q.exportUserCommentsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing exportUserCommentsStmt: %w", cerr)
+		}
+	}
+	if q.exportUserFollowsStmt != nil {
+		if cerr := q.exportUserFollowsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing exportUserFollowsStmt: %w", cerr)
+		}
+	}
+	if q.exportUserMembershipEventsStmt != nil {
+		if cerr := q.exportUserMembershipEventsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing exportUserMembershipEventsStmt: %w", cerr)
+		}
+	}
+	if q.followUserStmt != nil {
+		if cerr := q.followUserStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing followUserStmt: %w", cerr)
+		}
+	}
+	if q.getApiKeyByPrefixStmt != nil {
+		if cerr := q.getApiKeyByPrefixStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getApiKeyByPrefixStmt: %w", cerr)
+		}
+	}
+	if q.getBlogStmt != nil {
+		if cerr := q.getBlogStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getBlogStmt: %w", cerr)
+		}
+	}
+	if q.getBlogCommentStmt != nil {
+		if cerr := q.getBlogCommentStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getBlogCommentStmt: %w", cerr)
+		}
+	}
+	if q.getFollowCountsStmt != nil {
+		if cerr := q.getFollowCountsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getFollowCountsStmt: %w", cerr)
+		}
+	}
+	if q.getMembershipRoleStmt != nil {
+		if cerr := q.getMembershipRoleStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getMembershipRoleStmt: %w", cerr)
+		}
+	}
+	if q.getOrganizationStmt != nil {
+		if cerr := q.getOrganizationStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getOrganizationStmt: %w", cerr)
+		}
+	}
 	if q.getUserStmt != nil {
 		if cerr := q.getUserStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getUserStmt: %w", cerr)
@@ -56,11 +568,211 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing getUserByUsernameOrEmailStmt: %w", cerr)
 		}
 	}
+	if q.getUserRoleStmt != nil {
+		if cerr := q.getUserRoleStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getUserRoleStmt: %w", cerr)
+		}
+	}
+	if q.getUserTotpStmt != nil {
+		if cerr := q.getUserTotpStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getUserTotpStmt: %w", cerr)
+		}
+	}
+	if q.listApiKeysStmt != nil {
+		if cerr := q.listApiKeysStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listApiKeysStmt: %w", cerr)
+		}
+	}
+	if q.listBlogCommentsStmt != nil {
+		if cerr := q.listBlogCommentsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listBlogCommentsStmt: %w", cerr)
+		}
+	}
+	if q.listBlogsStmt != nil {
+		if cerr := q.listBlogsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listBlogsStmt: %w", cerr)
+		}
+	}
+	if q.listBlogsByAuthorStmt != nil {
+		if cerr := q.listBlogsByAuthorStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listBlogsByAuthorStmt: %w", cerr)
+		}
+	}
+	if q.listFeedBlogsStmt != nil {
+		if cerr := q.listFeedBlogsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listFeedBlogsStmt: %w", cerr)
+		}
+	}
+	if q.listFeedEntriesStmt != nil {
+		if cerr := q.listFeedEntriesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listFeedEntriesStmt: %w", cerr)
+		}
+	}
+	if q.listFollowersStmt != nil {
+		if cerr := q.listFollowersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listFollowersStmt: %w", cerr)
+		}
+	}
+	if q.listFollowingStmt != nil {
+		if cerr := q.listFollowingStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listFollowingStmt: %w", cerr)
+		}
+	}
+	if q.listHeavyFollowersStmt != nil {
+		if cerr := q.listHeavyFollowersStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listHeavyFollowersStmt: %w", cerr)
+		}
+	}
+	if q.listMembershipEventsStmt != nil {
+		if cerr := q.listMembershipEventsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listMembershipEventsStmt: %w", cerr)
+		}
+	}
+	if q.listMembershipsStmt != nil {
+		if cerr := q.listMembershipsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listMembershipsStmt: %w", cerr)
+		}
+	}
+	if q.listOrganizationBlogsStmt != nil {
+		if cerr := q.listOrganizationBlogsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listOrganizationBlogsStmt: %w", cerr)
+		}
+	}
+	if q.listOrganizationInvitationsStmt != nil {
+		if cerr := q.listOrganizationInvitationsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listOrganizationInvitationsStmt: %w", cerr)
+		}
+	}
+	if q.listTagsStmt != nil {
+		if cerr := q.listTagsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listTagsStmt: %w", cerr)
+		}
+	}
+	if q.listUserOrganizationsStmt != nil {
+		if cerr := q.listUserOrganizationsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listUserOrganizationsStmt: %w", cerr)
+		}
+	}
 	if q.listUsersStmt != nil {
 		if cerr := q.listUsersStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing listUsersStmt: %w", cerr)
 		}
 	}
+	if q.listUsersByEmailStmt != nil {
+		if cerr := q.listUsersByEmailStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listUsersByEmailStmt: %w", cerr)
+		}
+	}
+	if q.listWebhookDeliveriesStmt != nil {
+		if cerr := q.listWebhookDeliveriesStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listWebhookDeliveriesStmt: %w", cerr)
+		}
+	}
+	if q.listWebhooksStmt != nil {
+		if cerr := q.listWebhooksStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing listWebhooksStmt: %w", cerr)
+		}
+	}
+	if q.lockOrganizationStmt != nil {
+		if cerr := q.lockOrganizationStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing lockOrganizationStmt: %w", cerr)
+		}
+	}
+	if q.markWebhookDeliveryFailedStmt != nil {
+		if cerr := q.markWebhookDeliveryFailedStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing markWebhookDeliveryFailedStmt: %w", cerr)
+		}
+	}
+	if q.markWebhookDeliverySucceededStmt != nil {
+		if cerr := q.markWebhookDeliverySucceededStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing markWebhookDeliverySucceededStmt: %w", cerr)
+		}
+	}
+	if q.publishDueBlogsStmt != nil {
+		if cerr := q.publishDueBlogsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing publishDueBlogsStmt: %w", cerr)
+		}
+	}
+	if q.redeliverWebhookDeliveryStmt != nil {
+		if cerr := q.redeliverWebhookDeliveryStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing redeliverWebhookDeliveryStmt: %w", cerr)
+		}
+	}
+	if q.removeBlogReactionStmt != nil {
+		if cerr := q.removeBlogReactionStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing removeBlogReactionStmt: %w", cerr)
+		}
+	}
+	if q.revokeApiKeyStmt != nil {
+		if cerr := q.revokeApiKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing revokeApiKeyStmt: %w", cerr)
+		}
+	}
+	if q.revokeUserApiKeysStmt != nil {
+		if cerr := q.revokeUserApiKeysStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing revokeUserApiKeysStmt: %w", cerr)
+		}
+	}
+	if q.searchBlogsStmt != nil {
+		if cerr := q.searchBlogsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing searchBlogsStmt: %w", cerr)
+		}
+	}
+	if q.setUserRoleStmt != nil {
+		if cerr := q.setUserRoleStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing setUserRoleStmt: %w", cerr)
+		}
+	}
+	if q.touchApiKeyStmt != nil {
+		if cerr := q.touchApiKeyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing touchApiKeyStmt: %w", cerr)
+		}
+	}
+	if q.unfollowUserStmt != nil {
+		if cerr := q.unfollowUserStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing unfollowUserStmt: %w", cerr)
+		}
+	}
+	if q.updateBlogStmt != nil {
+		if cerr := q.updateBlogStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateBlogStmt: %w", cerr)
+		}
+	}
+	if q.updateBlogCommentStmt != nil {
+		if cerr := q.updateBlogCommentStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateBlogCommentStmt: %w", cerr)
+		}
+	}
+	if q.updateMembershipRoleStmt != nil {
+		if cerr := q.updateMembershipRoleStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateMembershipRoleStmt: %w", cerr)
+		}
+	}
+	if q.updateUserAvatarStmt != nil {
+		if cerr := q.updateUserAvatarStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateUserAvatarStmt: %w", cerr)
+		}
+	}
+	if q.upgradePasswordHashStmt != nil {
+		if cerr := q.upgradePasswordHashStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upgradePasswordHashStmt: %w", cerr)
+		}
+	}
+	if q.upsertTagStmt != nil {
+		if cerr := q.upsertTagStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertTagStmt: %w", cerr)
+		}
+	}
+	if q.upsertUserTotpStmt != nil {
+		if cerr := q.upsertUserTotpStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing upsertUserTotpStmt: %w", cerr)
+		}
+	}
+	if q.useRecoveryCodeStmt != nil {
+		if cerr := q.useRecoveryCodeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing useRecoveryCodeStmt: %w", cerr)
+		}
+	}
 	return err
 }
 
@@ -98,21 +810,199 @@ func (q *Queries) queryRow(ctx context.Context, stmt *sql.Stmt, query string, ar
 }
 
 type Queries struct {
-	db                           DBTX
-	tx                           *sql.Tx
-	createUserStmt               *sql.Stmt
-	getUserStmt                  *sql.Stmt
-	getUserByUsernameOrEmailStmt *sql.Stmt
-	listUsersStmt                *sql.Stmt
+	db                                DBTX
+	tx                                *sql.Tx
+	acceptOrganizationInvitationStmt  *sql.Stmt
+	addBlogReactionStmt               *sql.Stmt
+	addBlogTagStmt                    *sql.Stmt
+	adminListUsersStmt                *sql.Stmt
+	anonymiseMembershipEventsStmt     *sql.Stmt
+	anonymiseUserCommentsStmt         *sql.Stmt
+	claimDueWebhookDeliveriesStmt     *sql.Stmt
+	confirmUserTotpStmt               *sql.Stmt
+	countAvatarUsersStmt              *sql.Stmt
+	countOrganizationOwnersStmt       *sql.Stmt
+	countSoleOwnedOrganizationsStmt   *sql.Stmt
+	createApiKeyStmt                  *sql.Stmt
+	createBlogStmt                    *sql.Stmt
+	createBlogCommentStmt             *sql.Stmt
+	createMembershipStmt              *sql.Stmt
+	createMembershipEventStmt         *sql.Stmt
+	createOrganizationStmt            *sql.Stmt
+	createOrganizationInvitationStmt  *sql.Stmt
+	createRecoveryCodesStmt           *sql.Stmt
+	createUserStmt                    *sql.Stmt
+	createWebhookStmt                 *sql.Stmt
+	deleteBlogCommentStmt             *sql.Stmt
+	deleteBlogTagsStmt                *sql.Stmt
+	deleteInvitationsForEmailStmt     *sql.Stmt
+	deleteMembershipStmt              *sql.Stmt
+	deleteOrganizationInvitationStmt  *sql.Stmt
+	deleteRecoveryCodesStmt           *sql.Stmt
+	deleteSoleMemberOrganizationsStmt *sql.Stmt
+	deleteUserApiKeysStmt             *sql.Stmt
+	deleteUserBlogsStmt               *sql.Stmt
+	deleteUserFollowsStmt             *sql.Stmt
+	deleteUserMembershipsStmt         *sql.Stmt
+	deleteUserReactionsStmt           *sql.Stmt
+	deleteUserTotpStmt                *sql.Stmt
+	deleteWebhookStmt                 *sql.Stmt
+	disableUserStmt                   *sql.Stmt
+	enableUserStmt                    *sql.Stmt
+	enqueueWebhookDeliveriesStmt      *sql.Stmt
+	eraseUserStmt                     *sql.Stmt
+	exportUserBlogsStmt               *sql.Stmt
+	exportUserCommentsStmt            *sql.Stmt
+	exportUserFollowsStmt             *sql.Stmt
+	exportUserMembershipEventsStmt    *sql.Stmt
+	followUserStmt                    *sql.Stmt
+	getApiKeyByPrefixStmt             *sql.Stmt
+	getBlogStmt                       *sql.Stmt
+	getBlogCommentStmt                *sql.Stmt
+	getFollowCountsStmt               *sql.Stmt
+	getMembershipRoleStmt             *sql.Stmt
+	getOrganizationStmt               *sql.Stmt
+	getUserStmt                       *sql.Stmt
+	getUserByUsernameOrEmailStmt      *sql.Stmt
+	getUserRoleStmt                   *sql.Stmt
+	getUserTotpStmt                   *sql.Stmt
+	listApiKeysStmt                   *sql.Stmt
+	listBlogCommentsStmt              *sql.Stmt
+	listBlogsStmt                     *sql.Stmt
+	listBlogsByAuthorStmt             *sql.Stmt
+	listFeedBlogsStmt                 *sql.Stmt
+	listFeedEntriesStmt               *sql.Stmt
+	listFollowersStmt                 *sql.Stmt
+	listFollowingStmt                 *sql.Stmt
+	listHeavyFollowersStmt            *sql.Stmt
+	listMembershipEventsStmt          *sql.Stmt
+	listMembershipsStmt               *sql.Stmt
+	listOrganizationBlogsStmt         *sql.Stmt
+	listOrganizationInvitationsStmt   *sql.Stmt
+	listTagsStmt                      *sql.Stmt
+	listUserOrganizationsStmt         *sql.Stmt
+	listUsersStmt                     *sql.Stmt
+	listUsersByEmailStmt              *sql.Stmt
+	listWebhookDeliveriesStmt         *sql.Stmt
+	listWebhooksStmt                  *sql.Stmt
+	lockOrganizationStmt              *sql.Stmt
+	markWebhookDeliveryFailedStmt     *sql.Stmt
+	markWebhookDeliverySucceededStmt  *sql.Stmt
+	publishDueBlogsStmt               *sql.Stmt
+	redeliverWebhookDeliveryStmt      *sql.Stmt
+	removeBlogReactionStmt            *sql.Stmt
+	revokeApiKeyStmt                  *sql.Stmt
+	revokeUserApiKeysStmt             *sql.Stmt
+	searchBlogsStmt                   *sql.Stmt
+	setUserRoleStmt                   *sql.Stmt
+	touchApiKeyStmt                   *sql.Stmt
+	unfollowUserStmt                  *sql.Stmt
+	updateBlogStmt                    *sql.Stmt
+	updateBlogCommentStmt             *sql.Stmt
+	updateMembershipRoleStmt          *sql.Stmt
+	updateUserAvatarStmt              *sql.Stmt
+	upgradePasswordHashStmt           *sql.Stmt
+	upsertTagStmt                     *sql.Stmt
+	upsertUserTotpStmt                *sql.Stmt
+	useRecoveryCodeStmt               *sql.Stmt
 }
 
 func (q *Queries) WithTx(tx *sql.Tx) *Queries {
 	return &Queries{
-		db:                           tx,
-		tx:                           tx,
-		createUserStmt:               q.createUserStmt,
-		getUserStmt:                  q.getUserStmt,
-		getUserByUsernameOrEmailStmt: q.getUserByUsernameOrEmailStmt,
-		listUsersStmt:                q.listUsersStmt,
+		db:                                tx,
+		tx:                                tx,
+		acceptOrganizationInvitationStmt:  q.acceptOrganizationInvitationStmt,
+		addBlogReactionStmt:               q.addBlogReactionStmt,
+		addBlogTagStmt:                    q.addBlogTagStmt,
+		adminListUsersStmt:                q.adminListUsersStmt,
+		anonymiseMembershipEventsStmt:     q.anonymiseMembershipEventsStmt,
+		anonymiseUserCommentsStmt:         q.anonymiseUserCommentsStmt,
+		claimDueWebhookDeliveriesStmt:     q.claimDueWebhookDeliveriesStmt,
+		confirmUserTotpStmt:               q.confirmUserTotpStmt,
+		countAvatarUsersStmt:              q.countAvatarUsersStmt,
+		countOrganizationOwnersStmt:       q.countOrganizationOwnersStmt,
+		countSoleOwnedOrganizationsStmt:   q.countSoleOwnedOrganizationsStmt,
+		createApiKeyStmt:                  q.createApiKeyStmt,
+		createBlogStmt:                    q.createBlogStmt,
+		createBlogCommentStmt:             q.createBlogCommentStmt,
+		createMembershipStmt:              q.createMembershipStmt,
+		createMembershipEventStmt:         q.createMembershipEventStmt,
+		createOrganizationStmt:            q.createOrganizationStmt,
+		createOrganizationInvitationStmt:  q.createOrganizationInvitationStmt,
+		createRecoveryCodesStmt:           q.createRecoveryCodesStmt,
+		createUserStmt:                    q.createUserStmt,
+		createWebhookStmt:                 q.createWebhookStmt,
+		deleteBlogCommentStmt:             q.deleteBlogCommentStmt,
+		deleteBlogTagsStmt:                q.deleteBlogTagsStmt,
+		deleteInvitationsForEmailStmt:     q.deleteInvitationsForEmailStmt,
+		deleteMembershipStmt:              q.deleteMembershipStmt,
+		deleteOrganizationInvitationStmt:  q.deleteOrganizationInvitationStmt,
+		deleteRecoveryCodesStmt:           q.deleteRecoveryCodesStmt,
+		deleteSoleMemberOrganizationsStmt: q.deleteSoleMemberOrganizationsStmt,
+		deleteUserApiKeysStmt:             q.deleteUserApiKeysStmt,
+		deleteUserBlogsStmt:               q.deleteUserBlogsStmt,
+		deleteUserFollowsStmt:             q.deleteUserFollowsStmt,
+		deleteUserMembershipsStmt:         q.deleteUserMembershipsStmt,
+		deleteUserReactionsStmt:           q.deleteUserReactionsStmt,
+		deleteUserTotpStmt:                q.deleteUserTotpStmt,
+		deleteWebhookStmt:                 q.deleteWebhookStmt,
+		disableUserStmt:                   q.disableUserStmt,
+		enableUserStmt:                    q.enableUserStmt,
+		enqueueWebhookDeliveriesStmt:      q.enqueueWebhookDeliveriesStmt,
+		eraseUserStmt:                     q.eraseUserStmt,
+		exportUserBlogsStmt:               q.exportUserBlogsStmt,
+		exportUserCommentsStmt:            q.exportUserCommentsStmt,
+		exportUserFollowsStmt:             q.exportUserFollowsStmt,
+		exportUserMembershipEventsStmt:    q.exportUserMembershipEventsStmt,
+		followUserStmt:                    q.followUserStmt,
+		getApiKeyByPrefixStmt:             q.getApiKeyByPrefixStmt,
+		getBlogStmt:                       q.getBlogStmt,
+		getBlogCommentStmt:                q.getBlogCommentStmt,
+		getFollowCountsStmt:               q.getFollowCountsStmt,
+		getMembershipRoleStmt:             q.getMembershipRoleStmt,
+		getOrganizationStmt:               q.getOrganizationStmt,
+		getUserStmt:                       q.getUserStmt,
+		getUserByUsernameOrEmailStmt:      q.getUserByUsernameOrEmailStmt,
+		getUserRoleStmt:                   q.getUserRoleStmt,
+		getUserTotpStmt:                   q.getUserTotpStmt,
+		listApiKeysStmt:                   q.listApiKeysStmt,
+		listBlogCommentsStmt:              q.listBlogCommentsStmt,
+		listBlogsStmt:                     q.listBlogsStmt,
+		listBlogsByAuthorStmt:             q.listBlogsByAuthorStmt,
+		listFeedBlogsStmt:                 q.listFeedBlogsStmt,
+		listFeedEntriesStmt:               q.listFeedEntriesStmt,
+		listFollowersStmt:                 q.listFollowersStmt,
+		listFollowingStmt:                 q.listFollowingStmt,
+		listHeavyFollowersStmt:            q.listHeavyFollowersStmt,
+		listMembershipEventsStmt:          q.listMembershipEventsStmt,
+		listMembershipsStmt:               q.listMembershipsStmt,
+		listOrganizationBlogsStmt:         q.listOrganizationBlogsStmt,
+		listOrganizationInvitationsStmt:   q.listOrganizationInvitationsStmt,
+		listTagsStmt:                      q.listTagsStmt,
+		listUserOrganizationsStmt:         q.listUserOrganizationsStmt,
+		listUsersStmt:                     q.listUsersStmt,
+		listUsersByEmailStmt:              q.listUsersByEmailStmt,
+		listWebhookDeliveriesStmt:         q.listWebhookDeliveriesStmt,
+		listWebhooksStmt:                  q.listWebhooksStmt,
+		lockOrganizationStmt:              q.lockOrganizationStmt,
+		markWebhookDeliveryFailedStmt:     q.markWebhookDeliveryFailedStmt,
+		markWebhookDeliverySucceededStmt:  q.markWebhookDeliverySucceededStmt,
+		publishDueBlogsStmt:               q.publishDueBlogsStmt,
+		redeliverWebhookDeliveryStmt:      q.redeliverWebhookDeliveryStmt,
+		removeBlogReactionStmt:            q.removeBlogReactionStmt,
+		revokeApiKeyStmt:                  q.revokeApiKeyStmt,
+		revokeUserApiKeysStmt:             q.revokeUserApiKeysStmt,
+		searchBlogsStmt:                   q.searchBlogsStmt,
+		setUserRoleStmt:                   q.setUserRoleStmt,
+		touchApiKeyStmt:                   q.touchApiKeyStmt,
+		unfollowUserStmt:                  q.unfollowUserStmt,
+		updateBlogStmt:                    q.updateBlogStmt,
+		updateBlogCommentStmt:             q.updateBlogCommentStmt,
+		updateMembershipRoleStmt:          q.updateMembershipRoleStmt,
+		updateUserAvatarStmt:              q.updateUserAvatarStmt,
+		upgradePasswordHashStmt:           q.upgradePasswordHashStmt,
+		upsertTagStmt:                     q.upsertTagStmt,
+		upsertUserTotpStmt:                q.upsertUserTotpStmt,
+		useRecoveryCodeStmt:               q.useRecoveryCodeStmt,
 	}
 }
@@ -6,22 +6,162 @@ package store
 
 import (
 	"database/sql"
+	"encoding/json"
+	"time"
 )
 
+type ApiKey struct {
+	ID         int32        `json:"id"`
+	UserID     int32        `json:"user_id"`
+	Name       string       `json:"name"`
+	Prefix     string       `json:"prefix"`
+	SecretHash string       `json:"secret_hash"`
+	Scopes     []string     `json:"scopes"`
+	ExpiresAt  sql.NullTime `json:"expires_at"`
+	LastUsedAt sql.NullTime `json:"last_used_at"`
+	RevokedAt  sql.NullTime `json:"revoked_at"`
+	Created    sql.NullTime `json:"created"`
+}
+
 type Blog struct {
+	ID             int32         `json:"id"`
+	Title          string        `json:"title"`
+	Content        string        `json:"content"`
+	UserID         int32         `json:"user_id"`
+	Created        sql.NullTime  `json:"created"`
+	Updated        sql.NullTime  `json:"updated"`
+	SearchVector   interface{}   `json:"search_vector"`
+	Status         string        `json:"status"`
+	PublishAt      sql.NullTime  `json:"publish_at"`
+	Summary        string        `json:"summary"`
+	OrganizationID sql.NullInt32 `json:"organization_id"`
+}
+
+type BlogComment struct {
+	ID       int32         `json:"id"`
+	BlogID   int32         `json:"blog_id"`
+	UserID   int32         `json:"user_id"`
+	ParentID sql.NullInt32 `json:"parent_id"`
+	Content  string        `json:"content"`
+	Created  sql.NullTime  `json:"created"`
+	Updated  sql.NullTime  `json:"updated"`
+}
+
+type BlogReaction struct {
 	ID      int32        `json:"id"`
-	Title   string       `json:"title"`
-	Content string       `json:"content"`
 	UserID  int32        `json:"user_id"`
+	BlogID  int32        `json:"blog_id"`
+	Kind    string       `json:"kind"`
+	Created sql.NullTime `json:"created"`
+}
+
+type BlogTag struct {
+	BlogID int32 `json:"blog_id"`
+	TagID  int32 `json:"tag_id"`
+}
+
+type Follow struct {
+	FollowerID int32        `json:"follower_id"`
+	FolloweeID int32        `json:"followee_id"`
+	Created    sql.NullTime `json:"created"`
+}
+
+type MembershipEvent struct {
+	ID             int32          `json:"id"`
+	OrganizationID int32          `json:"organization_id"`
+	ActorID        sql.NullInt32  `json:"actor_id"`
+	UserID         sql.NullInt32  `json:"user_id"`
+	Action         string         `json:"action"`
+	Role           sql.NullString `json:"role"`
+	PreviousRole   sql.NullString `json:"previous_role"`
+	Email          sql.NullString `json:"email"`
+	Created        sql.NullTime   `json:"created"`
+}
+
+type Membership struct {
+	OrganizationID int32        `json:"organization_id"`
+	UserID         int32        `json:"user_id"`
+	Role           string       `json:"role"`
+	Created        sql.NullTime `json:"created"`
+	Updated        sql.NullTime `json:"updated"`
+}
+
+type OrganizationInvitation struct {
+	ID             int32         `json:"id"`
+	OrganizationID int32         `json:"organization_id"`
+	Email          string        `json:"email"`
+	Role           string        `json:"role"`
+	TokenHash      string        `json:"token_hash"`
+	InvitedBy      sql.NullInt32 `json:"invited_by"`
+	ExpiresAt      time.Time     `json:"expires_at"`
+	AcceptedAt     sql.NullTime  `json:"accepted_at"`
+	Created        sql.NullTime  `json:"created"`
+}
+
+type Organization struct {
+	ID      int32        `json:"id"`
+	Name    string       `json:"name"`
+	Slug    string       `json:"slug"`
 	Created sql.NullTime `json:"created"`
 	Updated sql.NullTime `json:"updated"`
 }
 
-type User struct {
+type Tag struct {
+	ID      int32        `json:"id"`
+	Name    string       `json:"name"`
+	Slug    string       `json:"slug"`
+	Created sql.NullTime `json:"created"`
+}
+
+type UserRecoveryCode struct {
 	ID       int32        `json:"id"`
-	Username string       `json:"username"`
-	Email    string       `json:"email"`
-	Password string       `json:"password"`
+	UserID   int32        `json:"user_id"`
+	CodeHash string       `json:"code_hash"`
+	UsedAt   sql.NullTime `json:"used_at"`
 	Created  sql.NullTime `json:"created"`
-	Updated  sql.NullTime `json:"updated"`
+}
+
+type UserTotp struct {
+	UserID      int32        `json:"user_id"`
+	Secret      string       `json:"secret"`
+	ConfirmedAt sql.NullTime `json:"confirmed_at"`
+	Created     sql.NullTime `json:"created"`
+}
+
+type User struct {
+	ID         int32          `json:"id"`
+	Username   string         `json:"username"`
+	Email      string         `json:"email"`
+	Password   string         `json:"password"`
+	Created    sql.NullTime   `json:"created"`
+	Updated    sql.NullTime   `json:"updated"`
+	Role       string         `json:"role"`
+	AvatarKey  sql.NullString `json:"avatar_key"`
+	DisabledAt sql.NullTime   `json:"disabled_at"`
+}
+
+type Webhook struct {
+	ID      int32        `json:"id"`
+	UserID  int32        `json:"user_id"`
+	Url     string       `json:"url"`
+	Secret  string       `json:"secret"`
+	Events  []string     `json:"events"`
+	Active  bool         `json:"active"`
+	Created sql.NullTime `json:"created"`
+	Updated sql.NullTime `json:"updated"`
+}
+
+type WebhookDelivery struct {
+	ID             int32           `json:"id"`
+	WebhookID      int32           `json:"webhook_id"`
+	Event          string          `json:"event"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int32           `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	LastStatusCode sql.NullInt32   `json:"last_status_code"`
+	LastError      sql.NullString  `json:"last_error"`
+	DeliveredAt    sql.NullTime    `json:"delivered_at"`
+	Created        sql.NullTime    `json:"created"`
+	Updated        sql.NullTime    `json:"updated"`
 }
@@ -8,11 +8,562 @@ package store
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const acceptOrganizationInvitation = `-- name: AcceptOrganizationInvitation :one
+UPDATE organization_invitations
+SET accepted_at = CURRENT_TIMESTAMP
+WHERE token_hash = $1
+	AND lower(email) = lower($2)
+	AND accepted_at IS NULL
+	AND expires_at > CURRENT_TIMESTAMP
+RETURNING id, organization_id, email, role
+`
+
+type AcceptOrganizationInvitationParams struct {
+	TokenHash string `json:"token_hash"`
+	Email     string `json:"email"`
+}
+
+type AcceptOrganizationInvitationRow struct {
+	ID             int32  `json:"id"`
+	OrganizationID int32  `json:"organization_id"`
+	Email          string `json:"email"`
+	Role           string `json:"role"`
+}
+
+// an invitation works once, before it expires and only for the address it was sent to
+func (q *Queries) AcceptOrganizationInvitation(ctx context.Context, arg AcceptOrganizationInvitationParams) (AcceptOrganizationInvitationRow, error) {
+	row := q.queryRow(ctx, q.acceptOrganizationInvitationStmt, acceptOrganizationInvitation, arg.TokenHash, arg.Email)
+	var i AcceptOrganizationInvitationRow
+	err := row.Scan(
+		&i.ID,
+		&i.OrganizationID,
+		&i.Email,
+		&i.Role,
+	)
+	return i, err
+}
+
+const addBlogReaction = `-- name: AddBlogReaction :exec
+INSERT INTO blog_reactions(user_id, blog_id, kind)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, blog_id, kind) DO NOTHING
+`
+
+type AddBlogReactionParams struct {
+	UserID int32  `json:"user_id"`
+	BlogID int32  `json:"blog_id"`
+	Kind   string `json:"kind"`
+}
+
+func (q *Queries) AddBlogReaction(ctx context.Context, arg AddBlogReactionParams) error {
+	_, err := q.exec(ctx, q.addBlogReactionStmt, addBlogReaction, arg.UserID, arg.BlogID, arg.Kind)
+	return err
+}
+
+const addBlogTag = `-- name: AddBlogTag :exec
+INSERT INTO blog_tags(blog_id, tag_id)
+VALUES ($1, $2)
+ON CONFLICT (blog_id, tag_id) DO NOTHING
+`
+
+type AddBlogTagParams struct {
+	BlogID int32 `json:"blog_id"`
+	TagID  int32 `json:"tag_id"`
+}
+
+func (q *Queries) AddBlogTag(ctx context.Context, arg AddBlogTagParams) error {
+	_, err := q.exec(ctx, q.addBlogTagStmt, addBlogTag, arg.BlogID, arg.TagID)
+	return err
+}
+
+const adminListUsers = `-- name: AdminListUsers :many
+SELECT id, username, email, role, disabled_at, created
+FROM users
+ORDER BY id
+LIMIT $1 OFFSET $2
+`
+
+type AdminListUsersParams struct {
+	Limit  int32 `json:"limit"`
+	Offset int32 `json:"offset"`
+}
+
+type AdminListUsersRow struct {
+	ID         int32        `json:"id"`
+	Username   string       `json:"username"`
+	Email      string       `json:"email"`
+	Role       string       `json:"role"`
+	DisabledAt sql.NullTime `json:"disabled_at"`
+	Created    sql.NullTime `json:"created"`
+}
+
+func (q *Queries) AdminListUsers(ctx context.Context, arg AdminListUsersParams) ([]AdminListUsersRow, error) {
+	rows, err := q.query(ctx, q.adminListUsersStmt, adminListUsers, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AdminListUsersRow{}
+	for rows.Next() {
+		var i AdminListUsersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.Email,
+			&i.Role,
+			&i.DisabledAt,
+			&i.Created,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const anonymiseMembershipEvents = `-- name: AnonymiseMembershipEvents :exec
+UPDATE membership_events
+SET actor_id = NULLIF(actor_id, $1::int),
+	user_id = NULLIF(user_id, $1::int),
+	email = CASE WHEN lower(email) = lower($2) THEN NULL ELSE email END
+WHERE actor_id = $1::int OR user_id = $1::int OR lower(email) = lower($2)
+`
+
+type AnonymiseMembershipEventsParams struct {
+	UserID int32  `json:"user_id"`
+	Email  string `json:"email"`
+}
+
+// the history stays, without who it was about
+func (q *Queries) AnonymiseMembershipEvents(ctx context.Context, arg AnonymiseMembershipEventsParams) error {
+	_, err := q.exec(ctx, q.anonymiseMembershipEventsStmt, anonymiseMembershipEvents, arg.UserID, arg.Email)
+	return err
+}
+
+const anonymiseUserComments = `-- name: AnonymiseUserComments :exec
+UPDATE blog_comments
+SET content = '[deleted]', updated = CURRENT_TIMESTAMP
+WHERE user_id = $1
+`
+
+// replies hang off comments, so the comments stay with their content removed
+func (q *Queries) AnonymiseUserComments(ctx context.Context, userID int32) error {
+	_, err := q.exec(ctx, q.anonymiseUserCommentsStmt, anonymiseUserComments, userID)
+	return err
+}
+
+const claimDueWebhookDeliveries = `-- name: ClaimDueWebhookDeliveries :many
+UPDATE webhook_deliveries d
+SET attempts = d.attempts + 1, next_attempt_at = CURRENT_TIMESTAMP + INTERVAL '1 minute', updated = CURRENT_TIMESTAMP
+FROM webhooks w
+WHERE w.id = d.webhook_id AND d.id IN (
+	SELECT id FROM webhook_deliveries
+	WHERE status = 'pending' AND next_attempt_at <= CURRENT_TIMESTAMP
+	ORDER BY next_attempt_at
+	LIMIT $1
+	FOR UPDATE SKIP LOCKED
 )
+RETURNING d.id, d.webhook_id, d.event, d.payload, d.attempts, w.url, w.secret
+`
+
+type ClaimDueWebhookDeliveriesRow struct {
+	ID        int32           `json:"id"`
+	WebhookID int32           `json:"webhook_id"`
+	Event     string          `json:"event"`
+	Payload   json.RawMessage `json:"payload"`
+	Attempts  int32           `json:"attempts"`
+	Url       string          `json:"url"`
+	Secret    string          `json:"secret"`
+}
+
+// next_attempt_at is pushed a minute ahead while sending, so rows held by a crashed worker come back on their own
+func (q *Queries) ClaimDueWebhookDeliveries(ctx context.Context, limit int32) ([]ClaimDueWebhookDeliveriesRow, error) {
+	rows, err := q.query(ctx, q.claimDueWebhookDeliveriesStmt, claimDueWebhookDeliveries, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ClaimDueWebhookDeliveriesRow{}
+	for rows.Next() {
+		var i ClaimDueWebhookDeliveriesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.WebhookID,
+			&i.Event,
+			&i.Payload,
+			&i.Attempts,
+			&i.Url,
+			&i.Secret,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const confirmUserTotp = `-- name: ConfirmUserTotp :execrows
+UPDATE user_totp
+SET confirmed_at = CURRENT_TIMESTAMP
+WHERE user_id = $1 AND confirmed_at IS NULL
+`
+
+func (q *Queries) ConfirmUserTotp(ctx context.Context, userID int32) (int64, error) {
+	result, err := q.exec(ctx, q.confirmUserTotpStmt, confirmUserTotp, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const countAvatarUsers = `-- name: CountAvatarUsers :one
+SELECT COUNT(*)
+FROM users
+WHERE avatar_key = $1
+`
+
+// avatars are stored by content hash, so two users can share one
+func (q *Queries) CountAvatarUsers(ctx context.Context, avatarKey sql.NullString) (int64, error) {
+	row := q.queryRow(ctx, q.countAvatarUsersStmt, countAvatarUsers, avatarKey)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countOrganizationOwners = `-- name: CountOrganizationOwners :one
+SELECT COUNT(*)
+FROM memberships
+WHERE organization_id = $1 AND role = 'owner'
+`
+
+func (q *Queries) CountOrganizationOwners(ctx context.Context, organizationID int32) (int64, error) {
+	row := q.queryRow(ctx, q.countOrganizationOwnersStmt, countOrganizationOwners, organizationID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countSoleOwnedOrganizations = `-- name: CountSoleOwnedOrganizations :one
+SELECT COUNT(*)
+FROM memberships m
+WHERE m.user_id = $1
+	AND m.role = 'owner'
+	AND NOT EXISTS (
+		SELECT 1 FROM memberships o
+		WHERE o.organization_id = m.organization_id AND o.user_id <> m.user_id AND o.role = 'owner'
+	)
+	AND EXISTS (
+		SELECT 1 FROM memberships o
+		WHERE o.organization_id = m.organization_id AND o.user_id <> m.user_id
+	)
+`
+
+// organisations that would be left without an owner while other people are still members
+func (q *Queries) CountSoleOwnedOrganizations(ctx context.Context, userID int32) (int64, error) {
+	row := q.queryRow(ctx, q.countSoleOwnedOrganizationsStmt, countSoleOwnedOrganizations, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createApiKey = `-- name: CreateApiKey :one
+INSERT INTO api_keys (user_id, name, prefix, secret_hash, scopes, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, user_id, name, prefix, scopes, expires_at, last_used_at, revoked_at, created
+`
+
+type CreateApiKeyParams struct {
+	UserID     int32        `json:"user_id"`
+	Name       string       `json:"name"`
+	Prefix     string       `json:"prefix"`
+	SecretHash string       `json:"secret_hash"`
+	Scopes     []string     `json:"scopes"`
+	ExpiresAt  sql.NullTime `json:"expires_at"`
+}
+
+type CreateApiKeyRow struct {
+	ID         int32        `json:"id"`
+	UserID     int32        `json:"user_id"`
+	Name       string       `json:"name"`
+	Prefix     string       `json:"prefix"`
+	Scopes     []string     `json:"scopes"`
+	ExpiresAt  sql.NullTime `json:"expires_at"`
+	LastUsedAt sql.NullTime `json:"last_used_at"`
+	RevokedAt  sql.NullTime `json:"revoked_at"`
+	Created    sql.NullTime `json:"created"`
+}
+
+func (q *Queries) CreateApiKey(ctx context.Context, arg CreateApiKeyParams) (CreateApiKeyRow, error) {
+	row := q.queryRow(ctx, q.createApiKeyStmt, createApiKey,
+		arg.UserID,
+		arg.Name,
+		arg.Prefix,
+		arg.SecretHash,
+		pq.Array(arg.Scopes),
+		arg.ExpiresAt,
+	)
+	var i CreateApiKeyRow
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Prefix,
+		pq.Array(&i.Scopes),
+		&i.ExpiresAt,
+		&i.LastUsedAt,
+		&i.RevokedAt,
+		&i.Created,
+	)
+	return i, err
+}
+
+const createBlog = `-- name: CreateBlog :one
+INSERT INTO blogs(title, content, summary, user_id, status, publish_at, organization_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+	RETURNING id, title, content, summary, user_id, status, publish_at, created, updated, organization_id
+`
+
+type CreateBlogParams struct {
+	Title          string        `json:"title"`
+	Content        string        `json:"content"`
+	Summary        string        `json:"summary"`
+	UserID         int32         `json:"user_id"`
+	Status         string        `json:"status"`
+	PublishAt      sql.NullTime  `json:"publish_at"`
+	OrganizationID sql.NullInt32 `json:"organization_id"`
+}
+
+type CreateBlogRow struct {
+	ID             int32         `json:"id"`
+	Title          string        `json:"title"`
+	Content        string        `json:"content"`
+	Summary        string        `json:"summary"`
+	UserID         int32         `json:"user_id"`
+	Status         string        `json:"status"`
+	PublishAt      sql.NullTime  `json:"publish_at"`
+	Created        sql.NullTime  `json:"created"`
+	Updated        sql.NullTime  `json:"updated"`
+	OrganizationID sql.NullInt32 `json:"organization_id"`
+}
+
+func (q *Queries) CreateBlog(ctx context.Context, arg CreateBlogParams) (CreateBlogRow, error) {
+	row := q.queryRow(ctx, q.createBlogStmt, createBlog,
+		arg.Title,
+		arg.Content,
+		arg.Summary,
+		arg.UserID,
+		arg.Status,
+		arg.PublishAt,
+		arg.OrganizationID,
+	)
+	var i CreateBlogRow
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Content,
+		&i.Summary,
+		&i.UserID,
+		&i.Status,
+		&i.PublishAt,
+		&i.Created,
+		&i.Updated,
+		&i.OrganizationID,
+	)
+	return i, err
+}
+
+const createBlogComment = `-- name: CreateBlogComment :one
+INSERT INTO blog_comments(blog_id, user_id, parent_id, content)
+VALUES ($1, $2, $3, $4)
+	RETURNING id, blog_id, user_id, parent_id, content, created, updated
+`
+
+type CreateBlogCommentParams struct {
+	BlogID   int32         `json:"blog_id"`
+	UserID   int32         `json:"user_id"`
+	ParentID sql.NullInt32 `json:"parent_id"`
+	Content  string        `json:"content"`
+}
+
+func (q *Queries) CreateBlogComment(ctx context.Context, arg CreateBlogCommentParams) (BlogComment, error) {
+	row := q.queryRow(ctx, q.createBlogCommentStmt, createBlogComment,
+		arg.BlogID,
+		arg.UserID,
+		arg.ParentID,
+		arg.Content,
+	)
+	var i BlogComment
+	err := row.Scan(
+		&i.ID,
+		&i.BlogID,
+		&i.UserID,
+		&i.ParentID,
+		&i.Content,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const createMembership = `-- name: CreateMembership :execrows
+INSERT INTO memberships (organization_id, user_id, role)
+VALUES ($1, $2, $3)
+ON CONFLICT (organization_id, user_id) DO NOTHING
+`
+
+type CreateMembershipParams struct {
+	OrganizationID int32  `json:"organization_id"`
+	UserID         int32  `json:"user_id"`
+	Role           string `json:"role"`
+}
+
+// zero rows when the user is already a member
+func (q *Queries) CreateMembership(ctx context.Context, arg CreateMembershipParams) (int64, error) {
+	result, err := q.exec(ctx, q.createMembershipStmt, createMembership, arg.OrganizationID, arg.UserID, arg.Role)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const createMembershipEvent = `-- name: CreateMembershipEvent :exec
+INSERT INTO membership_events (organization_id, actor_id, user_id, action, role, previous_role, email)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+`
+
+type CreateMembershipEventParams struct {
+	OrganizationID int32          `json:"organization_id"`
+	ActorID        sql.NullInt32  `json:"actor_id"`
+	UserID         sql.NullInt32  `json:"user_id"`
+	Action         string         `json:"action"`
+	Role           sql.NullString `json:"role"`
+	PreviousRole   sql.NullString `json:"previous_role"`
+	Email          sql.NullString `json:"email"`
+}
+
+func (q *Queries) CreateMembershipEvent(ctx context.Context, arg CreateMembershipEventParams) error {
+	_, err := q.exec(ctx, q.createMembershipEventStmt, createMembershipEvent,
+		arg.OrganizationID,
+		arg.ActorID,
+		arg.UserID,
+		arg.Action,
+		arg.Role,
+		arg.PreviousRole,
+		arg.Email,
+	)
+	return err
+}
+
+const createOrganization = `-- name: CreateOrganization :one
+INSERT INTO organizations (name, slug)
+VALUES ($1, $2)
+ON CONFLICT (slug) DO NOTHING
+RETURNING id, name, slug, created, updated
+`
+
+type CreateOrganizationParams struct {
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
+// no row when the slug is taken
+func (q *Queries) CreateOrganization(ctx context.Context, arg CreateOrganizationParams) (Organization, error) {
+	row := q.queryRow(ctx, q.createOrganizationStmt, createOrganization, arg.Name, arg.Slug)
+	var i Organization
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Slug,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const createOrganizationInvitation = `-- name: CreateOrganizationInvitation :one
+INSERT INTO organization_invitations (organization_id, email, role, token_hash, invited_by, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, organization_id, email, role, invited_by, expires_at, created
+`
+
+type CreateOrganizationInvitationParams struct {
+	OrganizationID int32         `json:"organization_id"`
+	Email          string        `json:"email"`
+	Role           string        `json:"role"`
+	TokenHash      string        `json:"token_hash"`
+	InvitedBy      sql.NullInt32 `json:"invited_by"`
+	ExpiresAt      time.Time     `json:"expires_at"`
+}
+
+type CreateOrganizationInvitationRow struct {
+	ID             int32         `json:"id"`
+	OrganizationID int32         `json:"organization_id"`
+	Email          string        `json:"email"`
+	Role           string        `json:"role"`
+	InvitedBy      sql.NullInt32 `json:"invited_by"`
+	ExpiresAt      time.Time     `json:"expires_at"`
+	Created        sql.NullTime  `json:"created"`
+}
+
+func (q *Queries) CreateOrganizationInvitation(ctx context.Context, arg CreateOrganizationInvitationParams) (CreateOrganizationInvitationRow, error) {
+	row := q.queryRow(ctx, q.createOrganizationInvitationStmt, createOrganizationInvitation,
+		arg.OrganizationID,
+		arg.Email,
+		arg.Role,
+		arg.TokenHash,
+		arg.InvitedBy,
+		arg.ExpiresAt,
+	)
+	var i CreateOrganizationInvitationRow
+	err := row.Scan(
+		&i.ID,
+		&i.OrganizationID,
+		&i.Email,
+		&i.Role,
+		&i.InvitedBy,
+		&i.ExpiresAt,
+		&i.Created,
+	)
+	return i, err
+}
+
+const createRecoveryCodes = `-- name: CreateRecoveryCodes :exec
+INSERT INTO user_recovery_codes (user_id, code_hash)
+SELECT $1::int, unnest($2::text[])
+`
+
+type CreateRecoveryCodesParams struct {
+	UserID     int32    `json:"user_id"`
+	CodeHashes []string `json:"code_hashes"`
+}
+
+func (q *Queries) CreateRecoveryCodes(ctx context.Context, arg CreateRecoveryCodesParams) error {
+	_, err := q.exec(ctx, q.createRecoveryCodesStmt, createRecoveryCodes, arg.UserID, pq.Array(arg.CodeHashes))
+	return err
+}
 
 const createUser = `-- name: CreateUser :one
 INSERT INTO users(username, email, password, created, updated)
 VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT DO NOTHING
 	RETURNING id, username, email, created, updated
 `
 
@@ -32,6 +583,7 @@ type CreateUserRow struct {
 	Updated  sql.NullTime `json:"updated"`
 }
 
+// no row when the email is already taken, ignoring case
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (CreateUserRow, error) {
 	row := q.queryRow(ctx, q.createUserStmt, createUser,
 		arg.Username,
@@ -51,67 +603,1532 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (CreateU
 	return i, err
 }
 
-const getUser = `-- name: GetUser :one
-SELECT id, username, email, password, created, updated
-FROM users
-WHERE id = $1
+const createWebhook = `-- name: CreateWebhook :one
+INSERT INTO webhooks (user_id, url, secret, events)
+VALUES ($1, $2, $3, $4)
+RETURNING id, user_id, url, secret, events, active, created, updated
 `
 
-func (q *Queries) GetUser(ctx context.Context, id int32) (User, error) {
-	row := q.queryRow(ctx, q.getUserStmt, getUser, id)
-	var i User
+type CreateWebhookParams struct {
+	UserID int32    `json:"user_id"`
+	Url    string   `json:"url"`
+	Secret string   `json:"secret"`
+	Events []string `json:"events"`
+}
+
+func (q *Queries) CreateWebhook(ctx context.Context, arg CreateWebhookParams) (Webhook, error) {
+	row := q.queryRow(ctx, q.createWebhookStmt, createWebhook,
+		arg.UserID,
+		arg.Url,
+		arg.Secret,
+		pq.Array(arg.Events),
+	)
+	var i Webhook
 	err := row.Scan(
 		&i.ID,
-		&i.Username,
-		&i.Email,
-		&i.Password,
+		&i.UserID,
+		&i.Url,
+		&i.Secret,
+		pq.Array(&i.Events),
+		&i.Active,
 		&i.Created,
 		&i.Updated,
 	)
 	return i, err
 }
 
-const getUserByUsernameOrEmail = `-- name: GetUserByUsernameOrEmail :one
-SELECT id, username, email, created, updated, password
-FROM users
-WHERE username = $1 OR email = $1
+const deleteBlogComment = `-- name: DeleteBlogComment :execrows
+DELETE FROM blog_comments
+WHERE id = $1
 `
 
-type GetUserByUsernameOrEmailRow struct {
-	ID       int32        `json:"id"`
-	Username string       `json:"username"`
-	Email    string       `json:"email"`
-	Created  sql.NullTime `json:"created"`
-	Updated  sql.NullTime `json:"updated"`
-	Password string       `json:"password"`
+func (q *Queries) DeleteBlogComment(ctx context.Context, id int32) (int64, error) {
+	result, err := q.exec(ctx, q.deleteBlogCommentStmt, deleteBlogComment, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
 }
 
-func (q *Queries) GetUserByUsernameOrEmail(ctx context.Context, username string) (GetUserByUsernameOrEmailRow, error) {
-	row := q.queryRow(ctx, q.getUserByUsernameOrEmailStmt, getUserByUsernameOrEmail, username)
-	var i GetUserByUsernameOrEmailRow
-	err := row.Scan(
-		&i.ID,
-		&i.Username,
-		&i.Email,
-		&i.Created,
-		&i.Updated,
-		&i.Password,
-	)
-	return i, err
+const deleteBlogTags = `-- name: DeleteBlogTags :exec
+DELETE FROM blog_tags
+WHERE blog_id = $1
+`
+
+func (q *Queries) DeleteBlogTags(ctx context.Context, blogID int32) error {
+	_, err := q.exec(ctx, q.deleteBlogTagsStmt, deleteBlogTags, blogID)
+	return err
 }
 
-const listUsers = `-- name: ListUsers :many
-SELECT id, username, email, created, updated
-FROM users
-ORDER BY id
+const deleteInvitationsForEmail = `-- name: DeleteInvitationsForEmail :exec
+DELETE FROM organization_invitations
+WHERE lower(email) = lower($1)
 `
 
-type ListUsersRow struct {
-	ID       int32        `json:"id"`
-	Username string       `json:"username"`
-	Email    string       `json:"email"`
-	Created  sql.NullTime `json:"created"`
-	Updated  sql.NullTime `json:"updated"`
+func (q *Queries) DeleteInvitationsForEmail(ctx context.Context, email string) error {
+	_, err := q.exec(ctx, q.deleteInvitationsForEmailStmt, deleteInvitationsForEmail, email)
+	return err
+}
+
+const deleteMembership = `-- name: DeleteMembership :exec
+DELETE FROM memberships
+WHERE organization_id = $1 AND user_id = $2
+`
+
+type DeleteMembershipParams struct {
+	OrganizationID int32 `json:"organization_id"`
+	UserID         int32 `json:"user_id"`
+}
+
+func (q *Queries) DeleteMembership(ctx context.Context, arg DeleteMembershipParams) error {
+	_, err := q.exec(ctx, q.deleteMembershipStmt, deleteMembership, arg.OrganizationID, arg.UserID)
+	return err
+}
+
+const deleteOrganizationInvitation = `-- name: DeleteOrganizationInvitation :one
+DELETE FROM organization_invitations
+WHERE id = $1 AND organization_id = $2 AND accepted_at IS NULL
+RETURNING email, role
+`
+
+type DeleteOrganizationInvitationParams struct {
+	ID             int32 `json:"id"`
+	OrganizationID int32 `json:"organization_id"`
+}
+
+type DeleteOrganizationInvitationRow struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+func (q *Queries) DeleteOrganizationInvitation(ctx context.Context, arg DeleteOrganizationInvitationParams) (DeleteOrganizationInvitationRow, error) {
+	row := q.queryRow(ctx, q.deleteOrganizationInvitationStmt, deleteOrganizationInvitation, arg.ID, arg.OrganizationID)
+	var i DeleteOrganizationInvitationRow
+	err := row.Scan(
+		&i.Email,
+		&i.Role,
+	)
+	return i, err
+}
+
+const deleteRecoveryCodes = `-- name: DeleteRecoveryCodes :exec
+DELETE FROM user_recovery_codes
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteRecoveryCodes(ctx context.Context, userID int32) error {
+	_, err := q.exec(ctx, q.deleteRecoveryCodesStmt, deleteRecoveryCodes, userID)
+	return err
+}
+
+const deleteSoleMemberOrganizations = `-- name: DeleteSoleMemberOrganizations :exec
+DELETE FROM organizations o
+WHERE EXISTS (SELECT 1 FROM memberships m WHERE m.organization_id = o.id AND m.user_id = $1)
+	AND NOT EXISTS (SELECT 1 FROM memberships m WHERE m.organization_id = o.id AND m.user_id <> $1)
+`
+
+func (q *Queries) DeleteSoleMemberOrganizations(ctx context.Context, userID int32) error {
+	_, err := q.exec(ctx, q.deleteSoleMemberOrganizationsStmt, deleteSoleMemberOrganizations, userID)
+	return err
+}
+
+const deleteUserApiKeys = `-- name: DeleteUserApiKeys :exec
+DELETE FROM api_keys
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserApiKeys(ctx context.Context, userID int32) error {
+	_, err := q.exec(ctx, q.deleteUserApiKeysStmt, deleteUserApiKeys, userID)
+	return err
+}
+
+const deleteUserBlogs = `-- name: DeleteUserBlogs :many
+DELETE FROM blogs
+WHERE user_id = $1 AND organization_id IS NULL
+RETURNING id, updated
+`
+
+type DeleteUserBlogsRow struct {
+	ID      int32        `json:"id"`
+	Updated sql.NullTime `json:"updated"`
+}
+
+// organisation blogs stay with the organisation
+func (q *Queries) DeleteUserBlogs(ctx context.Context, userID int32) ([]DeleteUserBlogsRow, error) {
+	rows, err := q.query(ctx, q.deleteUserBlogsStmt, deleteUserBlogs, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []DeleteUserBlogsRow{}
+	for rows.Next() {
+		var i DeleteUserBlogsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Updated,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteUserFollows = `-- name: DeleteUserFollows :exec
+DELETE FROM follows
+WHERE follower_id = $1 OR followee_id = $1
+`
+
+func (q *Queries) DeleteUserFollows(ctx context.Context, followerID int32) error {
+	_, err := q.exec(ctx, q.deleteUserFollowsStmt, deleteUserFollows, followerID)
+	return err
+}
+
+const deleteUserMemberships = `-- name: DeleteUserMemberships :exec
+DELETE FROM memberships
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserMemberships(ctx context.Context, userID int32) error {
+	_, err := q.exec(ctx, q.deleteUserMembershipsStmt, deleteUserMemberships, userID)
+	return err
+}
+
+const deleteUserReactions = `-- name: DeleteUserReactions :exec
+DELETE FROM blog_reactions
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserReactions(ctx context.Context, userID int32) error {
+	_, err := q.exec(ctx, q.deleteUserReactionsStmt, deleteUserReactions, userID)
+	return err
+}
+
+const deleteUserTotp = `-- name: DeleteUserTotp :exec
+DELETE FROM user_totp
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteUserTotp(ctx context.Context, userID int32) error {
+	_, err := q.exec(ctx, q.deleteUserTotpStmt, deleteUserTotp, userID)
+	return err
+}
+
+const deleteWebhook = `-- name: DeleteWebhook :execrows
+DELETE FROM webhooks
+WHERE id = $1
+`
+
+func (q *Queries) DeleteWebhook(ctx context.Context, id int32) (int64, error) {
+	result, err := q.exec(ctx, q.deleteWebhookStmt, deleteWebhook, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const disableUser = `-- name: DisableUser :execrows
+UPDATE users
+SET disabled_at = CURRENT_TIMESTAMP, updated = CURRENT_TIMESTAMP
+WHERE id = $1 AND disabled_at IS NULL
+`
+
+func (q *Queries) DisableUser(ctx context.Context, id int32) (int64, error) {
+	result, err := q.exec(ctx, q.disableUserStmt, disableUser, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const enableUser = `-- name: EnableUser :execrows
+UPDATE users
+SET disabled_at = NULL, updated = CURRENT_TIMESTAMP
+WHERE id = $1 AND disabled_at IS NOT NULL
+`
+
+func (q *Queries) EnableUser(ctx context.Context, id int32) (int64, error) {
+	result, err := q.exec(ctx, q.enableUserStmt, enableUser, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const enqueueWebhookDeliveries = `-- name: EnqueueWebhookDeliveries :execrows
+INSERT INTO webhook_deliveries (webhook_id, event, payload)
+SELECT id, $1::text, $2::jsonb
+FROM webhooks
+WHERE active AND $1::text = ANY(events)
+`
+
+type EnqueueWebhookDeliveriesParams struct {
+	Event   string          `json:"event"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// one delivery per active webhook subscribed to the event, run it in the same transaction as the change
+func (q *Queries) EnqueueWebhookDeliveries(ctx context.Context, arg EnqueueWebhookDeliveriesParams) (int64, error) {
+	result, err := q.exec(ctx, q.enqueueWebhookDeliveriesStmt, enqueueWebhookDeliveries, arg.Event, arg.Payload)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const eraseUser = `-- name: EraseUser :exec
+UPDATE users
+SET username = 'deleted-' || id,
+	email = 'deleted-' || id || '$1',
+	password = '!',
+	role = 'user',
+	avatar_key = NULL,
+	disabled_at = COALESCE(disabled_at, CURRENT_TIMESTAMP),
+	updated = CURRENT_TIMESTAMP
+WHERE id = $1
+`
+
+// the row stays so organisation blogs and comments keep an author, with nothing left that identifies anyone.
+// '!' is not a hash of any kind, so no password ever matches it. disabled as well, so its tokens are refused
+// even when redis can't say they were revoked
+func (q *Queries) EraseUser(ctx context.Context, id int32) error {
+	_, err := q.exec(ctx, q.eraseUserStmt, eraseUser, id)
+	return err
+}
+
+const exportUserBlogs = `-- name: ExportUserBlogs :many
+SELECT id, title, content, summary, status, publish_at, organization_id, created, updated
+FROM blogs
+WHERE user_id = $1
+ORDER BY id
+`
+
+type ExportUserBlogsRow struct {
+	ID             int32         `json:"id"`
+	Title          string        `json:"title"`
+	Content        string        `json:"content"`
+	Summary        string        `json:"summary"`
+	Status         string        `json:"status"`
+	PublishAt      sql.NullTime  `json:"publish_at"`
+	OrganizationID sql.NullInt32 `json:"organization_id"`
+	Created        sql.NullTime  `json:"created"`
+	Updated        sql.NullTime  `json:"updated"`
+}
+
+func (q *Queries) ExportUserBlogs(ctx context.Context, userID int32) ([]ExportUserBlogsRow, error) {
+	rows, err := q.query(ctx, q.exportUserBlogsStmt, exportUserBlogs, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ExportUserBlogsRow{}
+	for rows.Next() {
+		var i ExportUserBlogsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Content,
+			&i.Summary,
+			&i.Status,
+			&i.PublishAt,
+			&i.OrganizationID,
+			&i.Created,
+			&i.Updated,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const exportUserComments = `-- name: ExportUserComments :many
+SELECT id, blog_id, parent_id, content, created, updated
+FROM blog_comments
+WHERE user_id = $1
+ORDER BY id
+`
+
+type ExportUserCommentsRow struct {
+	ID       int32         `json:"id"`
+	BlogID   int32         `json:"blog_id"`
+	ParentID sql.NullInt32 `json:"parent_id"`
+	Content  string        `json:"content"`
+	Created  sql.NullTime  `json:"created"`
+	Updated  sql.NullTime  `json:"updated"`
+}
+
+func (q *Queries) ExportUserComments(ctx context.Context, userID int32) ([]ExportUserCommentsRow, error) {
+	rows, err := q.query(ctx, q.exportUserCommentsStmt, exportUserComments, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ExportUserCommentsRow{}
+	for rows.Next() {
+		var i ExportUserCommentsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.BlogID,
+			&i.ParentID,
+			&i.Content,
+			&i.Created,
+			&i.Updated,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const exportUserFollows = `-- name: ExportUserFollows :many
+SELECT follower_id, followee_id, created
+FROM follows
+WHERE follower_id = $1 OR followee_id = $1
+ORDER BY created
+`
+
+func (q *Queries) ExportUserFollows(ctx context.Context, followerID int32) ([]Follow, error) {
+	rows, err := q.query(ctx, q.exportUserFollowsStmt, exportUserFollows, followerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Follow{}
+	for rows.Next() {
+		var i Follow
+		if err := rows.Scan(
+			&i.FollowerID,
+			&i.FolloweeID,
+			&i.Created,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const exportUserMembershipEvents = `-- name: ExportUserMembershipEvents :many
+SELECT id, organization_id, actor_id, user_id, action, role, previous_role, email, created
+FROM membership_events
+WHERE actor_id = $1::int OR user_id = $1::int
+ORDER BY id
+`
+
+// changes the user made, and changes made to their memberships
+func (q *Queries) ExportUserMembershipEvents(ctx context.Context, userID int32) ([]MembershipEvent, error) {
+	rows, err := q.query(ctx, q.exportUserMembershipEventsStmt, exportUserMembershipEvents, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []MembershipEvent{}
+	for rows.Next() {
+		var i MembershipEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrganizationID,
+			&i.ActorID,
+			&i.UserID,
+			&i.Action,
+			&i.Role,
+			&i.PreviousRole,
+			&i.Email,
+			&i.Created,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const followUser = `-- name: FollowUser :execrows
+INSERT INTO follows (follower_id, followee_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING
+`
+
+type FollowUserParams struct {
+	FollowerID int32 `json:"follower_id"`
+	FolloweeID int32 `json:"followee_id"`
+}
+
+// zero rows when already following
+func (q *Queries) FollowUser(ctx context.Context, arg FollowUserParams) (int64, error) {
+	result, err := q.exec(ctx, q.followUserStmt, followUser, arg.FollowerID, arg.FolloweeID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getApiKeyByPrefix = `-- name: GetApiKeyByPrefix :one
+SELECT k.id, k.user_id, k.secret_hash, k.scopes, k.expires_at, k.revoked_at, u.username, u.disabled_at
+FROM api_keys k
+JOIN users u ON u.id = k.user_id
+WHERE k.prefix = $1
+`
+
+type GetApiKeyByPrefixRow struct {
+	ID         int32        `json:"id"`
+	UserID     int32        `json:"user_id"`
+	SecretHash string       `json:"secret_hash"`
+	Scopes     []string     `json:"scopes"`
+	ExpiresAt  sql.NullTime `json:"expires_at"`
+	RevokedAt  sql.NullTime `json:"revoked_at"`
+	Username   string       `json:"username"`
+	DisabledAt sql.NullTime `json:"disabled_at"`
+}
+
+func (q *Queries) GetApiKeyByPrefix(ctx context.Context, prefix string) (GetApiKeyByPrefixRow, error) {
+	row := q.queryRow(ctx, q.getApiKeyByPrefixStmt, getApiKeyByPrefix, prefix)
+	var i GetApiKeyByPrefixRow
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.SecretHash,
+		pq.Array(&i.Scopes),
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.Username,
+		&i.DisabledAt,
+	)
+	return i, err
+}
+
+const getBlog = `-- name: GetBlog :one
+SELECT b.id, b.title, b.content, b.summary, b.user_id, b.status, b.publish_at, b.created, b.updated, b.organization_id,
+	(SELECT COUNT(*) FROM blog_comments c WHERE c.blog_id = b.id)::bigint AS comment_count,
+	(SELECT COALESCE(jsonb_object_agg(r.kind, r.total), '{}')
+		FROM (SELECT kind, COUNT(*) AS total FROM blog_reactions WHERE blog_id = b.id GROUP BY kind) r
+	)::jsonb AS reaction_counts,
+	(SELECT COALESCE(array_agg(t.slug ORDER BY t.slug), '{}')
+		FROM blog_tags bt JOIN tags t ON t.id = bt.tag_id WHERE bt.blog_id = b.id
+	)::text[] AS tags
+FROM blogs b
+WHERE b.id = $1
+`
+
+type GetBlogRow struct {
+	ID             int32           `json:"id"`
+	Title          string          `json:"title"`
+	Content        string          `json:"content"`
+	Summary        string          `json:"summary"`
+	UserID         int32           `json:"user_id"`
+	Status         string          `json:"status"`
+	PublishAt      sql.NullTime    `json:"publish_at"`
+	Created        sql.NullTime    `json:"created"`
+	Updated        sql.NullTime    `json:"updated"`
+	OrganizationID sql.NullInt32   `json:"organization_id"`
+	CommentCount   int64           `json:"comment_count"`
+	ReactionCounts json.RawMessage `json:"reaction_counts"`
+	Tags           []string        `json:"tags"`
+}
+
+func (q *Queries) GetBlog(ctx context.Context, id int32) (GetBlogRow, error) {
+	row := q.queryRow(ctx, q.getBlogStmt, getBlog, id)
+	var i GetBlogRow
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Content,
+		&i.Summary,
+		&i.UserID,
+		&i.Status,
+		&i.PublishAt,
+		&i.Created,
+		&i.Updated,
+		&i.OrganizationID,
+		&i.CommentCount,
+		&i.ReactionCounts,
+		pq.Array(&i.Tags),
+	)
+	return i, err
+}
+
+const getBlogComment = `-- name: GetBlogComment :one
+SELECT id, blog_id, user_id, parent_id, content, created, updated
+FROM blog_comments
+WHERE id = $1
+`
+
+func (q *Queries) GetBlogComment(ctx context.Context, id int32) (BlogComment, error) {
+	row := q.queryRow(ctx, q.getBlogCommentStmt, getBlogComment, id)
+	var i BlogComment
+	err := row.Scan(
+		&i.ID,
+		&i.BlogID,
+		&i.UserID,
+		&i.ParentID,
+		&i.Content,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const getFollowCounts = `-- name: GetFollowCounts :one
+SELECT
+	(SELECT COUNT(*) FROM follows WHERE followee_id = $1)::bigint AS followers,
+	(SELECT COUNT(*) FROM follows WHERE follower_id = $1)::bigint AS following
+`
+
+type GetFollowCountsRow struct {
+	Followers int64 `json:"followers"`
+	Following int64 `json:"following"`
+}
+
+func (q *Queries) GetFollowCounts(ctx context.Context, followeeID int32) (GetFollowCountsRow, error) {
+	row := q.queryRow(ctx, q.getFollowCountsStmt, getFollowCounts, followeeID)
+	var i GetFollowCountsRow
+	err := row.Scan(
+		&i.Followers,
+		&i.Following,
+	)
+	return i, err
+}
+
+const getMembershipRole = `-- name: GetMembershipRole :one
+SELECT role
+FROM memberships
+WHERE organization_id = $1 AND user_id = $2
+`
+
+type GetMembershipRoleParams struct {
+	OrganizationID int32 `json:"organization_id"`
+	UserID         int32 `json:"user_id"`
+}
+
+func (q *Queries) GetMembershipRole(ctx context.Context, arg GetMembershipRoleParams) (string, error) {
+	row := q.queryRow(ctx, q.getMembershipRoleStmt, getMembershipRole, arg.OrganizationID, arg.UserID)
+	var role string
+	err := row.Scan(&role)
+	return role, err
+}
+
+const getOrganization = `-- name: GetOrganization :one
+SELECT id, name, slug, created, updated
+FROM organizations
+WHERE id = $1
+`
+
+func (q *Queries) GetOrganization(ctx context.Context, id int32) (Organization, error) {
+	row := q.queryRow(ctx, q.getOrganizationStmt, getOrganization, id)
+	var i Organization
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Slug,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const getUser = `-- name: GetUser :one
+SELECT id, username, email, password, created, updated, role, avatar_key, disabled_at
+FROM users
+WHERE id = $1
+`
+
+func (q *Queries) GetUser(ctx context.Context, id int32) (User, error) {
+	row := q.queryRow(ctx, q.getUserStmt, getUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Email,
+		&i.Password,
+		&i.Created,
+		&i.Updated,
+		&i.Role,
+		&i.AvatarKey,
+		&i.DisabledAt,
+	)
+	return i, err
+}
+
+const getUserByUsernameOrEmail = `-- name: GetUserByUsernameOrEmail :one
+SELECT id, username, email, created, updated, password, disabled_at
+FROM users
+WHERE username = $1 OR email = $1
+`
+
+type GetUserByUsernameOrEmailRow struct {
+	ID         int32        `json:"id"`
+	Username   string       `json:"username"`
+	Email      string       `json:"email"`
+	Created    sql.NullTime `json:"created"`
+	Updated    sql.NullTime `json:"updated"`
+	Password   string       `json:"password"`
+	DisabledAt sql.NullTime `json:"disabled_at"`
+}
+
+func (q *Queries) GetUserByUsernameOrEmail(ctx context.Context, username string) (GetUserByUsernameOrEmailRow, error) {
+	row := q.queryRow(ctx, q.getUserByUsernameOrEmailStmt, getUserByUsernameOrEmail, username)
+	var i GetUserByUsernameOrEmailRow
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Email,
+		&i.Created,
+		&i.Updated,
+		&i.Password,
+		&i.DisabledAt,
+	)
+	return i, err
+}
+
+const getUserRole = `-- name: GetUserRole :one
+SELECT role
+FROM users
+WHERE id = $1
+`
+
+func (q *Queries) GetUserRole(ctx context.Context, id int32) (string, error) {
+	row := q.queryRow(ctx, q.getUserRoleStmt, getUserRole, id)
+	var role string
+	err := row.Scan(&role)
+	return role, err
+}
+
+const getUserTotp = `-- name: GetUserTotp :one
+SELECT user_id, secret, confirmed_at, created
+FROM user_totp
+WHERE user_id = $1
+`
+
+func (q *Queries) GetUserTotp(ctx context.Context, userID int32) (UserTotp, error) {
+	row := q.queryRow(ctx, q.getUserTotpStmt, getUserTotp, userID)
+	var i UserTotp
+	err := row.Scan(
+		&i.UserID,
+		&i.Secret,
+		&i.ConfirmedAt,
+		&i.Created,
+	)
+	return i, err
+}
+
+const listApiKeys = `-- name: ListApiKeys :many
+SELECT id, user_id, name, prefix, scopes, expires_at, last_used_at, revoked_at, created
+FROM api_keys
+WHERE user_id = $1
+ORDER BY created DESC, id DESC
+`
+
+type ListApiKeysRow struct {
+	ID         int32        `json:"id"`
+	UserID     int32        `json:"user_id"`
+	Name       string       `json:"name"`
+	Prefix     string       `json:"prefix"`
+	Scopes     []string     `json:"scopes"`
+	ExpiresAt  sql.NullTime `json:"expires_at"`
+	LastUsedAt sql.NullTime `json:"last_used_at"`
+	RevokedAt  sql.NullTime `json:"revoked_at"`
+	Created    sql.NullTime `json:"created"`
+}
+
+func (q *Queries) ListApiKeys(ctx context.Context, userID int32) ([]ListApiKeysRow, error) {
+	rows, err := q.query(ctx, q.listApiKeysStmt, listApiKeys, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListApiKeysRow{}
+	for rows.Next() {
+		var i ListApiKeysRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.Prefix,
+			pq.Array(&i.Scopes),
+			&i.ExpiresAt,
+			&i.LastUsedAt,
+			&i.RevokedAt,
+			&i.Created,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBlogComments = `-- name: ListBlogComments :many
+SELECT id, blog_id, user_id, parent_id, content, created, updated
+FROM blog_comments
+WHERE blog_id = $1
+ORDER BY created, id
+`
+
+func (q *Queries) ListBlogComments(ctx context.Context, blogID int32) ([]BlogComment, error) {
+	rows, err := q.query(ctx, q.listBlogCommentsStmt, listBlogComments, blogID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []BlogComment{}
+	for rows.Next() {
+		var i BlogComment
+		if err := rows.Scan(
+			&i.ID,
+			&i.BlogID,
+			&i.UserID,
+			&i.ParentID,
+			&i.Content,
+			&i.Created,
+			&i.Updated,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBlogs = `-- name: ListBlogs :many
+SELECT b.id, b.title, b.content, b.summary, b.user_id, b.status, b.publish_at, b.created, b.updated, b.organization_id,
+	(SELECT COUNT(*) FROM blog_comments c WHERE c.blog_id = b.id)::bigint AS comment_count,
+	(SELECT COALESCE(jsonb_object_agg(r.kind, r.total), '{}')
+		FROM (SELECT kind, COUNT(*) AS total FROM blog_reactions WHERE blog_id = b.id GROUP BY kind) r
+	)::jsonb AS reaction_counts,
+	(SELECT COALESCE(array_agg(t.slug ORDER BY t.slug), '{}')
+		FROM blog_tags bt JOIN tags t ON t.id = bt.tag_id WHERE bt.blog_id = b.id
+	)::text[] AS tags
+FROM blogs b
+WHERE b.status = 'published'
+	AND (cardinality($1::text[]) = 0
+		OR (
+			SELECT COUNT(*) FROM blog_tags bt JOIN tags t ON t.id = bt.tag_id
+			WHERE bt.blog_id = b.id AND t.slug = ANY($1::text[])
+		) >= CASE WHEN $2::boolean THEN cardinality($1::text[]) ELSE 1 END)
+ORDER BY COALESCE(b.publish_at, b.created) DESC, b.id DESC
+LIMIT $3 OFFSET $4
+`
+
+type ListBlogsParams struct {
+	Tags      []string `json:"tags"`
+	MatchAll  bool     `json:"match_all"`
+	RowLimit  int32    `json:"row_limit"`
+	RowOffset int32    `json:"row_offset"`
+}
+
+type ListBlogsRow struct {
+	ID             int32           `json:"id"`
+	Title          string          `json:"title"`
+	Content        string          `json:"content"`
+	Summary        string          `json:"summary"`
+	UserID         int32           `json:"user_id"`
+	Status         string          `json:"status"`
+	PublishAt      sql.NullTime    `json:"publish_at"`
+	Created        sql.NullTime    `json:"created"`
+	Updated        sql.NullTime    `json:"updated"`
+	OrganizationID sql.NullInt32   `json:"organization_id"`
+	CommentCount   int64           `json:"comment_count"`
+	ReactionCounts json.RawMessage `json:"reaction_counts"`
+	Tags           []string        `json:"tags"`
+}
+
+// with match_all a blog needs every requested tag, otherwise any one of them is enough
+func (q *Queries) ListBlogs(ctx context.Context, arg ListBlogsParams) ([]ListBlogsRow, error) {
+	rows, err := q.query(ctx, q.listBlogsStmt, listBlogs,
+		pq.Array(arg.Tags),
+		arg.MatchAll,
+		arg.RowLimit,
+		arg.RowOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListBlogsRow{}
+	for rows.Next() {
+		var i ListBlogsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Content,
+			&i.Summary,
+			&i.UserID,
+			&i.Status,
+			&i.PublishAt,
+			&i.Created,
+			&i.Updated,
+			&i.OrganizationID,
+			&i.CommentCount,
+			&i.ReactionCounts,
+			pq.Array(&i.Tags),
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listBlogsByAuthor = `-- name: ListBlogsByAuthor :many
+SELECT id, title, content, summary, user_id, status, publish_at, created, updated
+FROM blogs
+WHERE user_id = $1
+ORDER BY created DESC, id DESC
+LIMIT $2 OFFSET $3
+`
+
+type ListBlogsByAuthorParams struct {
+	UserID int32 `json:"user_id"`
+	Limit  int32 `json:"limit"`
+	Offset int32 `json:"offset"`
+}
+
+type ListBlogsByAuthorRow struct {
+	ID        int32        `json:"id"`
+	Title     string       `json:"title"`
+	Content   string       `json:"content"`
+	Summary   string       `json:"summary"`
+	UserID    int32        `json:"user_id"`
+	Status    string       `json:"status"`
+	PublishAt sql.NullTime `json:"publish_at"`
+	Created   sql.NullTime `json:"created"`
+	Updated   sql.NullTime `json:"updated"`
+}
+
+func (q *Queries) ListBlogsByAuthor(ctx context.Context, arg ListBlogsByAuthorParams) ([]ListBlogsByAuthorRow, error) {
+	rows, err := q.query(ctx, q.listBlogsByAuthorStmt, listBlogsByAuthor, arg.UserID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListBlogsByAuthorRow{}
+	for rows.Next() {
+		var i ListBlogsByAuthorRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Content,
+			&i.Summary,
+			&i.UserID,
+			&i.Status,
+			&i.PublishAt,
+			&i.Created,
+			&i.Updated,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFeedBlogs = `-- name: ListFeedBlogs :many
+SELECT b.id, b.title, b.summary, b.user_id, u.username, b.publish_at, b.organization_id,
+	(SELECT COUNT(*) FROM blog_comments c WHERE c.blog_id = b.id)::bigint AS comment_count,
+	(SELECT COALESCE(jsonb_object_agg(r.kind, r.total), '{}')
+		FROM (SELECT kind, COUNT(*) AS total FROM blog_reactions WHERE blog_id = b.id GROUP BY kind) r
+	)::jsonb AS reaction_counts,
+	(SELECT COALESCE(array_agg(t.slug ORDER BY t.slug), '{}')
+		FROM blog_tags bt JOIN tags t ON t.id = bt.tag_id WHERE bt.blog_id = b.id
+	)::text[] AS tags
+FROM blogs b
+JOIN users u ON u.id = b.user_id
+WHERE b.id = ANY($1::int[]) AND b.status = 'published'
+ORDER BY COALESCE(b.publish_at, b.created) DESC, b.id DESC
+`
+
+type ListFeedBlogsRow struct {
+	ID             int32           `json:"id"`
+	Title          string          `json:"title"`
+	Summary        string          `json:"summary"`
+	UserID         int32           `json:"user_id"`
+	Username       string          `json:"username"`
+	PublishAt      sql.NullTime    `json:"publish_at"`
+	OrganizationID sql.NullInt32   `json:"organization_id"`
+	CommentCount   int64           `json:"comment_count"`
+	ReactionCounts json.RawMessage `json:"reaction_counts"`
+	Tags           []string        `json:"tags"`
+}
+
+// blogs that stopped being published since their id was read are left out
+func (q *Queries) ListFeedBlogs(ctx context.Context, ids []int32) ([]ListFeedBlogsRow, error) {
+	rows, err := q.query(ctx, q.listFeedBlogsStmt, listFeedBlogs, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListFeedBlogsRow{}
+	for rows.Next() {
+		var i ListFeedBlogsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Summary,
+			&i.UserID,
+			&i.Username,
+			&i.PublishAt,
+			&i.OrganizationID,
+			&i.CommentCount,
+			&i.ReactionCounts,
+			pq.Array(&i.Tags),
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFeedEntries = `-- name: ListFeedEntries :many
+SELECT b.id, COALESCE(b.publish_at, b.created) AS published_at
+FROM blogs b
+JOIN follows f ON f.followee_id = b.user_id
+WHERE f.follower_id = $1
+	AND b.status = 'published'
+	AND ($2::timestamp IS NULL
+		OR (COALESCE(b.publish_at, b.created), b.id) < ($2::timestamp, $3::int))
+ORDER BY COALESCE(b.publish_at, b.created) DESC, b.id DESC
+LIMIT $4
+`
+
+type ListFeedEntriesParams struct {
+	FollowerID        int32         `json:"follower_id"`
+	BeforePublishedAt sql.NullTime  `json:"before_published_at"`
+	BeforeID          sql.NullInt32 `json:"before_id"`
+	RowLimit          int32         `json:"row_limit"`
+}
+
+type ListFeedEntriesRow struct {
+	ID          int32        `json:"id"`
+	PublishedAt sql.NullTime `json:"published_at"`
+}
+
+// published blogs of everyone the user follows, newest first. blogs published before publish_at existed
+// don't have one, so they go by created. keyset paginated: pass the published_at and id of the last entry
+// seen, or NULL for the first page
+func (q *Queries) ListFeedEntries(ctx context.Context, arg ListFeedEntriesParams) ([]ListFeedEntriesRow, error) {
+	rows, err := q.query(ctx, q.listFeedEntriesStmt, listFeedEntries,
+		arg.FollowerID,
+		arg.BeforePublishedAt,
+		arg.BeforeID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListFeedEntriesRow{}
+	for rows.Next() {
+		var i ListFeedEntriesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.PublishedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFollowers = `-- name: ListFollowers :many
+SELECT u.id, u.username, f.created AS followed_at
+FROM follows f
+JOIN users u ON u.id = f.follower_id
+WHERE f.followee_id = $1
+ORDER BY f.created DESC, u.id DESC
+LIMIT $2 OFFSET $3
+`
+
+type ListFollowersParams struct {
+	FolloweeID int32 `json:"followee_id"`
+	Limit      int32 `json:"limit"`
+	Offset     int32 `json:"offset"`
+}
+
+type ListFollowersRow struct {
+	ID         int32        `json:"id"`
+	Username   string       `json:"username"`
+	FollowedAt sql.NullTime `json:"followed_at"`
+}
+
+func (q *Queries) ListFollowers(ctx context.Context, arg ListFollowersParams) ([]ListFollowersRow, error) {
+	rows, err := q.query(ctx, q.listFollowersStmt, listFollowers, arg.FolloweeID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListFollowersRow{}
+	for rows.Next() {
+		var i ListFollowersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.FollowedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFollowing = `-- name: ListFollowing :many
+SELECT u.id, u.username, f.created AS followed_at
+FROM follows f
+JOIN users u ON u.id = f.followee_id
+WHERE f.follower_id = $1
+ORDER BY f.created DESC, u.id DESC
+LIMIT $2 OFFSET $3
+`
+
+type ListFollowingParams struct {
+	FollowerID int32 `json:"follower_id"`
+	Limit      int32 `json:"limit"`
+	Offset     int32 `json:"offset"`
+}
+
+type ListFollowingRow struct {
+	ID         int32        `json:"id"`
+	Username   string       `json:"username"`
+	FollowedAt sql.NullTime `json:"followed_at"`
+}
+
+func (q *Queries) ListFollowing(ctx context.Context, arg ListFollowingParams) ([]ListFollowingRow, error) {
+	rows, err := q.query(ctx, q.listFollowingStmt, listFollowing, arg.FollowerID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListFollowingRow{}
+	for rows.Next() {
+		var i ListFollowingRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.FollowedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listHeavyFollowers = `-- name: ListHeavyFollowers :many
+SELECT f.follower_id
+FROM follows f
+WHERE f.followee_id = $1
+	AND (SELECT COUNT(*) FROM follows mine WHERE mine.follower_id = f.follower_id) >= $2::bigint
+`
+
+type ListHeavyFollowersParams struct {
+	FolloweeID   int32 `json:"followee_id"`
+	MinFollowing int64 `json:"min_following"`
+}
+
+// followers who follow at least min_following users, the ones whose feeds are cached
+func (q *Queries) ListHeavyFollowers(ctx context.Context, arg ListHeavyFollowersParams) ([]int32, error) {
+	rows, err := q.query(ctx, q.listHeavyFollowersStmt, listHeavyFollowers, arg.FolloweeID, arg.MinFollowing)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []int32{}
+	for rows.Next() {
+		var follower_id int32
+		if err := rows.Scan(&follower_id); err != nil {
+			return nil, err
+		}
+		items = append(items, follower_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMembershipEvents = `-- name: ListMembershipEvents :many
+SELECT id, organization_id, actor_id, user_id, action, role, previous_role, email, created
+FROM membership_events
+WHERE organization_id = $1
+ORDER BY id DESC
+LIMIT $2 OFFSET $3
+`
+
+type ListMembershipEventsParams struct {
+	OrganizationID int32 `json:"organization_id"`
+	Limit          int32 `json:"limit"`
+	Offset         int32 `json:"offset"`
+}
+
+func (q *Queries) ListMembershipEvents(ctx context.Context, arg ListMembershipEventsParams) ([]MembershipEvent, error) {
+	rows, err := q.query(ctx, q.listMembershipEventsStmt, listMembershipEvents, arg.OrganizationID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []MembershipEvent{}
+	for rows.Next() {
+		var i MembershipEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrganizationID,
+			&i.ActorID,
+			&i.UserID,
+			&i.Action,
+			&i.Role,
+			&i.PreviousRole,
+			&i.Email,
+			&i.Created,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMemberships = `-- name: ListMemberships :many
+SELECT m.user_id, u.username, m.role, m.created
+FROM memberships m
+JOIN users u ON u.id = m.user_id
+WHERE m.organization_id = $1
+ORDER BY m.created, m.user_id
+`
+
+type ListMembershipsRow struct {
+	UserID   int32        `json:"user_id"`
+	Username string       `json:"username"`
+	Role     string       `json:"role"`
+	Created  sql.NullTime `json:"created"`
+}
+
+func (q *Queries) ListMemberships(ctx context.Context, organizationID int32) ([]ListMembershipsRow, error) {
+	rows, err := q.query(ctx, q.listMembershipsStmt, listMemberships, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListMembershipsRow{}
+	for rows.Next() {
+		var i ListMembershipsRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.Username,
+			&i.Role,
+			&i.Created,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOrganizationBlogs = `-- name: ListOrganizationBlogs :many
+SELECT id, title, summary, user_id, status, publish_at, created, updated
+FROM blogs
+WHERE organization_id = $1
+ORDER BY created DESC, id DESC
+LIMIT $2 OFFSET $3
+`
+
+type ListOrganizationBlogsParams struct {
+	OrganizationID int32 `json:"organization_id"`
+	Limit          int32 `json:"limit"`
+	Offset         int32 `json:"offset"`
+}
+
+type ListOrganizationBlogsRow struct {
+	ID        int32        `json:"id"`
+	Title     string       `json:"title"`
+	Summary   string       `json:"summary"`
+	UserID    int32        `json:"user_id"`
+	Status    string       `json:"status"`
+	PublishAt sql.NullTime `json:"publish_at"`
+	Created   sql.NullTime `json:"created"`
+	Updated   sql.NullTime `json:"updated"`
+}
+
+// every status, for members
+func (q *Queries) ListOrganizationBlogs(ctx context.Context, arg ListOrganizationBlogsParams) ([]ListOrganizationBlogsRow, error) {
+	rows, err := q.query(ctx, q.listOrganizationBlogsStmt, listOrganizationBlogs, arg.OrganizationID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListOrganizationBlogsRow{}
+	for rows.Next() {
+		var i ListOrganizationBlogsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Summary,
+			&i.UserID,
+			&i.Status,
+			&i.PublishAt,
+			&i.Created,
+			&i.Updated,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOrganizationInvitations = `-- name: ListOrganizationInvitations :many
+SELECT id, organization_id, email, role, invited_by, expires_at, created
+FROM organization_invitations
+WHERE organization_id = $1 AND accepted_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+ORDER BY id DESC
+`
+
+type ListOrganizationInvitationsRow struct {
+	ID             int32         `json:"id"`
+	OrganizationID int32         `json:"organization_id"`
+	Email          string        `json:"email"`
+	Role           string        `json:"role"`
+	InvitedBy      sql.NullInt32 `json:"invited_by"`
+	ExpiresAt      time.Time     `json:"expires_at"`
+	Created        sql.NullTime  `json:"created"`
+}
+
+// invitations that can still be accepted
+func (q *Queries) ListOrganizationInvitations(ctx context.Context, organizationID int32) ([]ListOrganizationInvitationsRow, error) {
+	rows, err := q.query(ctx, q.listOrganizationInvitationsStmt, listOrganizationInvitations, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListOrganizationInvitationsRow{}
+	for rows.Next() {
+		var i ListOrganizationInvitationsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrganizationID,
+			&i.Email,
+			&i.Role,
+			&i.InvitedBy,
+			&i.ExpiresAt,
+			&i.Created,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listTags = `-- name: ListTags :many
+SELECT t.id, t.name, t.slug, COUNT(b.id) FILTER (WHERE b.status = 'published') AS usage_count
+FROM tags t
+LEFT JOIN blog_tags bt ON bt.tag_id = t.id
+LEFT JOIN blogs b ON b.id = bt.blog_id
+GROUP BY t.id
+ORDER BY usage_count DESC, t.slug
+`
+
+type ListTagsRow struct {
+	ID         int32  `json:"id"`
+	Name       string `json:"name"`
+	Slug       string `json:"slug"`
+	UsageCount int64  `json:"usage_count"`
+}
+
+func (q *Queries) ListTags(ctx context.Context) ([]ListTagsRow, error) {
+	rows, err := q.query(ctx, q.listTagsStmt, listTags)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListTagsRow{}
+	for rows.Next() {
+		var i ListTagsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Slug,
+			&i.UsageCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserOrganizations = `-- name: ListUserOrganizations :many
+SELECT o.id, o.name, o.slug, m.role, o.created
+FROM organizations o
+JOIN memberships m ON m.organization_id = o.id
+WHERE m.user_id = $1
+ORDER BY o.name, o.id
+`
+
+type ListUserOrganizationsRow struct {
+	ID      int32        `json:"id"`
+	Name    string       `json:"name"`
+	Slug    string       `json:"slug"`
+	Role    string       `json:"role"`
+	Created sql.NullTime `json:"created"`
+}
+
+func (q *Queries) ListUserOrganizations(ctx context.Context, userID int32) ([]ListUserOrganizationsRow, error) {
+	rows, err := q.query(ctx, q.listUserOrganizationsStmt, listUserOrganizations, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListUserOrganizationsRow{}
+	for rows.Next() {
+		var i ListUserOrganizationsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Slug,
+			&i.Role,
+			&i.Created,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsers = `-- name: ListUsers :many
+SELECT id, username, email, created, updated
+FROM users
+ORDER BY id
+`
+
+type ListUsersRow struct {
+	ID       int32        `json:"id"`
+	Username string       `json:"username"`
+	Email    string       `json:"email"`
+	Created  sql.NullTime `json:"created"`
+	Updated  sql.NullTime `json:"updated"`
 }
 
 func (q *Queries) ListUsers(ctx context.Context) ([]ListUsersRow, error) {
@@ -120,15 +2137,413 @@ func (q *Queries) ListUsers(ctx context.Context) ([]ListUsersRow, error) {
 		return nil, err
 	}
 	defer rows.Close()
-	items := []ListUsersRow{}
+	items := []ListUsersRow{}
+	for rows.Next() {
+		var i ListUsersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.Email,
+			&i.Created,
+			&i.Updated,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsersByEmail = `-- name: ListUsersByEmail :many
+SELECT id, username, email
+FROM users
+WHERE lower(email) = lower($1) AND disabled_at IS NULL
+ORDER BY id
+LIMIT 2
+`
+
+type ListUsersByEmailRow struct {
+	ID       int32  `json:"id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+// emails are matched ignoring case, disabled accounts are left out. a database from before the unique
+// index can have more than one account for an email, two rows are enough to tell
+func (q *Queries) ListUsersByEmail(ctx context.Context, email string) ([]ListUsersByEmailRow, error) {
+	rows, err := q.query(ctx, q.listUsersByEmailStmt, listUsersByEmail, email)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListUsersByEmailRow{}
+	for rows.Next() {
+		var i ListUsersByEmailRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.Email,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhookDeliveries = `-- name: ListWebhookDeliveries :many
+SELECT id, webhook_id, event, payload, status, attempts, next_attempt_at, last_status_code, last_error, delivered_at, created, updated
+FROM webhook_deliveries
+WHERE webhook_id = $1
+ORDER BY id DESC
+LIMIT $2 OFFSET $3
+`
+
+type ListWebhookDeliveriesParams struct {
+	WebhookID int32 `json:"webhook_id"`
+	Limit     int32 `json:"limit"`
+	Offset    int32 `json:"offset"`
+}
+
+func (q *Queries) ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	rows, err := q.query(ctx, q.listWebhookDeliveriesStmt, listWebhookDeliveries, arg.WebhookID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []WebhookDelivery{}
 	for rows.Next() {
-		var i ListUsersRow
+		var i WebhookDelivery
 		if err := rows.Scan(
 			&i.ID,
-			&i.Username,
-			&i.Email,
+			&i.WebhookID,
+			&i.Event,
+			&i.Payload,
+			&i.Status,
+			&i.Attempts,
+			&i.NextAttemptAt,
+			&i.LastStatusCode,
+			&i.LastError,
+			&i.DeliveredAt,
+			&i.Created,
+			&i.Updated,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWebhooks = `-- name: ListWebhooks :many
+SELECT id, user_id, url, events, active, created, updated
+FROM webhooks
+ORDER BY id
+`
+
+type ListWebhooksRow struct {
+	ID      int32        `json:"id"`
+	UserID  int32        `json:"user_id"`
+	Url     string       `json:"url"`
+	Events  []string     `json:"events"`
+	Active  bool         `json:"active"`
+	Created sql.NullTime `json:"created"`
+	Updated sql.NullTime `json:"updated"`
+}
+
+func (q *Queries) ListWebhooks(ctx context.Context) ([]ListWebhooksRow, error) {
+	rows, err := q.query(ctx, q.listWebhooksStmt, listWebhooks)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListWebhooksRow{}
+	for rows.Next() {
+		var i ListWebhooksRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Url,
+			pq.Array(&i.Events),
+			&i.Active,
+			&i.Created,
+			&i.Updated,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockOrganization = `-- name: LockOrganization :exec
+SELECT id
+FROM organizations
+WHERE id = $1
+FOR UPDATE
+`
+
+// held while memberships change so two owners can't demote each other and leave nobody in charge
+func (q *Queries) LockOrganization(ctx context.Context, id int32) error {
+	_, err := q.exec(ctx, q.lockOrganizationStmt, lockOrganization, id)
+	return err
+}
+
+const markWebhookDeliveryFailed = `-- name: MarkWebhookDeliveryFailed :exec
+UPDATE webhook_deliveries
+SET status = $2, last_status_code = $3, last_error = $4, next_attempt_at = $5, updated = CURRENT_TIMESTAMP
+WHERE id = $1
+`
+
+type MarkWebhookDeliveryFailedParams struct {
+	ID             int32          `json:"id"`
+	Status         string         `json:"status"`
+	LastStatusCode sql.NullInt32  `json:"last_status_code"`
+	LastError      sql.NullString `json:"last_error"`
+	NextAttemptAt  time.Time      `json:"next_attempt_at"`
+}
+
+// status stays pending with a later next_attempt_at for a retry, or becomes dead once attempts run out
+func (q *Queries) MarkWebhookDeliveryFailed(ctx context.Context, arg MarkWebhookDeliveryFailedParams) error {
+	_, err := q.exec(ctx, q.markWebhookDeliveryFailedStmt, markWebhookDeliveryFailed,
+		arg.ID,
+		arg.Status,
+		arg.LastStatusCode,
+		arg.LastError,
+		arg.NextAttemptAt,
+	)
+	return err
+}
+
+const markWebhookDeliverySucceeded = `-- name: MarkWebhookDeliverySucceeded :exec
+UPDATE webhook_deliveries
+SET status = 'succeeded', last_status_code = $2, last_error = NULL, delivered_at = CURRENT_TIMESTAMP, updated = CURRENT_TIMESTAMP
+WHERE id = $1
+`
+
+type MarkWebhookDeliverySucceededParams struct {
+	ID             int32         `json:"id"`
+	LastStatusCode sql.NullInt32 `json:"last_status_code"`
+}
+
+func (q *Queries) MarkWebhookDeliverySucceeded(ctx context.Context, arg MarkWebhookDeliverySucceededParams) error {
+	_, err := q.exec(ctx, q.markWebhookDeliverySucceededStmt, markWebhookDeliverySucceeded, arg.ID, arg.LastStatusCode)
+	return err
+}
+
+const publishDueBlogs = `-- name: PublishDueBlogs :many
+UPDATE blogs
+SET status = 'published', updated = CURRENT_TIMESTAMP
+WHERE id IN (
+	SELECT id FROM blogs
+	WHERE status = 'scheduled' AND publish_at <= CURRENT_TIMESTAMP
+	ORDER BY publish_at
+	LIMIT $1
+	FOR UPDATE SKIP LOCKED
+)
+	RETURNING id, title, user_id, publish_at
+`
+
+type PublishDueBlogsRow struct {
+	ID        int32        `json:"id"`
+	Title     string       `json:"title"`
+	UserID    int32        `json:"user_id"`
+	PublishAt sql.NullTime `json:"publish_at"`
+}
+
+// SKIP LOCKED lets several instances run this at once, each row is claimed by exactly one of them
+func (q *Queries) PublishDueBlogs(ctx context.Context, limit int32) ([]PublishDueBlogsRow, error) {
+	rows, err := q.query(ctx, q.publishDueBlogsStmt, publishDueBlogs, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []PublishDueBlogsRow{}
+	for rows.Next() {
+		var i PublishDueBlogsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.UserID,
+			&i.PublishAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const redeliverWebhookDelivery = `-- name: RedeliverWebhookDelivery :one
+UPDATE webhook_deliveries
+SET status = 'pending', attempts = 0, next_attempt_at = CURRENT_TIMESTAMP, updated = CURRENT_TIMESTAMP
+WHERE id = $1 AND webhook_id = $2
+RETURNING id, webhook_id, event, payload, status, attempts, next_attempt_at, last_status_code, last_error, delivered_at, created, updated
+`
+
+type RedeliverWebhookDeliveryParams struct {
+	ID        int32 `json:"id"`
+	WebhookID int32 `json:"webhook_id"`
+}
+
+// queues a delivery again with a fresh set of attempts, whatever state it ended in
+func (q *Queries) RedeliverWebhookDelivery(ctx context.Context, arg RedeliverWebhookDeliveryParams) (WebhookDelivery, error) {
+	row := q.queryRow(ctx, q.redeliverWebhookDeliveryStmt, redeliverWebhookDelivery, arg.ID, arg.WebhookID)
+	var i WebhookDelivery
+	err := row.Scan(
+		&i.ID,
+		&i.WebhookID,
+		&i.Event,
+		&i.Payload,
+		&i.Status,
+		&i.Attempts,
+		&i.NextAttemptAt,
+		&i.LastStatusCode,
+		&i.LastError,
+		&i.DeliveredAt,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const removeBlogReaction = `-- name: RemoveBlogReaction :execrows
+DELETE FROM blog_reactions
+WHERE user_id = $1 AND blog_id = $2 AND kind = $3
+`
+
+type RemoveBlogReactionParams struct {
+	UserID int32  `json:"user_id"`
+	BlogID int32  `json:"blog_id"`
+	Kind   string `json:"kind"`
+}
+
+func (q *Queries) RemoveBlogReaction(ctx context.Context, arg RemoveBlogReactionParams) (int64, error) {
+	result, err := q.exec(ctx, q.removeBlogReactionStmt, removeBlogReaction, arg.UserID, arg.BlogID, arg.Kind)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const revokeApiKey = `-- name: RevokeApiKey :execrows
+UPDATE api_keys
+SET revoked_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+`
+
+type RevokeApiKeyParams struct {
+	ID     int32 `json:"id"`
+	UserID int32 `json:"user_id"`
+}
+
+func (q *Queries) RevokeApiKey(ctx context.Context, arg RevokeApiKeyParams) (int64, error) {
+	result, err := q.exec(ctx, q.revokeApiKeyStmt, revokeApiKey, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const revokeUserApiKeys = `-- name: RevokeUserApiKeys :execrows
+UPDATE api_keys
+SET revoked_at = CURRENT_TIMESTAMP
+WHERE user_id = $1 AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeUserApiKeys(ctx context.Context, userID int32) (int64, error) {
+	result, err := q.exec(ctx, q.revokeUserApiKeysStmt, revokeUserApiKeys, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const searchBlogs = `-- name: SearchBlogs :many
+SELECT id, title, user_id, created, updated,
+	ts_rank(search_vector, websearch_to_tsquery('english', $1))::real AS rank,
+	ts_headline('english', content, websearch_to_tsquery('english', $1),
+		'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=30, MinWords=10')::text AS snippet,
+	COUNT(*) OVER() AS total
+FROM blogs
+WHERE status = 'published'
+	AND search_vector @@ websearch_to_tsquery('english', $1)
+	AND ($2::int IS NULL OR user_id = $2)
+ORDER BY rank DESC, id DESC
+LIMIT $3 OFFSET $4
+`
+
+type SearchBlogsParams struct {
+	Query     string        `json:"query"`
+	AuthorID  sql.NullInt32 `json:"author_id"`
+	RowLimit  int32         `json:"row_limit"`
+	RowOffset int32         `json:"row_offset"`
+}
+
+type SearchBlogsRow struct {
+	ID      int32        `json:"id"`
+	Title   string       `json:"title"`
+	UserID  int32        `json:"user_id"`
+	Created sql.NullTime `json:"created"`
+	Updated sql.NullTime `json:"updated"`
+	Rank    float32      `json:"rank"`
+	Snippet string       `json:"snippet"`
+	Total   int64        `json:"total"`
+}
+
+func (q *Queries) SearchBlogs(ctx context.Context, arg SearchBlogsParams) ([]SearchBlogsRow, error) {
+	rows, err := q.query(ctx, q.searchBlogsStmt, searchBlogs,
+		arg.Query,
+		arg.AuthorID,
+		arg.RowLimit,
+		arg.RowOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SearchBlogsRow{}
+	for rows.Next() {
+		var i SearchBlogsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.UserID,
 			&i.Created,
 			&i.Updated,
+			&i.Rank,
+			&i.Snippet,
+			&i.Total,
 		); err != nil {
 			return nil, err
 		}
@@ -142,3 +2557,260 @@ func (q *Queries) ListUsers(ctx context.Context) ([]ListUsersRow, error) {
 	}
 	return items, nil
 }
+
+const setUserRole = `-- name: SetUserRole :execrows
+UPDATE users
+SET role = $1, updated = CURRENT_TIMESTAMP
+WHERE id = $2
+`
+
+type SetUserRoleParams struct {
+	Role string `json:"role"`
+	ID   int32  `json:"id"`
+}
+
+func (q *Queries) SetUserRole(ctx context.Context, arg SetUserRoleParams) (int64, error) {
+	result, err := q.exec(ctx, q.setUserRoleStmt, setUserRole, arg.Role, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const touchApiKey = `-- name: TouchApiKey :exec
+UPDATE api_keys
+SET last_used_at = CURRENT_TIMESTAMP
+WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < CURRENT_TIMESTAMP - INTERVAL '1 minute')
+`
+
+// written at most once a minute per key so busy clients don't turn every request into a write
+func (q *Queries) TouchApiKey(ctx context.Context, id int32) error {
+	_, err := q.exec(ctx, q.touchApiKeyStmt, touchApiKey, id)
+	return err
+}
+
+const unfollowUser = `-- name: UnfollowUser :execrows
+DELETE FROM follows
+WHERE follower_id = $1 AND followee_id = $2
+`
+
+type UnfollowUserParams struct {
+	FollowerID int32 `json:"follower_id"`
+	FolloweeID int32 `json:"followee_id"`
+}
+
+func (q *Queries) UnfollowUser(ctx context.Context, arg UnfollowUserParams) (int64, error) {
+	result, err := q.exec(ctx, q.unfollowUserStmt, unfollowUser, arg.FollowerID, arg.FolloweeID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateBlog = `-- name: UpdateBlog :one
+UPDATE blogs
+SET title = $2, content = $3, summary = $4, status = $5, publish_at = $6, updated = CURRENT_TIMESTAMP
+WHERE id = $1 AND updated = $7
+	RETURNING id, title, content, summary, user_id, status, publish_at, created, updated, organization_id
+`
+
+type UpdateBlogParams struct {
+	ID        int32        `json:"id"`
+	Title     string       `json:"title"`
+	Content   string       `json:"content"`
+	Summary   string       `json:"summary"`
+	Status    string       `json:"status"`
+	PublishAt sql.NullTime `json:"publish_at"`
+	Updated   sql.NullTime `json:"updated"`
+}
+
+type UpdateBlogRow struct {
+	ID             int32         `json:"id"`
+	Title          string        `json:"title"`
+	Content        string        `json:"content"`
+	Summary        string        `json:"summary"`
+	UserID         int32         `json:"user_id"`
+	Status         string        `json:"status"`
+	PublishAt      sql.NullTime  `json:"publish_at"`
+	Created        sql.NullTime  `json:"created"`
+	Updated        sql.NullTime  `json:"updated"`
+	OrganizationID sql.NullInt32 `json:"organization_id"`
+}
+
+// only updates the version the editor last saw, no row means someone else saved first
+func (q *Queries) UpdateBlog(ctx context.Context, arg UpdateBlogParams) (UpdateBlogRow, error) {
+	row := q.queryRow(ctx, q.updateBlogStmt, updateBlog,
+		arg.ID,
+		arg.Title,
+		arg.Content,
+		arg.Summary,
+		arg.Status,
+		arg.PublishAt,
+		arg.Updated,
+	)
+	var i UpdateBlogRow
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Content,
+		&i.Summary,
+		&i.UserID,
+		&i.Status,
+		&i.PublishAt,
+		&i.Created,
+		&i.Updated,
+		&i.OrganizationID,
+	)
+	return i, err
+}
+
+const updateBlogComment = `-- name: UpdateBlogComment :one
+UPDATE blog_comments
+SET content = $2, updated = CURRENT_TIMESTAMP
+WHERE id = $1 AND user_id = $3
+	RETURNING id, blog_id, user_id, parent_id, content, created, updated
+`
+
+type UpdateBlogCommentParams struct {
+	ID      int32  `json:"id"`
+	Content string `json:"content"`
+	UserID  int32  `json:"user_id"`
+}
+
+func (q *Queries) UpdateBlogComment(ctx context.Context, arg UpdateBlogCommentParams) (BlogComment, error) {
+	row := q.queryRow(ctx, q.updateBlogCommentStmt, updateBlogComment, arg.ID, arg.Content, arg.UserID)
+	var i BlogComment
+	err := row.Scan(
+		&i.ID,
+		&i.BlogID,
+		&i.UserID,
+		&i.ParentID,
+		&i.Content,
+		&i.Created,
+		&i.Updated,
+	)
+	return i, err
+}
+
+const updateMembershipRole = `-- name: UpdateMembershipRole :exec
+UPDATE memberships
+SET role = $3, updated = CURRENT_TIMESTAMP
+WHERE organization_id = $1 AND user_id = $2
+`
+
+type UpdateMembershipRoleParams struct {
+	OrganizationID int32  `json:"organization_id"`
+	UserID         int32  `json:"user_id"`
+	Role           string `json:"role"`
+}
+
+func (q *Queries) UpdateMembershipRole(ctx context.Context, arg UpdateMembershipRoleParams) error {
+	_, err := q.exec(ctx, q.updateMembershipRoleStmt, updateMembershipRole, arg.OrganizationID, arg.UserID, arg.Role)
+	return err
+}
+
+const updateUserAvatar = `-- name: UpdateUserAvatar :execrows
+UPDATE users
+SET avatar_key = $2, updated = CURRENT_TIMESTAMP
+WHERE id = $1 AND updated IS NOT DISTINCT FROM $3
+`
+
+type UpdateUserAvatarParams struct {
+	ID        int32          `json:"id"`
+	AvatarKey sql.NullString `json:"avatar_key"`
+	Updated   sql.NullTime   `json:"updated"`
+}
+
+// zero rows means the profile changed since the client fetched it. users registered before updated was
+// set on create have it NULL, which has to match too
+func (q *Queries) UpdateUserAvatar(ctx context.Context, arg UpdateUserAvatarParams) (int64, error) {
+	result, err := q.exec(ctx, q.updateUserAvatarStmt, updateUserAvatar, arg.ID, arg.AvatarKey, arg.Updated)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const upgradePasswordHash = `-- name: UpgradePasswordHash :exec
+UPDATE users
+SET password = $1
+WHERE id = $2 AND password = $3
+`
+
+type UpgradePasswordHashParams struct {
+	NewHash string `json:"new_hash"`
+	ID      int32  `json:"id"`
+	OldHash string `json:"old_hash"`
+}
+
+// only replaces the hash it was computed from, a password change in between wins
+func (q *Queries) UpgradePasswordHash(ctx context.Context, arg UpgradePasswordHashParams) error {
+	_, err := q.exec(ctx, q.upgradePasswordHashStmt, upgradePasswordHash, arg.NewHash, arg.ID, arg.OldHash)
+	return err
+}
+
+const upsertTag = `-- name: UpsertTag :one
+INSERT INTO tags(name, slug)
+VALUES ($1, $2)
+ON CONFLICT (slug) DO UPDATE SET slug = EXCLUDED.slug
+	RETURNING id, name, slug, created
+`
+
+type UpsertTagParams struct {
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
+func (q *Queries) UpsertTag(ctx context.Context, arg UpsertTagParams) (Tag, error) {
+	row := q.queryRow(ctx, q.upsertTagStmt, upsertTag, arg.Name, arg.Slug)
+	var i Tag
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Slug,
+		&i.Created,
+	)
+	return i, err
+}
+
+const upsertUserTotp = `-- name: UpsertUserTotp :execrows
+INSERT INTO user_totp (user_id, secret)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE
+SET secret = EXCLUDED.secret, created = CURRENT_TIMESTAMP
+WHERE user_totp.confirmed_at IS NULL
+`
+
+type UpsertUserTotpParams struct {
+	UserID int32  `json:"user_id"`
+	Secret string `json:"secret"`
+}
+
+// a confirmed secret is never replaced, zero rows means 2fa is already on
+func (q *Queries) UpsertUserTotp(ctx context.Context, arg UpsertUserTotpParams) (int64, error) {
+	result, err := q.exec(ctx, q.upsertUserTotpStmt, upsertUserTotp, arg.UserID, arg.Secret)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const useRecoveryCode = `-- name: UseRecoveryCode :execrows
+UPDATE user_recovery_codes
+SET used_at = CURRENT_TIMESTAMP
+WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+`
+
+type UseRecoveryCodeParams struct {
+	UserID   int32  `json:"user_id"`
+	CodeHash string `json:"code_hash"`
+}
+
+// the used_at check makes each code single use even with concurrent logins
+func (q *Queries) UseRecoveryCode(ctx context.Context, arg UseRecoveryCodeParams) (int64, error) {
+	result, err := q.exec(ctx, q.useRecoveryCodeStmt, useRecoveryCode, arg.UserID, arg.CodeHash)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,96 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/exzacter/gorestapi/internal/store"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WrapDB returns a store.DBTX that starts a span for every query, so tracing needs no change to the
+// generated code. works for *sql.DB and *sql.Tx alike:
+//
+//	queries := store.New(tracing.WrapDB(db))
+//	qtx := store.New(tracing.WrapDB(tx))
+func WrapDB(db store.DBTX) store.DBTX {
+	return &tracedDB{db: db}
+}
+
+type tracedDB struct {
+	db store.DBTX
+}
+
+func (t *tracedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := startQuery(ctx, query)
+	defer span.End()
+
+	result, err := t.db.ExecContext(ctx, query, args...)
+	recordError(span, err)
+
+	return result, err
+}
+
+func (t *tracedDB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	ctx, span := startQuery(ctx, query)
+	defer span.End()
+
+	stmt, err := t.db.PrepareContext(ctx, query)
+	recordError(span, err)
+
+	return stmt, err
+}
+
+// the span covers running the query, not reading the rows afterwards
+func (t *tracedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := startQuery(ctx, query)
+	defer span.End()
+
+	rows, err := t.db.QueryContext(ctx, query, args...)
+	recordError(span, err)
+
+	return rows, err
+}
+
+func (t *tracedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := startQuery(ctx, query)
+	defer span.End()
+
+	row := t.db.QueryRowContext(ctx, query, args...)
+	// Err reports a failed query without consuming the row, sql.ErrNoRows only shows up on Scan
+	recordError(span, row.Err())
+
+	return row
+}
+
+// sqlc starts every query with "-- name: GetBlog :one", that name becomes the span name.
+// only the query text is recorded, never the argument values
+func startQuery(ctx context.Context, query string) (context.Context, trace.Span) {
+	name := "db.query"
+	if first, _, _ := strings.Cut(query, "\n"); strings.HasPrefix(first, "-- name: ") {
+		if fields := strings.Fields(strings.TrimPrefix(first, "-- name: ")); len(fields) > 0 {
+			name = fields[0]
+		}
+	}
+
+	return tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNamePostgreSQL,
+			semconv.DBOperationName(name),
+			semconv.DBQueryText(query),
+		),
+	)
+}
+
+func recordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -0,0 +1,61 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/exzacter/gorestapi/internal/metrics"
+	"github.com/exzacter/gorestapi/internal/serverconfig"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+)
+
+const instrumentationName = "github.com/exzacter/gorestapi"
+
+// backed by the global provider, spans are no-ops until Setup installs a real one
+var tracer = otel.Tracer(instrumentationName)
+
+// Setup installs the tracer provider picked by TRACING_EXPORTER:
+//   - "none" keeps the no-op provider, incoming traceparent headers are still passed along
+//   - "stdout" prints finished spans, handy locally
+//   - "otlp" sends spans over OTLP/HTTP, configured by the standard OTEL_EXPORTER_OTLP_* env vars
+//
+// the returned func flushes buffered spans and should be called on shutdown
+func Setup(ctx context.Context, config *serverconfig.Config) (func(context.Context) error, error) {
+	// W3C traceparent and baggage, in and out
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+
+	switch config.TracingExporter {
+	case "none":
+		return func(context.Context) error { return nil }, nil
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case "otlp":
+		exporter, err = otlptracehttp.New(ctx)
+	default:
+		return nil, fmt.Errorf("Unknown TRACING_EXPORTER %q, expected none, stdout or otlp", config.TracingExporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error creating %s trace exporter: %v", config.TracingExporter, err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceName(config.TracingServiceName),
+			semconv.ServiceVersion(metrics.Version),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
@@ -18,3 +18,8 @@ func RespondWithError(w http.ResponseWriter, code int, message string) {
 func RespondWithNotFound(w http.ResponseWriter) {
 	RespondWithError(w, http.StatusNotFound, "Resource not found")
 }
+
+// RespondWithPreconditionFailed is the 412 for an If-Match that no longer matches
+func RespondWithPreconditionFailed(w http.ResponseWriter) {
+	RespondWithError(w, http.StatusPreconditionFailed, "This was changed since you fetched it, fetch it again and reapply your changes")
+}
@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Version names one saved state of a row. it changes whenever the row's updated timestamp does, and
+// the timestamp is what updates compare against in sql. a row never updated since before the column was
+// set has a NULL timestamp, which is version 0
+func Version(kind string, id int32, updated sql.NullTime) string {
+	if !updated.Valid {
+		return fmt.Sprintf("%s-%d-0", kind, id)
+	}
+	return fmt.Sprintf("%s-%d-%d", kind, id, updated.Time.UnixMicro())
+}
+
+// ETag is the strong etag for a version. variant tells apart the bodies one url can return, such as
+// html and markdown, and can be empty
+func ETag(version, variant string) string {
+	if variant == "" {
+		return `"` + version + `"`
+	}
+	return `"` + version + "." + variant + `"`
+}
+
+// NotModified sets the ETag header and answers 304 when If-None-Match already has it. the handler
+// should return when it reports true
+func NotModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	w.Header().Set("ETag", etag)
+
+	for _, tag := range etagList(r.Header.Get("If-None-Match")) {
+		// If-None-Match uses weak comparison, W/ is ignored
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+
+	return false
+}
+
+// RequireIfMatch answers 428 when there is no If-Match header and 412 when it doesn't name the current
+// version, in any variant. the handler should return when it reports false. the update itself must
+// still check the version in sql, this only saves work on a mismatch that is already known
+func RequireIfMatch(w http.ResponseWriter, r *http.Request, version string) bool {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		RespondWithError(w, http.StatusPreconditionRequired, "If-Match header is required, send the ETag from your last GET")
+		return false
+	}
+
+	for _, tag := range etagList(header) {
+		if tag == "*" {
+			return true
+		}
+
+		// strong comparison, a weak tag never matches
+		if strings.HasPrefix(tag, "W/") {
+			continue
+		}
+		if tagVersion, _, _ := strings.Cut(strings.Trim(tag, `"`), "."); tagVersion == version {
+			return true
+		}
+	}
+
+	RespondWithPreconditionFailed(w)
+	return false
+}
+
+// splits a comma separated etag header
+func etagList(header string) []string {
+	var tags []string
+	for _, tag := range strings.Split(header, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestVersion(t *testing.T) {
+	updated := time.Date(2026, 1, 2, 3, 4, 5, 6000, time.UTC)
+
+	tests := []struct {
+		name    string
+		updated sql.NullTime
+		want    string
+	}{
+		{"updated", sql.NullTime{Time: updated, Valid: true}, "blog-7-1767323045000006"},
+		{"never updated", sql.NullTime{}, "blog-7-0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Version("blog", 7, tt.updated); got != tt.want {
+				t.Fatalf("Version = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	etag := ETag("blog-7-100", "html")
+
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		want        bool
+	}{
+		{"no header", "", false},
+		{"same tag", etag, true},
+		{"weak tag of the same version", "W/" + etag, true},
+		{"in a list", `"blog-7-99", ` + etag, true},
+		{"any", "*", true},
+		{"older version", ETag("blog-7-99", "html"), false},
+		{"other variant", ETag("blog-7-100", "markdown"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/blogs/7", nil)
+			if tt.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			rec := httptest.NewRecorder()
+
+			got := NotModified(rec, req, etag)
+			if got != tt.want {
+				t.Fatalf("NotModified = %v, want %v", got, tt.want)
+			}
+			if rec.Header().Get("ETag") != etag {
+				t.Fatalf("ETag header = %q, want %q", rec.Header().Get("ETag"), etag)
+			}
+			if tt.want && rec.Code != http.StatusNotModified {
+				t.Fatalf("status = %d, want 304", rec.Code)
+			}
+		})
+	}
+}
+
+func TestRequireIfMatch(t *testing.T) {
+	version := "blog-7-100"
+
+	tests := []struct {
+		name    string
+		ifMatch string
+		// 0 when the request may go ahead
+		status int
+	}{
+		{"no header", "", http.StatusPreconditionRequired},
+		{"current version", ETag(version, ""), 0},
+		// any body of the version will do, the update replaces them all
+		{"current version in another variant", ETag(version, "html-1a2b3c4d"), 0},
+		{"compressed variant", ETag(version, "markdown.gzip"), 0},
+		{"in a list", `"blog-7-99", ` + ETag(version, ""), 0},
+		{"any", "*", 0},
+		{"older version", ETag("blog-7-99", ""), http.StatusPreconditionFailed},
+		{"weak tag", "W/" + ETag(version, ""), http.StatusPreconditionFailed},
+		{"another blog", ETag("blog-8-100", ""), http.StatusPreconditionFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/blogs/7", nil)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			rec := httptest.NewRecorder()
+
+			ok := RequireIfMatch(rec, req, version)
+			if ok != (tt.status == 0) {
+				t.Fatalf("RequireIfMatch = %v, want %v", ok, tt.status == 0)
+			}
+			if !ok && rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+)
+
+const (
+	DefaultPageLimit = 10
+	MaxPageLimit     = 100
+)
+
+// ParsePagination reads ?page= and ?limit= from the request and returns the page along with
+// the limit and offset to pass to a query. Missing or invalid values fall back to page 1 and
+// the default limit.
+func ParsePagination(r *http.Request) (page, limit, offset int32) {
+	page, limit = 1, DefaultPageLimit
+
+	if p, err := strconv.Atoi(r.URL.Query().Get("page")); err == nil && p > 0 && p <= math.MaxInt32/MaxPageLimit {
+		page = int32(p)
+	}
+
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 {
+		limit = int32(min(l, MaxPageLimit))
+	}
+
+	return page, limit, (page - 1) * limit
+}
@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Slugify lowercases the string and collapses anything that isn't a letter or digit into a single dash,
+// so "Go  API!" and "go-api" both become "go-api"
+func Slugify(s string) string {
+	var b strings.Builder
+	dash := false
+
+	for _, r := range strings.ToLower(strings.TrimSpace(s)) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// SlugifyAll slugs every value, dropping empties and duplicates while keeping the original order
+func SlugifyAll(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	slugs := make([]string, 0, len(values))
+
+	for _, v := range values {
+		slug := Slugify(v)
+		if slug == "" || seen[slug] {
+			continue
+		}
+		seen[slug] = true
+		slugs = append(slugs, slug)
+	}
+
+	return slugs
+}
@@ -0,0 +1,64 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/exzacter/gorestapi/internal/store"
+)
+
+// events a webhook can subscribe to
+const (
+	EventUserCreated    = "user.created"
+	EventBlogPublished  = "blog.published"
+	EventCommentCreated = "comment.created"
+)
+
+var Events = []string{EventUserCreated, EventBlogPublished, EventCommentCreated}
+
+// the body of every delivery
+type Envelope struct {
+	Event      string      `json:"event"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	Data       interface{} `json:"data"`
+}
+
+type UserCreated struct {
+	ID       int32  `json:"id"`
+	Username string `json:"username"`
+}
+
+type BlogPublished struct {
+	ID        int32     `json:"id"`
+	Title     string    `json:"title"`
+	UserID    int32     `json:"user_id"`
+	PublishAt time.Time `json:"publish_at"`
+}
+
+type CommentCreated struct {
+	ID       int32  `json:"id"`
+	BlogID   int32  `json:"blog_id"`
+	UserID   int32  `json:"user_id"`
+	ParentID *int32 `json:"parent_id"`
+	Content  string `json:"content"`
+}
+
+// Enqueue queues a delivery of the event for every webhook subscribed to it. pass queries bound to the
+// transaction making the change, then the event is stored if and only if the change is
+func Enqueue(ctx context.Context, q *store.Queries, event string, data interface{}) error {
+	payload, err := json.Marshal(Envelope{
+		Event:      event,
+		OccurredAt: time.Now().UTC(),
+		Data:       data,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = q.EnqueueWebhookDeliveries(ctx, store.EnqueueWebhookDeliveriesParams{
+		Event:   event,
+		Payload: payload,
+	})
+	return err
+}
@@ -0,0 +1,39 @@
+package webhooks
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy decides when a failed delivery is tried again and when it is given up on
+type RetryPolicy struct {
+	MaxAttempts int32
+	// wait after the first failure, doubled after each one after that
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// the default gives up after 8 attempts, roughly an hour after the first
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 8,
+	BaseDelay:   30 * time.Second,
+	MaxDelay:    time.Hour,
+}
+
+// Next returns how long to wait after the given attempt failed, or dead when it was the last one.
+// up to a fifth of jitter is added so receivers coming back from an outage aren't hit all at once
+func (p RetryPolicy) Next(attempts int32) (delay time.Duration, dead bool) {
+	if attempts >= p.MaxAttempts {
+		return 0, true
+	}
+
+	delay = p.BaseDelay
+	for i := int32(1); i < attempts && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	return delay + rand.N(delay/5+1), false
+}
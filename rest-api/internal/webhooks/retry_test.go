@@ -0,0 +1,42 @@
+package webhooks
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyNext(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 6, BaseDelay: 10 * time.Second, MaxDelay: time.Minute}
+
+	tests := []struct {
+		attempts int32
+		base     time.Duration
+	}{
+		{1, 10 * time.Second},
+		{2, 20 * time.Second},
+		{3, 40 * time.Second},
+		// capped from here on
+		{4, time.Minute},
+		{5, time.Minute},
+	}
+
+	for _, tt := range tests {
+		delay, dead := policy.Next(tt.attempts)
+		if dead {
+			t.Fatalf("attempt %d: dead before MaxAttempts", tt.attempts)
+		}
+		if delay < tt.base || delay > tt.base+tt.base/5 {
+			t.Fatalf("attempt %d: delay %v outside [%v, %v]", tt.attempts, delay, tt.base, tt.base+tt.base/5)
+		}
+	}
+}
+
+func TestRetryPolicyDead(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Second, MaxDelay: time.Minute}
+
+	for _, attempts := range []int32{3, 4} {
+		if delay, dead := policy.Next(attempts); !dead || delay != 0 {
+			t.Fatalf("attempt %d: Next = %v, %v, want 0, true", attempts, delay, dead)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package webhooks
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSendSignsDelivery(t *testing.T) {
+	secret := "whsec_test"
+	payload := []byte(`{"blog_id":7}`)
+
+	received := make(chan error, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err == nil {
+			err = Verify(secret, r.Header.Get(HeaderTimestamp), r.Header.Get(HeaderSignature), body, time.Minute)
+		}
+		if r.Header.Get(HeaderEvent) != "blog.published" || r.Header.Get(HeaderDelivery) != "42" {
+			t.Errorf("got event %q delivery %q", r.Header.Get(HeaderEvent), r.Header.Get(HeaderDelivery))
+		}
+		received <- err
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer receiver.Close()
+
+	status, err := NewSender(time.Second).Send(context.Background(), receiver.URL, secret, 42, "blog.published", payload)
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if status != http.StatusAccepted {
+		t.Fatalf("status = %d, want %d", status, http.StatusAccepted)
+	}
+	if err := <-received; err != nil {
+		t.Fatalf("receiver could not verify the delivery: %v", err)
+	}
+}
+
+func TestSendFailures(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		status  int
+	}{
+		{"server error", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}, http.StatusInternalServerError},
+		// following it would post the signed payload somewhere that was never registered
+		{"redirect", func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, "https://elsewhere.example.com/hook", http.StatusFound)
+		}, http.StatusFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			receiver := httptest.NewServer(tt.handler)
+			defer receiver.Close()
+
+			status, err := NewSender(time.Second).Send(context.Background(), receiver.URL, "whsec_test", 1, "blog.created", []byte(`{}`))
+			if err == nil {
+				t.Fatal("Send succeeded, want an error")
+			}
+			if status != tt.status {
+				t.Fatalf("status = %d, want %d", status, tt.status)
+			}
+		})
+	}
+}
+
+func TestSendTimeout(t *testing.T) {
+	release := make(chan struct{})
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer receiver.Close()
+	defer close(release)
+
+	status, err := NewSender(50*time.Millisecond).Send(context.Background(), receiver.URL, "whsec_test", 1, "blog.created", []byte(`{}`))
+	if err == nil {
+		t.Fatal("Send succeeded, want a timeout")
+	}
+	if status != 0 {
+		t.Fatalf("status = %d, want 0 without a response", status)
+	}
+}
@@ -0,0 +1,63 @@
+package webhooks
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignVerifyRoundTrip(t *testing.T) {
+	secret, err := NewSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(secret, "whsec_") {
+		t.Fatalf("secret %q is missing the whsec_ prefix", secret)
+	}
+
+	body := []byte(`{"event":"blog.created"}`)
+	now := time.Now().Unix()
+	signature := Sign(secret, now, body)
+
+	if !strings.HasPrefix(signature, "sha256=") {
+		t.Fatalf("signature %q is missing the sha256= prefix", signature)
+	}
+	if err := Verify(secret, strconv.FormatInt(now, 10), signature, body, 5*time.Minute); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	secret := "whsec_test"
+	body := []byte(`{"id":1}`)
+	now := time.Now().Unix()
+	stamp := strconv.FormatInt(now, 10)
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		signature string
+		body      []byte
+		want      error
+	}{
+		{"tampered body", secret, stamp, Sign(secret, now, body), []byte(`{"id":2}`), ErrInvalidSignature},
+		{"wrong secret", "whsec_other", stamp, Sign(secret, now, body), body, ErrInvalidSignature},
+		// the timestamp is signed, moving it forward breaks the signature
+		{"replayed with a new timestamp", secret, strconv.FormatInt(now+1, 10), Sign(secret, now, body), body, ErrInvalidSignature},
+		{"malformed timestamp", secret, "yesterday", Sign(secret, now, body), body, ErrInvalidSignature},
+		{"too old", secret, strconv.FormatInt(now-3600, 10), Sign(secret, now-3600, body), body, ErrStaleTimestamp},
+		{"too far ahead", secret, strconv.FormatInt(now+3600, 10), Sign(secret, now+3600, body), body, ErrStaleTimestamp},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.secret, tt.timestamp, tt.signature, tt.body, 5*time.Minute)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Verify = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
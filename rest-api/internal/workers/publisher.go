@@ -0,0 +1,113 @@
+package workers
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"github.com/exzacter/gorestapi/internal/feed"
+	"github.com/exzacter/gorestapi/internal/sse"
+	"github.com/exzacter/gorestapi/internal/store"
+	"github.com/exzacter/gorestapi/internal/tracing"
+	"github.com/exzacter/gorestapi/internal/webhooks"
+)
+
+const publishBatchSize = 100
+
+// Publisher moves scheduled blogs to published once their publish_at has passed.
+// PublishDueBlogs claims rows with FOR UPDATE SKIP LOCKED, so every instance of the api can run one
+// without a blog being published twice
+type Publisher struct {
+	db       *sql.DB
+	stream   *sse.Broker
+	feed     *feed.Feed
+	interval time.Duration
+}
+
+func NewPublisher(db *sql.DB, stream *sse.Broker, feeds *feed.Feed, interval time.Duration) *Publisher {
+	return &Publisher{
+		db:       db,
+		stream:   stream,
+		feed:     feeds,
+		interval: interval,
+	}
+}
+
+// Run checks for due blogs every interval until ctx is cancelled
+func (p *Publisher) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.publishDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Publisher) publishDue(ctx context.Context) {
+	// keep going while full batches come back so a backlog clears in one tick
+	for {
+		published, err := p.publishBatch(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Error publishing scheduled blogs: %v", err)
+			}
+			return
+		}
+
+		for _, blog := range published {
+			log.Printf("Published scheduled blog %d", blog.ID)
+
+			// after the commit, and unlike the webhook it is fine to lose one if redis is down
+			if err := p.stream.Publish(ctx, sse.EventBlogPublished, sse.Blog{
+				ID:     blog.ID,
+				Title:  blog.Title,
+				UserID: blog.UserID,
+			}); err != nil {
+				log.Printf("Failed to publish stream event for blog %d: %v", blog.ID, err)
+			}
+			if err := p.feed.Published(ctx, blog.UserID, blog.ID, blog.PublishAt.Time); err != nil {
+				log.Printf("Failed to queue the feed fan-out of blog %d: %v", blog.ID, err)
+			}
+		}
+
+		if len(published) < publishBatchSize {
+			return
+		}
+	}
+}
+
+// publishes one batch and queues the blog.published webhooks in the same transaction
+func (p *Publisher) publishBatch(ctx context.Context) ([]store.PublishDueBlogsRow, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := store.New(tracing.WrapDB(tx))
+
+	published, err := q.PublishDueBlogs(ctx, publishBatchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, blog := range published {
+		if err := webhooks.Enqueue(ctx, q, webhooks.EventBlogPublished, webhooks.BlogPublished{
+			ID:        blog.ID,
+			Title:     blog.Title,
+			UserID:    blog.UserID,
+			PublishAt: blog.PublishAt.Time,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	return published, tx.Commit()
+}
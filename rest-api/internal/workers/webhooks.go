@@ -0,0 +1,120 @@
+package workers
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/exzacter/gorestapi/internal/store"
+	"github.com/exzacter/gorestapi/internal/webhooks"
+)
+
+const webhookBatchSize = 20
+
+// the queries the dispatcher runs, *store.Queries in the api
+type deliveryStore interface {
+	ClaimDueWebhookDeliveries(ctx context.Context, limit int32) ([]store.ClaimDueWebhookDeliveriesRow, error)
+	MarkWebhookDeliverySucceeded(ctx context.Context, arg store.MarkWebhookDeliverySucceededParams) error
+	MarkWebhookDeliveryFailed(ctx context.Context, arg store.MarkWebhookDeliveryFailedParams) error
+}
+
+// WebhookDispatcher sends queued webhook deliveries. like the Publisher, rows are claimed with
+// FOR UPDATE SKIP LOCKED so every instance of the api can run one
+type WebhookDispatcher struct {
+	queries  deliveryStore
+	sender   *webhooks.Sender
+	policy   webhooks.RetryPolicy
+	interval time.Duration
+}
+
+func NewWebhookDispatcher(queries *store.Queries, sender *webhooks.Sender, policy webhooks.RetryPolicy, interval time.Duration) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		queries:  queries,
+		sender:   sender,
+		policy:   policy,
+		interval: interval,
+	}
+}
+
+// Run sends due deliveries every interval until ctx is cancelled
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		d.dispatchDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *WebhookDispatcher) dispatchDue(ctx context.Context) {
+	for {
+		due, err := d.queries.ClaimDueWebhookDeliveries(ctx, webhookBatchSize)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Error claiming webhook deliveries: %v", err)
+			}
+			return
+		}
+
+		// one slow receiver shouldn't hold up the rest of the batch
+		var wg sync.WaitGroup
+		for _, delivery := range due {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				d.deliver(ctx, delivery)
+			}()
+		}
+		wg.Wait()
+
+		if len(due) < webhookBatchSize || ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+func (d *WebhookDispatcher) deliver(ctx context.Context, delivery store.ClaimDueWebhookDeliveriesRow) {
+	status, sendErr := d.sender.Send(ctx, delivery.Url, delivery.Secret, delivery.ID, delivery.Event, delivery.Payload)
+
+	// interrupted by shutdown, the claim runs out and another run picks the delivery up again
+	if sendErr != nil && ctx.Err() != nil {
+		return
+	}
+
+	statusCode := sql.NullInt32{Int32: int32(status), Valid: status != 0}
+
+	if sendErr == nil {
+		if err := d.queries.MarkWebhookDeliverySucceeded(ctx, store.MarkWebhookDeliverySucceededParams{
+			ID:             delivery.ID,
+			LastStatusCode: statusCode,
+		}); err != nil {
+			log.Printf("Error recording webhook delivery %d: %v", delivery.ID, err)
+		}
+		return
+	}
+
+	delay, dead := d.policy.Next(delivery.Attempts)
+	state := "pending"
+	if dead {
+		state = "dead"
+		log.Printf("Webhook delivery %d is dead after %d attempts: %v", delivery.ID, delivery.Attempts, sendErr)
+	}
+
+	if err := d.queries.MarkWebhookDeliveryFailed(ctx, store.MarkWebhookDeliveryFailedParams{
+		ID:             delivery.ID,
+		Status:         state,
+		LastStatusCode: statusCode,
+		LastError:      sql.NullString{String: sendErr.Error(), Valid: true},
+		NextAttemptAt:  time.Now().Add(delay),
+	}); err != nil {
+		log.Printf("Error recording webhook delivery %d: %v", delivery.ID, err)
+	}
+}
@@ -0,0 +1,143 @@
+package workers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/exzacter/gorestapi/internal/store"
+	"github.com/exzacter/gorestapi/internal/webhooks"
+)
+
+// hands out the queued deliveries once and records what the dispatcher made of them
+type fakeDeliveries struct {
+	mu        sync.Mutex
+	due       []store.ClaimDueWebhookDeliveriesRow
+	succeeded []store.MarkWebhookDeliverySucceededParams
+	failed    []store.MarkWebhookDeliveryFailedParams
+}
+
+func (f *fakeDeliveries) ClaimDueWebhookDeliveries(ctx context.Context, limit int32) ([]store.ClaimDueWebhookDeliveriesRow, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	due := f.due
+	f.due = nil
+	return due, nil
+}
+
+func (f *fakeDeliveries) MarkWebhookDeliverySucceeded(ctx context.Context, arg store.MarkWebhookDeliverySucceededParams) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.succeeded = append(f.succeeded, arg)
+	return nil
+}
+
+func (f *fakeDeliveries) MarkWebhookDeliveryFailed(ctx context.Context, arg store.MarkWebhookDeliveryFailedParams) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.failed = append(f.failed, arg)
+	return nil
+}
+
+var testRetryPolicy = webhooks.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Minute, MaxDelay: time.Hour}
+
+func newTestDispatcher(deliveries *fakeDeliveries) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		queries:  deliveries,
+		sender:   webhooks.NewSender(time.Second),
+		policy:   testRetryPolicy,
+		interval: time.Minute,
+	}
+}
+
+func TestDispatcherSucceeds(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	deliveries := &fakeDeliveries{due: []store.ClaimDueWebhookDeliveriesRow{
+		{ID: 1, Event: "blog.created", Payload: []byte(`{}`), Attempts: 1, Url: receiver.URL, Secret: "whsec_test"},
+	}}
+	newTestDispatcher(deliveries).dispatchDue(context.Background())
+
+	if len(deliveries.succeeded) != 1 || len(deliveries.failed) != 0 {
+		t.Fatalf("got %d succeeded and %d failed, want 1 and 0", len(deliveries.succeeded), len(deliveries.failed))
+	}
+	if code := deliveries.succeeded[0].LastStatusCode; !code.Valid || code.Int32 != http.StatusNoContent {
+		t.Fatalf("recorded status %+v, want %d", code, http.StatusNoContent)
+	}
+}
+
+func TestDispatcherRetriesAndDeadLetters(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer receiver.Close()
+
+	tests := []struct {
+		name     string
+		attempts int32
+		status   string
+	}{
+		{"first failure is retried", 1, "pending"},
+		{"last attempt is dead", testRetryPolicy.MaxAttempts, "dead"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deliveries := &fakeDeliveries{due: []store.ClaimDueWebhookDeliveriesRow{
+				{ID: 9, Event: "blog.created", Payload: []byte(`{}`), Attempts: tt.attempts, Url: receiver.URL, Secret: "whsec_test"},
+			}}
+
+			before := time.Now()
+			newTestDispatcher(deliveries).dispatchDue(context.Background())
+
+			if len(deliveries.failed) != 1 || len(deliveries.succeeded) != 0 {
+				t.Fatalf("got %d failed and %d succeeded, want 1 and 0", len(deliveries.failed), len(deliveries.succeeded))
+			}
+
+			failed := deliveries.failed[0]
+			if failed.Status != tt.status {
+				t.Fatalf("status = %q, want %q", failed.Status, tt.status)
+			}
+			if !failed.LastStatusCode.Valid || failed.LastStatusCode.Int32 != http.StatusServiceUnavailable {
+				t.Fatalf("recorded status %+v, want %d", failed.LastStatusCode, http.StatusServiceUnavailable)
+			}
+			if !failed.LastError.Valid {
+				t.Fatal("the error wasn't recorded")
+			}
+
+			if tt.status == "pending" && failed.NextAttemptAt.Before(before.Add(testRetryPolicy.BaseDelay)) {
+				t.Fatalf("retry at %v, want at least %v later", failed.NextAttemptAt, testRetryPolicy.BaseDelay)
+			}
+		})
+	}
+}
+
+func TestDispatcherLeavesDeliveriesInterruptedByShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	release := make(chan struct{})
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cancel()
+		<-release
+	}))
+	defer receiver.Close()
+	defer close(release)
+
+	deliveries := &fakeDeliveries{due: []store.ClaimDueWebhookDeliveriesRow{
+		{ID: 3, Event: "blog.created", Payload: []byte(`{}`), Attempts: 1, Url: receiver.URL, Secret: "whsec_test"},
+	}}
+	newTestDispatcher(deliveries).dispatchDue(ctx)
+
+	// the claim runs out and another run sends it, recording a failure would use up an attempt
+	if len(deliveries.failed) != 0 || len(deliveries.succeeded) != 0 {
+		t.Fatalf("got %d failed and %d succeeded, want nothing recorded", len(deliveries.failed), len(deliveries.succeeded))
+	}
+}
@@ -1,15 +1,31 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/exzacter/gorestapi/internal/dbconfig"
+	"github.com/exzacter/gorestapi/internal/exports"
+	"github.com/exzacter/gorestapi/internal/feed"
 	"github.com/exzacter/gorestapi/internal/handlers"
+	"github.com/exzacter/gorestapi/internal/jobs"
+	"github.com/exzacter/gorestapi/internal/mailer"
+	"github.com/exzacter/gorestapi/internal/metrics"
+	"github.com/exzacter/gorestapi/internal/middlewares"
+	"github.com/exzacter/gorestapi/internal/password"
 	"github.com/exzacter/gorestapi/internal/routes"
 	"github.com/exzacter/gorestapi/internal/serverconfig"
+	"github.com/exzacter/gorestapi/internal/sse"
+	"github.com/exzacter/gorestapi/internal/storage"
 	"github.com/exzacter/gorestapi/internal/store"
+	"github.com/exzacter/gorestapi/internal/tracing"
+	"github.com/exzacter/gorestapi/internal/webhooks"
+	"github.com/exzacter/gorestapi/internal/workers"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -26,17 +42,112 @@ func main() {
 	db := dbconfig.ConnectDB(config.DatabaseURL)
 	defer db.Close()
 
-	// connect to redis
-	rdb := dbconfig.ConnectRedis()
+	// connect to redis, behind a circuit breaker so an outage degrades the api instead of stopping it
+	rdb := dbconfig.ConnectRedis(config)
 	defer func(rdb *redis.Client) {
 		_ = rdb.Close()
 	}(rdb)
 
-	// initialises sqlc queries
-	queries := store.New(db)
+	// pool stats for /metrics, redis command timings and spans
+	metrics.RegisterDB(db, "postgres")
+	rdb.AddHook(metrics.RedisHook{})
+	rdb.AddHook(tracing.RedisHook{})
+
+	// initialises sqlc queries, every query gets a span through the wrapped db
+	queries := store.New(tracing.WrapDB(db))
+	// AuthMiddle looks api keys up through these, like it uses dbconfig.RedisClient for the blacklist
+	middlewares.Queries = queries
+	switch config.RedisFailMode {
+	case "open":
+		middlewares.RedisFailOpen = true
+	case "closed":
+		middlewares.RedisFailOpen = false
+	default:
+		log.Fatalf("Unknown REDIS_FAIL_MODE %q, expected open or closed", config.RedisFailMode)
+	}
+
+	// cancelled on ctrl+c or SIGTERM so the server and background workers stop together
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// sends spans to the exporter set by TRACING_EXPORTER, flushed on the way out
+	shutdownTracing, err := tracing.Setup(ctx, config)
+	if err != nil {
+		log.Fatalf("Failed to set up tracing %v", err)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			log.Printf("Tracing shutdown failed %v", err)
+		}
+	}()
+
+	// live blog activity, shared between instances through redis pub/sub. Run ends the open streams on
+	// shutdown so the server isn't left waiting on them
+	stream := sse.NewBroker(rdb, "blog-events", config.SSEStreamMaxLen)
+	go stream.Run(ctx)
+
+	// sends queued webhook deliveries, retrying failures with backoff until they are marked dead
+	retryPolicy := webhooks.DefaultRetryPolicy
+	retryPolicy.MaxAttempts = int32(config.WebhookMaxAttempts)
+	dispatcher := workers.NewWebhookDispatcher(queries, webhooks.NewSender(config.WebhookTimeout), retryPolicy, config.WebhookInterval)
+	go dispatcher.Run(ctx)
+
+	// where uploads such as avatars are stored, local disk or an s3 compatible bucket
+	blobStore, err := storage.Open(ctx, config)
+	if err != nil {
+		log.Fatalf("Failed to open storage %v", err)
+	}
+
+	// background jobs live in redis. handlers enqueue through the queue, the worker runs them and on
+	// shutdown gets JOBS_SHUTDOWN_TIMEOUT to finish what it is running before the process exits
+	jobDriver := jobs.NewRedis(rdb, "jobs")
+	jobQueue := jobs.NewQueue(jobDriver)
+	jobWorker := jobs.NewWorker(jobDriver, jobs.WorkerOptions{
+		Concurrency:     int(config.JobsConcurrency),
+		PollInterval:    config.JobsPollInterval,
+		Visibility:      config.JobsVisibilityTimeout,
+		ShutdownTimeout: config.JobsShutdownTimeout,
+	})
+	// personal data exports are built and later deleted by jobs
+	exports.NewExporter(queries, blobStore, rdb, jobQueue, config.ExportLinkTTL).Register(jobWorker)
+
+	// mail such as login links is sent by a job, through smtp or to the log
+	mail, err := mailer.Open(config)
+	if err != nil {
+		log.Fatalf("Failed to set up mail %v", err)
+	}
+	mailer.Register(jobWorker, mail, rdb)
+
+	// feeds are built on read, users following many get theirs cached and kept current by a fan-out job
+	feedCacheMinFollowing := config.FeedCacheMinFollowing
+	if !config.FeedCache {
+		feedCacheMinFollowing = 0
+	}
+	feeds := feed.New(queries, rdb, jobQueue, feed.Options{
+		CacheMinFollowing: feedCacheMinFollowing,
+		CacheSize:         config.FeedCacheSize,
+		CacheTTL:          config.FeedCacheTTL,
+	})
+	feeds.Register(jobWorker)
+
+	// publishes scheduled blogs when they are due, safe to run on every instance
+	publisher := workers.NewPublisher(db, stream, feeds, config.PublishInterval)
+	go publisher.Run(ctx)
+
+	jobsDone := make(chan struct{})
+	go func() {
+		jobWorker.Run(ctx)
+		close(jobsDone)
+	}()
+
+	// hashing params and the policy for new passwords, including the breached list if one is configured
+	passwords, err := password.FromConfig(config)
+	if err != nil {
+		log.Fatalf("Failed to set up passwords %v", err)
+	}
 
 	// thisis calling the core_handler which in future will hold our connections to DB and other things we are dependant on
-	handler := handlers.NewHandlers(db, queries, rdb)
+	handler := handlers.NewHandlers(db, queries, rdb, blobStore, jobQueue, passwords, stream, feeds, config)
 
 	// mux or NewServeMux is the router. It maps the url path from the request and can point them to the function to handle it
 	mux := http.NewServeMux()
@@ -44,17 +155,62 @@ func main() {
 	// calls the setuproutes function within routes. the setup routes function registers all of the functions and URL's being called within it
 	routes.SetupRoutes(mux, handler)
 
+	// /metrics goes on the admin port when one is set, otherwise alongside the api
+	metricsServer := &http.Server{Addr: config.MetricsAddr}
+	if config.MetricsAddr == "" {
+		mux.Handle("GET /metrics", metrics.Handler())
+	} else {
+		adminMux := http.NewServeMux()
+		adminMux.Handle("GET /metrics", metrics.Handler())
+		metricsServer.Handler = adminMux
+
+		go func() {
+			log.Printf("Metrics listening on %s", config.MetricsAddr)
+			if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Fatalf("Metrics server failed %v", err)
+			}
+		}()
+	}
+
 	// setting serverAddr variable to the value of the string from config.ServerPort which is set in the config.go file in serverconfig folder
 	serverAddr := fmt.Sprintf(":%s", config.ServerPort)
+	// cors wraps the whole mux so preflight OPTIONS requests are answered before routing
+	cors := middlewares.CORS(middlewares.CORSOptions{
+		AllowedOrigins:   config.CORSAllowedOrigins,
+		AllowedMethods:   config.CORSAllowedMethods,
+		AllowedHeaders:   config.CORSAllowedHeaders,
+		ExposedHeaders:   config.CORSExposedHeaders,
+		AllowCredentials: config.CORSAllowCredentials,
+		MaxAge:           config.CORSMaxAge,
+	})
+
+	// gzip or deflate for clients that accept it. the metrics inside it still see the handler's status
+	compress := middlewares.Compress(middlewares.CompressOptions{
+		MinSize: int(config.CompressMinSize),
+	})
+
 	// telling server, to run on the port specified in the serverAddr and then all requests to go through mux (router)
 	server := &http.Server{
 		Addr:    serverAddr,
-		Handler: mux,
+		Handler: cors(tracing.Middleware(compress(metrics.Instrument(mux)))),
 	}
 
+	// stop accepting requests once a shutdown signal arrives, ListenAndServe then returns ErrServerClosed
+	go func() {
+		<-ctx.Done()
+		if err := server.Shutdown(context.Background()); err != nil {
+			log.Printf("Server shutdown failed %v", err)
+		}
+		if err := metricsServer.Shutdown(context.Background()); err != nil {
+			log.Printf("Metrics server shutdown failed %v", err)
+		}
+	}()
+
 	fmt.Printf("Server has been started on %s\n", serverAddr)
 	fmt.Printf("Testing new air backend")
 	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 		log.Fatalf("Server failed %v", err)
 	}
+
+	<-jobsDone
 }